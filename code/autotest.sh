@@ -33,10 +33,24 @@ for i in {1..5}; do
   fi
 done
 
+# Login as admin
+echo -e "\n-----"
+echo -e "${YELLOW}Logging in as admin${NC}"
+TOKEN=$(curl -s -X 'POST' --location 'localhost:8000/v1/auth/login' \
+--header 'Content-Type: application/json' \
+--data-raw '{
+    "document": "97580053080",
+    "password": "local-only-admin-password"
+}' | jq -r '.token')
+echo "$TOKEN"
+sleep 2
+
 # Get admin user
+echo -e "\n-----"
 echo -e "${YELLOW}Getting admin user${NC}"
 curl -s -X 'POST' --location 'localhost:8000/v1/users/validate' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
     "document": "97580053080"
 }' | jq
@@ -47,9 +61,10 @@ echo -e "\n-----"
 echo -e "${YELLOW}Creating User${NC}"
 curl -s --location 'localhost:8000/v1/users' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
     "Name": "Test User",
-    "Document": "97580053081",
+    "Document": "975.800.531-60",
     "Email": "test@user.com"
 }' | jq
 sleep 2
@@ -59,8 +74,9 @@ echo -e "\n-----"
 echo -e "${YELLOW}Getting user by document${NC}"
 curl -s -X 'POST' --location 'localhost:8000/v1/users/validate' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
-    "document": "97580053081"
+    "document": "97580053160"
 }' | jq
 sleep 2
 
@@ -73,9 +89,9 @@ echo -e "\n-----"
 echo -e "${YELLOW}Creating Category${NC}"
 CATEGORY=$(curl -s --location 'localhost:8000/v1/categories' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data '{
-    "name": "Category Test",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "name": "Category Test"
 }')
 echo -e "$CATEGORY" | jq
 CATEGORY_ID=$(echo $CATEGORY | jq -r '.id')
@@ -96,9 +112,9 @@ echo -e "\n-----"
 echo -e "${YELLOW}Deleting Category${NC}"
 curl -s --location --request DELETE 'localhost:8000/v1/categories' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
-    "id": "'$CATEGORY_ID'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "id": "'$CATEGORY_ID'"
 }'
 sleep 2
 
@@ -130,12 +146,12 @@ echo -e "${YELLOW}Creating Product${NC}"
 CATEGORY_ID_DRINK="a557b0c0-3bcf-11ee-be56-0242ac120002"
 PRODUCT=$(curl -s --location 'localhost:8000/v1/products' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data '{
     "name": "Coca Cola",
     "description": "Coca Cola 350ml",
     "price": "5.00",
-    "category_id": "'$CATEGORY_ID_DRINK'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "category_id": "'$CATEGORY_ID_DRINK'"
 }')
 echo -e "$PRODUCT" | jq
 PRODUCT_ID=$(echo $PRODUCT | jq -r '.id')
@@ -146,13 +162,13 @@ echo -e "\n-----"
 echo -e "${YELLOW}Editing Product${NC}"
 curl -s --location --request PUT 'localhost:8000/v1/products' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
     "id": "'$PRODUCT_ID'",
     "name": "Coca Cola 350ml",
     "description": "Coca Cola 350ml Lata",
     "price": "5.00",
-    "category_id": "'$CATEGORY_ID_DRINK'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "category_id": "'$CATEGORY_ID_DRINK'"
 }' | jq .
 sleep 2
 
@@ -171,12 +187,12 @@ echo -e "\n-----"
 echo -e "${YELLOW}Creating Product 2${NC}"
 PRODUCT2=$(curl -s --location 'localhost:8000/v1/products' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data '{
     "name": "Coca Cola 2L",
     "description": "Coca Cola 2L",
     "price": "5.00",
-    "category_id": "'$CATEGORY_ID_DRINK'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "category_id": "'$CATEGORY_ID_DRINK'"
 }')
 echo -e "$PRODUCT2" | jq
 PRODUCT_ID2=$(echo $PRODUCT2 | jq -r '.id')
@@ -187,12 +203,12 @@ echo -e "\n-----"
 echo -e "${YELLOW}Creating Product 3${NC}"
 PRODUCT3=$(curl -s --location 'localhost:8000/v1/products' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data '{
     "name": "Coca Cola 1L",
     "description": "Coca Cola 1L",
     "price": "4.00",
-    "category_id": "'$CATEGORY_ID_DRINK'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "category_id": "'$CATEGORY_ID_DRINK'"
 }')
 echo -e "$PRODUCT3" | jq
 PRODUCT_ID3=$(echo $PRODUCT3 | jq -r '.id')
@@ -215,9 +231,9 @@ echo -e "\n-----"
 echo -e "${YELLOW}Deleting Product${NC}"
 curl -s --location --request DELETE 'localhost:8000/v1/products' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
-    "id": "'$PRODUCT_ID'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "id": "'$PRODUCT_ID'"
 }'
 sleep 2
 
@@ -233,12 +249,12 @@ echo -e "\n-----"
 echo -e "${YELLOW}Creating Order${NC}"
 ORDER=$(curl -s --location --request POST 'localhost:8000/v1/orders' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
    "products_ids": [
       "'$PRODUCT_ID2'",
       "'$PRODUCT_ID3'"
-   ],
-   "user_id": "123e4567-e89b-12d3-a456-426614174000"
+   ]
 }')
 echo -e "$ORDER" | jq
 ORDER_ID=$(echo $ORDER | jq -r '.id')
@@ -251,9 +267,9 @@ echo -e "${YELLOW}Creating Checkout - FASE 2${NC}"
 echo -e "${GREEN} Item 1 da entrega ${NC}"
 CHECKOUT=$(curl -s --location --request POST 'http://localhost:8000/v1/orders/checkout' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
-    "order_id": "'$ORDER_ID'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "order_id": "'$ORDER_ID'"
 }')
 echo -e "$CHECKOUT" | jq
 PAYMENT_ID=$(echo $CHECKOUT | jq -r '.payment_info.payment_id')
@@ -267,6 +283,7 @@ echo -e "${YELLOW}Paying Order using webhook - FASE 2 ${NC}"
 echo -e "${GREEN} Item 3 da entrega ${NC}"
 PAYMENT=$(curl -s --location --request POST 'http://localhost:8000/v1/webhook/payment-notification' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
     "approved": true,
     "payment_id": "'$PAYMENT_ID'",
@@ -281,9 +298,9 @@ echo -e "${YELLOW}Getting Order after payment - FASE 2 ${NC}"
 echo -e "${GREEN} Item 2 da entrega ${NC}"
 ORDER=$(curl -s -X 'POST' 'http://localhost:8000/v1/orders/get' \
 --header 'Content-Type: application/json' \
+--header "Authorization: Bearer $TOKEN" \
 --data-raw '{
-    "id": "'$ORDER_ID'",
-    "user_id": "123e4567-e89b-12d3-a456-426614174000"
+    "id": "'$ORDER_ID'"
 }')
 echo "$ORDER" | jq
 sleep 2
@@ -295,10 +312,10 @@ ORDERS=$(curl -s -X 'POST' \
   'http://localhost:8000/v1/orders/all' \
   -H 'accept: application/json' \
   -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer $TOKEN" \
   -d '{
   "limit": 10,
-  "offset": 0,
-  "user_id": "123e4567-e89b-12d3-a456-426614174000"
+  "offset": 0
 }')
 if command -v jq &> /dev/null; then
     echo $ORDERS | jq
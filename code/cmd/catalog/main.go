@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/usecases"
+	pgxrepo "github.com/SOAT1StackGoLang/tech-challenge/internal/repositories/postgres"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Imports or exports the product catalog straight from the database.
+//
+//	catalog -user <admin id> -import menu.csv [-dry-run]
+//	catalog -user <admin id> -export menu.json
+func main() {
+	var (
+		userID     string
+		importFile string
+		exportFile string
+		format     string
+		dryRun     bool
+	)
+	flag.StringVar(&userID, "user", "", "ID of the admin user running the import/export")
+	flag.StringVar(&importFile, "import", "", "file to import categories and products from")
+	flag.StringVar(&exportFile, "export", "", "file to export categories and products to")
+	flag.StringVar(&format, "format", "", "csv or json, defaults to the file extension")
+	flag.BoolVar(&dryRun, "dry-run", false, "run the import and roll it back, reporting what it would do without writing")
+	flag.Parse()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		fail("invalid -user: %s", err)
+	}
+	if (importFile == "") == (exportFile == "") {
+		fail("exactly one of -import or -export must be given")
+	}
+
+	godotenv.Load()
+	helpers.ReadPgxConnEnvs()
+	gormDB, err := gorm.Open(postgres.Open(helpers.ToDsnWithDbName()), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		fail("failed connecting to database: %s", err)
+	}
+
+	log := helpers.NewLogger()
+	auditRepo := pgxrepo.NewPgxAuditRepository(gormDB, log)
+	uow := pgxrepo.NewPgxUnitOfWork(gormDB)
+	userUseCase := usecases.NewUsersUseCase(
+		pgxrepo.NewPgxUsersRepository(gormDB, log),
+		pgxrepo.NewPgxGuestSessionsRepository(gormDB, log),
+		auditRepo,
+		uow,
+		log,
+	)
+	prodRepo := pgxrepo.NewPgxProductsRepository(gormDB, log)
+	prodUseCase := usecases.NewProductsUseCase(prodRepo, pgxrepo.NewPgxProductPricesRepository(gormDB, log), auditRepo, uow, userUseCase, log)
+	catalogUseCase := usecases.NewCatalogUseCase(
+		log,
+		pgxrepo.NewPgxCategoriesRepository(gormDB, log),
+		prodRepo,
+		auditRepo,
+		prodUseCase,
+		userUseCase,
+		uow,
+	)
+
+	ctx := domain.ContextWithCaller(context.Background(), domain.Caller{UserID: uid})
+
+	if exportFile != "" {
+		out, err := os.Create(exportFile)
+		if err != nil {
+			fail("failed creating %s: %s", exportFile, err)
+		}
+		defer out.Close()
+
+		if err = catalogUseCase.ExportCatalog(ctx, fileFormat(format, exportFile), out); err != nil {
+			fail("failed exporting catalog: %s", err)
+		}
+		return
+	}
+
+	in, err := os.Open(importFile)
+	if err != nil {
+		fail("failed opening %s: %s", importFile, err)
+	}
+	defer in.Close()
+
+	report, err := catalogUseCase.ImportCatalog(ctx, fileFormat(format, importFile), in, dryRun)
+	if err != nil {
+		fail("failed importing catalog: %s", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+
+	if report.Failed > 0 {
+		os.Exit(2)
+	}
+}
+
+func fileFormat(format, file string) domain.CatalogFormat {
+	if format != "" {
+		return domain.CatalogFormat(strings.ToLower(format))
+	}
+	return domain.CatalogFormat(strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), "."))
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	// adminUserID is the admin seeded by 02_insert_admin_user.
+	adminUserID            = "123e4567-e89b-12d3-a456-426614174000"
+	minAdminPasswordLength = 12
+)
+
+// bootstrapAdminPassword gives the seeded admin its first password, read from ADMIN_PASSWORD. It only applies
+// while the admin has no password, so a password changed later is kept and the variable can be removed.
+func bootstrapAdminPassword(dsn string) error {
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		return nil
+	}
+	if len(password) < minAdminPasswordLength {
+		return fmt.Errorf("ADMIN_PASSWORD must have at least %d characters", minAdminPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	result := db.Exec(
+		"update public.lanchonete_users set password_hash = ? where id = ? and password_hash is null",
+		string(hash), adminUserID,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		println("admin password set from ADMIN_PASSWORD")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+create table public.lanchonete_product_prices
+(
+    id             uuid           not null,
+    created_at     timestamptz    not null,
+    product_id     uuid           not null,
+    price          numeric(10, 2) not null,
+    effective_from timestamptz    not null,
+    applied_at     timestamptz,
+    created_by     uuid,
+
+    constraint lanchonete_product_prices_pk
+        PRIMARY KEY (id)
+);
+
+alter table public.lanchonete_product_prices
+    add constraint fk_product_price_product_id
+        foreign key (product_id)
+            references public.lanchonete_products (id)
+            on delete cascade;
+
+alter table public.lanchonete_product_prices
+    add constraint fk_product_price_created_by
+        foreign key (created_by)
+            references public.lanchonete_users (id);
+
+create index lanchonete_product_prices_product_effective_index
+    on public.lanchonete_product_prices using BTREE (product_id, effective_from);
+
+create index lanchonete_product_prices_pending_index
+    on public.lanchonete_product_prices using BTREE (effective_from)
+    where applied_at is null;
+
+-- current prices become the first entry of each product's history
+insert into public.lanchonete_product_prices (id, created_at, product_id, price, effective_from, applied_at)
+select gen_random_uuid(), now(), id, price, created_at, created_at
+from public.lanchonete_products;
@@ -0,0 +1,16 @@
+alter table public.lanchonete_products
+    add column deleted_at timestamptz;
+
+alter table public.lanchonete_categories
+    add column deleted_at timestamptz;
+
+-- names only need to be unique among categories still in use
+drop index public.lanchonete_categories_name_index;
+
+create unique index lanchonete_categories_name_index
+    on public.lanchonete_categories using BTREE (name)
+    where deleted_at is null;
+
+create index lanchonete_products_category_index
+    on public.lanchonete_products using BTREE (category_id)
+    where deleted_at is null;
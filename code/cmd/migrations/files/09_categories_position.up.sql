@@ -0,0 +1,8 @@
+alter table public.lanchonete_categories
+    add column position int default 0 not null;
+
+update public.lanchonete_categories c
+set position = ordered.position
+from (select id, row_number() over (order by created_at, name) as position
+      from public.lanchonete_categories) ordered
+where c.id = ordered.id;
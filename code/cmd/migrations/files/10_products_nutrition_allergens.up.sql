@@ -0,0 +1,5 @@
+alter table public.lanchonete_products
+    add column nutrition jsonb;
+
+alter table public.lanchonete_products
+    add column allergens jsonb default '[]' not null;
@@ -0,0 +1,2 @@
+alter table public.lanchonete_users
+    add column password_hash varchar(72);
@@ -0,0 +1,42 @@
+create table public.lanchonete_roles
+(
+    name        varchar(20)  not null,
+    description varchar(100) not null,
+
+    constraint lanchonete_roles_pk
+        PRIMARY KEY (name)
+);
+
+insert into public.lanchonete_roles (name, description)
+values ('customer', 'Cliente, acessa apenas os próprios pedidos'),
+       ('cashier', 'Caixa, atende pedidos de qualquer cliente'),
+       ('kitchen', 'Cozinha, acompanha e avança o status dos pedidos'),
+       ('manager', 'Gerente, administra cardápio e pedidos'),
+       ('admin', 'Administrador, acesso total incluindo papéis');
+
+create table public.lanchonete_user_roles
+(
+    user_id    uuid        not null,
+    role       varchar(20) not null,
+    granted_at timestamptz not null,
+    granted_by uuid,
+
+    constraint lanchonete_user_roles_pk
+        PRIMARY KEY (user_id, role),
+    constraint lanchonete_user_roles_user_fk
+        FOREIGN KEY (user_id) REFERENCES public.lanchonete_users (id),
+    constraint lanchonete_user_roles_role_fk
+        FOREIGN KEY (role) REFERENCES public.lanchonete_roles (name)
+);
+
+insert into public.lanchonete_user_roles (user_id, role, granted_at)
+select id, 'customer', now()
+from public.lanchonete_users;
+
+insert into public.lanchonete_user_roles (user_id, role, granted_at)
+select id, 'admin', now()
+from public.lanchonete_users
+where is_admin;
+
+alter table public.lanchonete_users
+    drop column is_admin;
@@ -0,0 +1,48 @@
+-- documents are stored with digits only, see domain.NormalizeCPF
+
+-- the same CPF may be stored both formatted and with digits only. Such duplicates are merged, before
+-- normalising, into the account still active, then the one with a password, then the oldest
+create temporary table lanchonete_users_duplicates as
+select id,
+       first_value(id) over (partition by regexp_replace(document, '[^0-9]', '', 'g')
+           order by deleted_at is not null, password_hash is null, created_at, id) as kept_id
+from public.lanchonete_users;
+
+delete
+from lanchonete_users_duplicates
+where id = kept_id;
+
+update public.lanchonete_orders o
+set user_id = d.kept_id
+from lanchonete_users_duplicates d
+where o.user_id = d.id;
+
+update public.lanchonete_product_prices p
+set created_by = d.kept_id
+from lanchonete_users_duplicates d
+where p.created_by = d.id;
+
+insert into public.lanchonete_user_roles (user_id, role, granted_at, granted_by)
+select d.kept_id, r.role, r.granted_at, r.granted_by
+from public.lanchonete_user_roles r
+         join lanchonete_users_duplicates d on r.user_id = d.id
+on conflict (user_id, role) do nothing;
+
+delete
+from public.lanchonete_user_roles r
+    using lanchonete_users_duplicates d
+where r.user_id = d.id;
+
+delete
+from public.lanchonete_users u
+    using lanchonete_users_duplicates d
+where u.id = d.id;
+
+drop table lanchonete_users_duplicates;
+
+update public.lanchonete_users
+set document = regexp_replace(document, '[^0-9]', '', 'g')
+where document ~ '[^0-9]';
+
+alter table public.lanchonete_users
+    alter column document type varchar(11);
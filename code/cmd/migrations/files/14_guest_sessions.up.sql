@@ -0,0 +1,25 @@
+create table public.lanchonete_guest_sessions
+(
+    id          uuid        not null,
+    created_at  timestamptz not null,
+    expires_at  timestamptz not null,
+    document    varchar(11),
+    merged_into uuid,
+    merged_at   timestamptz,
+
+    constraint lanchonete_guest_sessions_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_guest_sessions_user_fk
+        FOREIGN KEY (merged_into) REFERENCES public.lanchonete_users (id)
+);
+
+alter table public.lanchonete_orders
+    alter column user_id drop not null,
+    add column guest_session_id uuid
+        constraint fk_order_guest_session_id
+            references public.lanchonete_guest_sessions (id),
+    add constraint lanchonete_orders_owner_check
+        check (user_id is not null or guest_session_id is not null);
+
+create index lanchonete_orders_guest_session_index
+    on public.lanchonete_orders using BTREE (guest_session_id);
@@ -0,0 +1,33 @@
+-- LGPD data subject requests, kept as an audit log of every export and erasure
+create table public.lanchonete_data_subject_requests
+(
+    id           uuid        not null,
+    user_id      uuid        not null,
+    type         varchar(20) not null,
+    status       varchar(20) not null,
+    requested_by uuid        not null,
+    created_at   timestamptz not null,
+    completed_at timestamptz,
+    error        text,
+
+    constraint lanchonete_data_subject_requests_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_data_subject_requests_user_fk
+        FOREIGN KEY (user_id) REFERENCES public.lanchonete_users (id)
+);
+
+create index lanchonete_data_subject_requests_user_index
+    on public.lanchonete_data_subject_requests using BTREE (user_id);
+
+-- erased users keep their row, for the foreign keys, with the personal data cleared
+alter table public.lanchonete_users
+    alter column document drop not null,
+    alter column email drop not null,
+    add column anonymized_at timestamptz;
+
+-- orders of erased users are kept for the financial records, without an owner
+alter table public.lanchonete_orders
+    add column detached_at timestamptz,
+    drop constraint lanchonete_orders_owner_check,
+    add constraint lanchonete_orders_owner_check
+        check (user_id is not null or guest_session_id is not null or detached_at is not null);
@@ -0,0 +1,18 @@
+-- consent records are append only, the latest one per user and purpose is the current choice
+create table public.lanchonete_user_consents
+(
+    id         uuid        not null,
+    user_id    uuid        not null,
+    purpose    varchar(30) not null,
+    granted    boolean     not null,
+    channel    varchar(20) not null,
+    created_at timestamptz not null,
+
+    constraint lanchonete_user_consents_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_user_consents_user_fk
+        FOREIGN KEY (user_id) REFERENCES public.lanchonete_users (id)
+);
+
+create index lanchonete_user_consents_purpose_index
+    on public.lanchonete_user_consents using BTREE (purpose, user_id, created_at desc);
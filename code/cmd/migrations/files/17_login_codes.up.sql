@@ -0,0 +1,19 @@
+-- one-time codes emailed for the passwordless login, only their hash is stored
+create table public.lanchonete_login_codes
+(
+    id          uuid        not null,
+    user_id     uuid        not null,
+    code_hash   varchar(72) not null,
+    created_at  timestamptz not null,
+    expires_at  timestamptz not null,
+    attempts    int default 0 not null,
+    consumed_at timestamptz,
+
+    constraint lanchonete_login_codes_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_login_codes_user_fk
+        FOREIGN KEY (user_id) REFERENCES public.lanchonete_users (id)
+);
+
+create index lanchonete_login_codes_user_index
+    on public.lanchonete_login_codes using BTREE (user_id, created_at desc);
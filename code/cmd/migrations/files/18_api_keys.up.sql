@@ -0,0 +1,18 @@
+-- API keys of devices, only the sha256 of the key is stored, the prefix finds it
+create table public.lanchonete_api_keys
+(
+    id           uuid        not null,
+    name         varchar(100) not null,
+    prefix       varchar(16) unique not null,
+    key_hash     varchar(64) not null,
+    scopes       jsonb       not null,
+    created_by   uuid        not null,
+    created_at   timestamptz not null,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+
+    constraint lanchonete_api_keys_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_api_keys_created_by_fk
+        FOREIGN KEY (created_by) REFERENCES public.lanchonete_users (id)
+);
@@ -0,0 +1,33 @@
+-- Audit log of administrative changes, append only: a trigger refuses updates and deletes
+create table public.lanchonete_audit_log
+(
+    id               uuid        not null,
+    created_at       timestamptz not null,
+    actor_user_id    uuid,
+    actor_api_key_id uuid,
+    action           varchar(50) not null,
+    entity_type      varchar(30) not null,
+    entity_id        uuid,
+    before           jsonb,
+    after            jsonb,
+    request_id       varchar(100),
+
+    constraint lanchonete_audit_log_pk
+        PRIMARY KEY (id)
+);
+
+create index lanchonete_audit_log_created_at_idx on public.lanchonete_audit_log (created_at);
+create index lanchonete_audit_log_entity_idx on public.lanchonete_audit_log (entity_type, entity_id);
+
+create function public.lanchonete_audit_log_append_only() returns trigger as
+$$
+begin
+    raise exception 'lanchonete_audit_log is append only';
+end;
+$$ language plpgsql;
+
+create trigger lanchonete_audit_log_append_only
+    before update or delete
+    on public.lanchonete_audit_log
+    for each row
+execute function public.lanchonete_audit_log_append_only();
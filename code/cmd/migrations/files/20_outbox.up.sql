@@ -0,0 +1,18 @@
+-- Domain events written in the same transaction as the change that raised them, relayed to the event bus
+create table public.lanchonete_outbox
+(
+    id              uuid        not null,
+    event_type      varchar(50) not null,
+    payload         jsonb       not null,
+    request_id      varchar(100),
+    created_at      timestamptz not null,
+    attempts        integer     not null default 0,
+    next_attempt_at timestamptz not null,
+    last_error      text,
+    delivered_at    timestamptz,
+
+    constraint lanchonete_outbox_pk
+        PRIMARY KEY (id)
+);
+
+create index lanchonete_outbox_pending_idx on public.lanchonete_outbox (next_attempt_at) where delivered_at is null;
@@ -0,0 +1,47 @@
+-- Partner subscriptions to the domain events, the secret signs the deliveries so it is kept in clear
+create table public.lanchonete_webhook_subscriptions
+(
+    id          uuid         not null,
+    url         varchar(2048) not null,
+    event_types jsonb        not null,
+    secret      varchar(200) not null,
+    created_by  uuid         not null,
+    created_at  timestamptz  not null,
+    deleted_at  timestamptz,
+
+    constraint lanchonete_webhook_subscriptions_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_webhook_subscriptions_created_by_fk
+        FOREIGN KEY (created_by) REFERENCES public.lanchonete_users (id)
+);
+
+-- Delivery log, a row per event and subscription, dead letters are kept with status 'dead'
+create table public.lanchonete_webhook_deliveries
+(
+    id               uuid        not null,
+    subscription_id  uuid        not null,
+    event_id         uuid        not null,
+    event_type       varchar(50) not null,
+    event_version    integer     not null,
+    source           varchar(50) not null,
+    occurred_at      timestamptz not null,
+    request_id       varchar(100),
+    data             jsonb       not null,
+    status           varchar(20) not null,
+    attempts         integer     not null default 0,
+    next_attempt_at  timestamptz not null,
+    last_error       text,
+    last_status_code integer,
+    created_at       timestamptz not null,
+    delivered_at     timestamptz,
+
+    constraint lanchonete_webhook_deliveries_pk
+        PRIMARY KEY (id),
+    constraint lanchonete_webhook_deliveries_subscription_fk
+        FOREIGN KEY (subscription_id) REFERENCES public.lanchonete_webhook_subscriptions (id),
+    constraint lanchonete_webhook_deliveries_event_uk
+        UNIQUE (subscription_id, event_id)
+);
+
+create index lanchonete_webhook_deliveries_pending_idx on public.lanchonete_webhook_deliveries (next_attempt_at) where status = 'pending';
+create index lanchonete_webhook_deliveries_status_idx on public.lanchonete_webhook_deliveries (status, created_at);
@@ -0,0 +1,9 @@
+-- Optimistic concurrency, updates are made on the version read and bump it
+alter table public.lanchonete_orders
+    add column version integer not null default 1;
+
+alter table public.lanchonete_products
+    add column version integer not null default 1;
+
+alter table public.lanchonete_payments
+    add column version integer not null default 1;
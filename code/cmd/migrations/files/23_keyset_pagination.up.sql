@@ -0,0 +1,12 @@
+-- Keyset pagination goes by (created_at, id) after the cursor, within the owner or category listed
+create index lanchonete_orders_user_keyset_index
+    on public.lanchonete_orders using BTREE (user_id, created_at, id);
+
+create index lanchonete_orders_guest_session_keyset_index
+    on public.lanchonete_orders using BTREE (guest_session_id, created_at, id);
+
+create index lanchonete_orders_keyset_index
+    on public.lanchonete_orders using BTREE (created_at, id);
+
+create index lanchonete_products_category_keyset_index
+    on public.lanchonete_products using BTREE (category_id, created_at, id);
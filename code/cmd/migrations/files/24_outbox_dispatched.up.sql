@@ -0,0 +1,8 @@
+-- The relay records the dispatch to the subscribers of the service apart from the publish to the broker,
+-- so retrying a failed publish does not run the subscribers again
+alter table public.lanchonete_outbox
+    add column dispatched_at timestamptz;
+
+update public.lanchonete_outbox
+set dispatched_at = delivered_at
+where delivered_at is not null;
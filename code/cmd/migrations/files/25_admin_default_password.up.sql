@@ -0,0 +1,6 @@
+-- earlier versions of 11_users_password gave the seeded admin the well-known password "admin123",
+-- deployments still using it go back to no password until ADMIN_PASSWORD sets one
+update public.lanchonete_users
+set password_hash = null
+where id = '123e4567-e89b-12d3-a456-426614174000'
+  and password_hash = '$2a$10$GXQqVLShQ7O57iqnnZbLG.beRjsYuKT1/d.CxpKO.CrYAREf9nuXO';
@@ -44,4 +44,8 @@ func main() {
 	} else {
 		println(fmt.Sprintf("last applied %d", applied))
 	}
+
+	if err = bootstrapAdminPassword(dsn); err != nil {
+		panic(fmt.Sprintf("Error setting the admin password: %s", err.Error()))
+	}
 }
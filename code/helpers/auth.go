@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	defaultTokenTTL = 12 * time.Hour
+	// minTokenSecretLength is the size of the HS256 hash, shorter secrets are easier to guess.
+	minTokenSecretLength = 32
+)
+
+var (
+	tokenSecret []byte
+	tokenTTL    time.Duration
+)
+
+// ReadAuthEnvs reads the token settings. It fails without a JWT_SECRET of at least minTokenSecretLength
+// bytes, since anyone could forge tokens signed with an empty or short key.
+func ReadAuthEnvs() error {
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < minTokenSecretLength {
+		return fmt.Errorf("JWT_SECRET must be set, with at least %d characters", minTokenSecretLength)
+	}
+	tokenSecret = []byte(secret)
+	ttl, err := time.ParseDuration(os.Getenv("JWT_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	tokenTTL = ttl
+	return nil
+}
+
+// TokenSecret is the key used to sign the session tokens.
+func TokenSecret() []byte {
+	return tokenSecret
+}
+
+// TokenTTL is how long a session token is valid after login.
+func TokenTTL() time.Duration {
+	return tokenTTL
+}
@@ -0,0 +1,23 @@
+package helpers
+
+import "testing"
+
+func TestReadAuthEnvs(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "001_should_refuse_empty_secret", secret: "", wantErr: true},
+		{name: "002_should_refuse_short_secret", secret: "change-me", wantErr: true},
+		{name: "003_should_accept_long_secret", secret: "local-only-secret-change-me-in-production"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", tt.secret)
+			if err := ReadAuthEnvs(); (err != nil) != tt.wantErr {
+				t.Errorf("ReadAuthEnvs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,8 +1,151 @@
 package helpers
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 var ErrUnauthorized = errors.New("user is not authorize to access this resource")
+var ErrForbidden = errors.New("user is not allowed to perform this action")
 var ErrInvalidCurrencyFormat = errors.New("invalid currency format. Expected 'R$ X,XX'")
 var ErrBadRequest = errors.New("bad request")
 var ErrInvalidInput = errors.New("invalid input")
+var ErrNotFound = errors.New("not found")
+var ErrConflict = errors.New("conflict")
+var ErrVersionConflict = errors.New("version conflict")
+var ErrTooManyRequests = errors.New("too many requests")
+
+// TooManyRequestsError refuses a request over a rate limit, until RetryAfter has passed. It matches
+// ErrTooManyRequests with errors.Is.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+}
+
+func NewTooManyRequestsError(retryAfter time.Duration) *TooManyRequestsError {
+	return &TooManyRequestsError{RetryAfter: retryAfter}
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("too many requests, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyRequestsError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+// NotFoundError reports a resource missing, or hidden by a soft delete. It matches ErrNotFound with errors.Is.
+// ID is uuid.Nil when the resource was looked up by something else, such as a document or a name.
+type NotFoundError struct {
+	Resource string
+	ID       uuid.UUID
+}
+
+func NewNotFoundError(resource string, ID uuid.UUID) *NotFoundError {
+	return &NotFoundError{Resource: resource, ID: ID}
+}
+
+func (e *NotFoundError) Error() string {
+	if e.ID == uuid.Nil {
+		return fmt.Sprintf("%s not found", e.Resource)
+	}
+	return fmt.Sprintf("%s %s not found", e.Resource, e.ID)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ForbiddenError reports an authenticated caller lacking the permission for an action. It matches ErrForbidden
+// with errors.Is, ErrUnauthorized is left for callers not authenticated at all.
+type ForbiddenError struct {
+	Permission string
+}
+
+func NewForbiddenError(permission string) *ForbiddenError {
+	return &ForbiddenError{Permission: permission}
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("missing permission %s", e.Permission)
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// ConflictError reports a request that cannot be fulfilled in the current state of a resource.
+// It matches ErrConflict with errors.Is.
+type ConflictError struct {
+	Resource string
+	Reason   string
+}
+
+func NewConflictError(resource, reason string) *ConflictError {
+	return &ConflictError{Resource: resource, Reason: reason}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflict: %s", e.Resource, e.Reason)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// VersionConflictError reports a change made on an outdated version of a resource, changed by someone
+// else since it was read. It matches ErrVersionConflict and ErrConflict with errors.Is.
+type VersionConflictError struct {
+	Resource string
+	ID       uuid.UUID
+	Version  int
+}
+
+func NewVersionConflictError(resource string, ID uuid.UUID, version int) *VersionConflictError {
+	return &VersionConflictError{Resource: resource, ID: ID, Version: version}
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %s was changed since version %d", e.Resource, e.ID, e.Version)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict || target == ErrConflict
+}
+
+// ValidationError reports an input field with an invalid value. It matches ErrInvalidInput with errors.Is.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func NewValidationError(field, reason string) *ValidationError {
+	return &ValidationError{Field: field, Reason: reason}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// ValidationErrors gathers every invalid field of an input, so they are all reported at once. It matches
+// ErrInvalidInput with errors.Is.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	reasons := make([]string, 0, len(e))
+	for _, err := range e {
+		reasons = append(reasons, err.Error())
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidInput
+}
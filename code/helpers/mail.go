@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"os"
+	"strconv"
+)
+
+const defaultSMTPPort = 1025
+
+var (
+	smtpHost     string
+	smtpPort     int
+	smtpFrom     string
+	smtpUser     string
+	smtpPassword string
+)
+
+// ReadMailEnvs reads the SMTP server settings. Without a user the server is used without authentication,
+// as local SMTP sinks are.
+func ReadMailEnvs() {
+	smtpHost = os.Getenv("SMTP_HOST")
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = defaultSMTPPort
+	}
+	smtpPort = port
+	smtpFrom = os.Getenv("SMTP_FROM")
+	smtpUser = os.Getenv("SMTP_USER")
+	smtpPassword = os.Getenv("SMTP_PASSWORD")
+}
+
+func SMTPHost() string {
+	return smtpHost
+}
+
+func SMTPPort() int {
+	return smtpPort
+}
+
+// SMTPFrom is the sender address of the emails.
+func SMTPFrom() string {
+	return smtpFrom
+}
+
+func SMTPUser() string {
+	return smtpUser
+}
+
+func SMTPPassword() string {
+	return smtpPassword
+}
@@ -0,0 +1,59 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// CONTENT_TYPE is the content type of the encoded envelopes.
+const CONTENT_TYPE = "application/json"
+
+// envelope is the wire format of domain.EventEnvelope, the contract with the services consuming the events.
+type envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	Source     string          `json:"source"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// EncodeEnvelope writes the envelope in its wire format, also used for the webhook deliveries.
+func EncodeEnvelope(in domain.EventEnvelope) ([]byte, error) {
+	return json.Marshal(envelope{
+		ID:         in.ID,
+		Type:       string(in.Type),
+		Version:    in.Version,
+		Source:     in.Source,
+		OccurredAt: in.OccurredAt,
+		RequestID:  in.RequestID,
+		Data:       in.Data,
+	})
+}
+
+func decodeEnvelope(body []byte) (domain.EventEnvelope, error) {
+	var in envelope
+	if err := json.Unmarshal(body, &in); err != nil {
+		return domain.EventEnvelope{}, err
+	}
+
+	return domain.EventEnvelope{
+		ID:         in.ID,
+		Type:       domain.EventType(in.Type),
+		Version:    in.Version,
+		Source:     in.Source,
+		OccurredAt: in.OccurredAt,
+		RequestID:  in.RequestID,
+		Data:       in.Data,
+	}, nil
+}
+
+// handlerContext carries the request ID of the envelope, correlating the logs of both services.
+func handlerContext(ctx context.Context, in domain.EventEnvelope) context.Context {
+	return domain.ContextWithRequestID(ctx, in.RequestID)
+}
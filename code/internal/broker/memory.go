@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+var ErrBrokerClosed = errors.New("message broker is closed")
+
+type memoryQueue struct {
+	name     string
+	handlers []ports.BrokerHandler
+	next     int
+}
+
+type memoryBroker struct {
+	logger *zap.SugaredLogger
+
+	mu     sync.Mutex
+	closed bool
+	queues map[domain.EventType][]*memoryQueue
+}
+
+// NewMemoryBroker delivers the envelopes within the process, for tests and for running without a broker.
+// Envelopes are encoded and decoded as on the wire and handled before Publish returns. Like a queue in
+// RabbitMQ, a queue with several handlers hands each envelope to one of them, and a failed envelope is
+// redelivered once.
+func NewMemoryBroker(logger *zap.SugaredLogger) ports.MessageBroker {
+	return &memoryBroker{logger: logger, queues: make(map[domain.EventType][]*memoryQueue)}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, in domain.EventEnvelope) error {
+	body, err := EncodeEnvelope(in)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	var handlers []ports.BrokerHandler
+	for _, q := range b.queues[in.Type] {
+		handlers = append(handlers, q.handlers[q.next%len(q.handlers)])
+		q.next++
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		b.deliver(ctx, body, handler)
+	}
+
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(eventType domain.EventType, queue string, handler ports.BrokerHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	for _, q := range b.queues[eventType] {
+		if q.name == queue {
+			q.handlers = append(q.handlers, handler)
+			return nil
+		}
+	}
+	b.queues[eventType] = append(b.queues[eventType], &memoryQueue{name: queue, handlers: []ports.BrokerHandler{handler}})
+
+	return nil
+}
+
+func (b *memoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return nil
+}
+
+func (b *memoryBroker) deliver(ctx context.Context, body []byte, handler ports.BrokerHandler) {
+	in, err := decodeEnvelope(body)
+	if err != nil {
+		b.logger.Errorw("failed decoding event envelope", zap.Error(err))
+		return
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = handler(handlerContext(ctx, in), in); err == nil {
+			return
+		}
+		b.logger.Errorw(
+			"event envelope handler failed",
+			zap.String("id", in.ID.String()),
+			zap.String("event_type", string(in.Type)),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,72 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	tests := []struct {
+		name      string
+		queues    []string
+		failures  int
+		wantCalls int
+	}{
+		{name: "001_should_deliver_to_every_queue", queues: []string{"kitchen", "billing"}, wantCalls: 2},
+		{name: "002_should_deliver_once_to_competing_handlers", queues: []string{"kitchen", "kitchen"}, wantCalls: 1},
+		{name: "003_should_redeliver_failed_envelope_once", queues: []string{"kitchen"}, failures: 5, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewMemoryBroker(zap.NewNop().Sugar())
+			defer broker.Close()
+
+			event := domain.OrderCreated{OrderID: uuid.New(), OccurredAt: time.Now().UTC()}
+			payload, _ := json.Marshal(event)
+			want := domain.EventEnvelope{
+				ID:         uuid.New(),
+				Type:       event.EventType(),
+				Version:    domain.EVENT_ENVELOPE_VERSION,
+				Source:     "lanchonete",
+				OccurredAt: event.OccurredAt,
+				RequestID:  "request",
+				Data:       payload,
+			}
+
+			calls, failures := 0, tt.failures
+			for _, queue := range tt.queues {
+				err := broker.Subscribe(domain.EVENT_ORDER_CREATED, queue, func(ctx context.Context, got domain.EventEnvelope) error {
+					calls++
+					if got.ID != want.ID || got.Version != want.Version || domain.RequestIDFromContext(ctx) != want.RequestID {
+						t.Errorf("handled %+v, want %+v", got, want)
+					}
+					if decoded, err := got.Event(); err != nil || decoded.(domain.OrderCreated).OrderID != event.OrderID {
+						t.Errorf("Event() = %v, %v, want %v", decoded, err, event)
+					}
+					if failures > 0 {
+						failures--
+						return errors.New("failed")
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("Subscribe() error = %v", err)
+				}
+			}
+
+			if err := broker.Publish(context.Background(), want); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handled %d envelopes, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	// consumerPrefetch is how many unacknowledged envelopes a consumer holds at a time.
+	consumerPrefetch = 10
+	// resubscribeDelay is how long a consumer waits between attempts to subscribe again after losing the connection.
+	resubscribeDelay = 2 * time.Second
+)
+
+var ErrPublishNacked = errors.New("message broker refused the envelope")
+
+type rabbitMQBroker struct {
+	logger   *zap.SugaredLogger
+	url      string
+	exchange string
+
+	mu      sync.Mutex
+	closed  bool
+	done    chan struct{}
+	conn    *amqp.Connection
+	publish *amqp.Channel
+
+	consumers        sync.WaitGroup
+	resubscribeDelay time.Duration
+}
+
+// subscription is kept to declare, bind and consume the queue again when the connection is lost.
+type subscription struct {
+	eventType domain.EventType
+	queue     string
+	handler   ports.BrokerHandler
+}
+
+// NewRabbitMQBroker publishes the envelopes as persistent messages to a durable topic exchange, routed by
+// the event type, waiting for the broker to confirm each one. The connection is dialed again when lost,
+// and the consumers subscribe again once it is back.
+func NewRabbitMQBroker(logger *zap.SugaredLogger, url, exchange string) (ports.MessageBroker, error) {
+	b := &rabbitMQBroker{logger: logger, url: url, exchange: exchange, done: make(chan struct{}), resubscribeDelay: resubscribeDelay}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.publishChannel(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *rabbitMQBroker) Publish(ctx context.Context, in domain.EventEnvelope) error {
+	body, err := EncodeEnvelope(in)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	ch, err := b.publishChannel()
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, b.exchange, string(in.Type), false, false, amqp.Publishing{
+		ContentType:  CONTENT_TYPE,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    in.ID.String(),
+		Type:         string(in.Type),
+		Timestamp:    in.OccurredAt,
+		AppId:        in.Source,
+		Body:         body,
+	})
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed publishing %s: %w", in.Type, err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed confirming %s: %w", in.Type, err)
+	}
+	if !acked {
+		return fmt.Errorf("publishing %s: %w", in.Type, ErrPublishNacked)
+	}
+
+	return nil
+}
+
+// Subscribe declares the durable queue, bound to the event type, and consumes it in the background.
+// Envelopes are acknowledged once handled. A failed one is redelivered once, then dropped, as are the
+// envelopes that can not be decoded. When the connection is lost the queue is declared, bound and consumed
+// again as soon as the broker is back.
+func (b *rabbitMQBroker) Subscribe(eventType domain.EventType, queue string, handler ports.BrokerHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	sub := subscription{eventType: eventType, queue: queue, handler: handler}
+	deliveries, err := b.subscribe(sub)
+	if err != nil {
+		return err
+	}
+
+	b.consumers.Add(1)
+	go b.consume(sub, deliveries)
+
+	return nil
+}
+
+// subscribe declares and binds the queue of sub on its own channel and starts consuming it, dialing the
+// broker again when the connection was lost. The caller holds b.mu.
+func (b *rabbitMQBroker) subscribe(sub subscription) (<-chan amqp.Delivery, error) {
+	if _, err := b.publishChannel(); err != nil {
+		return nil, err
+	}
+
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err = ch.Qos(consumerPrefetch, 0, false); err != nil {
+		_ = ch.Close()
+		return nil, err
+	}
+	if _, err = ch.QueueDeclare(sub.queue, true, false, false, false, nil); err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("failed declaring queue %s: %w", sub.queue, err)
+	}
+	if err = ch.QueueBind(sub.queue, string(sub.eventType), b.exchange, false, nil); err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("failed binding queue %s: %w", sub.queue, err)
+	}
+	deliveries, err := ch.Consume(sub.queue, "", false, false, false, false, nil)
+	if err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("failed consuming queue %s: %w", sub.queue, err)
+	}
+
+	return deliveries, nil
+}
+
+// Close closes the connection, stopping the consumers once they finish the envelope being handled.
+func (b *rabbitMQBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	var err error
+	if b.conn != nil && !b.conn.IsClosed() {
+		err = b.conn.Close()
+	}
+	b.mu.Unlock()
+
+	b.consumers.Wait()
+	return err
+}
+
+// publishChannel returns the channel in confirm mode used to publish, dialing the broker again when the
+// connection was lost. The caller holds b.mu.
+func (b *rabbitMQBroker) publishChannel() (*amqp.Channel, error) {
+	if b.publish != nil && !b.publish.IsClosed() {
+		return b.publish, nil
+	}
+
+	if b.conn == nil || b.conn.IsClosed() {
+		conn, err := amqp.Dial(b.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed connecting to message broker: %w", err)
+		}
+		b.conn = conn
+	}
+
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err = ch.ExchangeDeclare(b.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("failed declaring exchange %s: %w", b.exchange, err)
+	}
+	if err = ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, err
+	}
+	b.publish = ch
+
+	return ch, nil
+}
+
+// consume handles the deliveries of sub until the broker is closed, subscribing again whenever the
+// deliveries stop because the connection or the channel was lost.
+func (b *rabbitMQBroker) consume(sub subscription, deliveries <-chan amqp.Delivery) {
+	defer b.consumers.Done()
+
+	for {
+		b.handleDeliveries(sub, deliveries)
+
+		var ok bool
+		if deliveries, ok = b.resubscribe(sub); !ok {
+			return
+		}
+	}
+}
+
+func (b *rabbitMQBroker) handleDeliveries(sub subscription, deliveries <-chan amqp.Delivery) {
+	for d := range deliveries {
+		in, err := decodeEnvelope(d.Body)
+		if err != nil {
+			b.logger.Errorw("failed decoding event envelope", zap.String("queue", sub.queue), zap.String("message_id", d.MessageId), zap.Error(err))
+			_ = d.Nack(false, false)
+			continue
+		}
+
+		if err = sub.handler(handlerContext(context.Background(), in), in); err != nil {
+			b.logger.Errorw(
+				"event envelope handler failed",
+				zap.String("queue", sub.queue),
+				zap.String("id", in.ID.String()),
+				zap.String("event_type", string(in.Type)),
+				zap.Bool("redelivered", d.Redelivered),
+				zap.Error(err),
+			)
+			_ = d.Nack(false, !d.Redelivered)
+			continue
+		}
+		_ = d.Ack(false)
+	}
+}
+
+// resubscribe tries to subscribe sub again every resubscribeDelay, until it succeeds or the broker is closed.
+func (b *rabbitMQBroker) resubscribe(sub subscription) (<-chan amqp.Delivery, bool) {
+	select {
+	case <-b.done:
+		return nil, false
+	default:
+		b.logger.Errorw("message broker consumer stopped, subscribing again", zap.String("queue", sub.queue))
+	}
+
+	for {
+		select {
+		case <-b.done:
+			return nil, false
+		case <-time.After(b.resubscribeDelay):
+		}
+
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return nil, false
+		}
+		deliveries, err := b.subscribe(sub)
+		b.mu.Unlock()
+		if err != nil {
+			b.logger.Errorw("failed subscribing again to message broker", zap.String("queue", sub.queue), zap.Error(err))
+			continue
+		}
+
+		b.logger.Infow("message broker consumer subscribed again", zap.String("queue", sub.queue))
+		return deliveries, true
+	}
+}
@@ -0,0 +1,116 @@
+package broker
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TestRabbitMQBroker runs against the broker at BROKER_URL, such as the one in devsecops/local.
+func TestRabbitMQBroker(t *testing.T) {
+	url := os.Getenv("BROKER_URL")
+	if url == "" {
+		t.Skip("BROKER_URL not set")
+	}
+
+	exchange := "lanchonete.events.test." + uuid.NewString()
+	broker, err := NewRabbitMQBroker(zap.NewNop().Sugar(), url, exchange)
+	if err != nil {
+		t.Fatalf("NewRabbitMQBroker() error = %v", err)
+	}
+	defer broker.Close()
+
+	received := make(chan domain.EventEnvelope, 1)
+	err = broker.Subscribe(domain.EVENT_ORDER_STATUS_CHANGED, exchange, func(ctx context.Context, in domain.EventEnvelope) error {
+		received <- in
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	want := domain.EventEnvelope{
+		ID:         uuid.New(),
+		Type:       domain.EVENT_ORDER_STATUS_CHANGED,
+		Version:    domain.EVENT_ENVELOPE_VERSION,
+		Source:     "lanchonete",
+		OccurredAt: time.Now().UTC(),
+		Data:       []byte(`{"status":"Recebido"}`),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = broker.Publish(ctx, want); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.ID != want.ID || got.Type != want.Type {
+			t.Errorf("received %+v, want %+v", got, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("envelope not received")
+	}
+}
+
+// TestRabbitMQBrokerResubscribes drops the connection under a subscription and expects the consumer to get
+// the envelopes published once the connection is back.
+func TestRabbitMQBrokerResubscribes(t *testing.T) {
+	url := os.Getenv("BROKER_URL")
+	if url == "" {
+		t.Skip("BROKER_URL not set")
+	}
+
+	exchange := "lanchonete.events.test." + uuid.NewString()
+	broker, err := NewRabbitMQBroker(zap.NewNop().Sugar(), url, exchange)
+	if err != nil {
+		t.Fatalf("NewRabbitMQBroker() error = %v", err)
+	}
+	defer broker.Close()
+	rabbit := broker.(*rabbitMQBroker)
+	rabbit.resubscribeDelay = 100 * time.Millisecond
+
+	received := make(chan domain.EventEnvelope, 1)
+	err = broker.Subscribe(domain.EVENT_ORDER_STATUS_CHANGED, exchange, func(ctx context.Context, in domain.EventEnvelope) error {
+		received <- in
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	rabbit.mu.Lock()
+	err = rabbit.conn.Close()
+	rabbit.mu.Unlock()
+	if err != nil {
+		t.Fatalf("closing connection error = %v", err)
+	}
+
+	want := domain.EventEnvelope{
+		ID:         uuid.New(),
+		Type:       domain.EVENT_ORDER_STATUS_CHANGED,
+		Version:    domain.EVENT_ENVELOPE_VERSION,
+		Source:     "lanchonete",
+		OccurredAt: time.Now().UTC(),
+		Data:       []byte(`{"status":"Recebido"}`),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = broker.Publish(ctx, want); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.ID != want.ID {
+			t.Errorf("received %+v, want %+v", got, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("envelope not received after the connection was dropped")
+	}
+}
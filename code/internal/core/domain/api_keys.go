@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates a device, such as a kiosk, a kitchen display or the payment gateway, instead of a person.
+// Its scopes are the permissions it was given, roles do not apply. Only the hash of the key is stored, the key
+// itself is shown once, on creation.
+type APIKey struct {
+	ID         uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []Permission
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+	Key        string
+}
+
+func NewAPIKey(ID uuid.UUID, name string, scopes []Permission, createdBy uuid.UUID, createdAt time.Time) *APIKey {
+	return &APIKey{ID: ID, Name: name, Scopes: scopes, CreatedBy: createdBy, CreatedAt: createdAt}
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction is an administrative change recorded in the audit log.
+type AuditAction string
+
+const (
+	AUDIT_PRODUCT_CREATE         AuditAction = "product.create"
+	AUDIT_PRODUCT_UPDATE                     = "product.update"
+	AUDIT_PRODUCT_DELETE                     = "product.delete"
+	AUDIT_PRODUCT_RESTORE                    = "product.restore"
+	AUDIT_PRODUCT_SCHEDULE_PRICE             = "product.schedule_price"
+	AUDIT_CATEGORY_CREATE                    = "category.create"
+	AUDIT_CATEGORY_UPDATE                    = "category.update"
+	AUDIT_CATEGORY_REORDER                   = "category.reorder"
+	AUDIT_CATEGORY_DELETE                    = "category.delete"
+	AUDIT_CATEGORY_RESTORE                   = "category.restore"
+	AUDIT_CATALOG_IMPORT                     = "catalog.import"
+	AUDIT_ORDER_UPDATE_STATUS                = "order.update_status"
+	AUDIT_ORDER_DELETE                       = "order.delete"
+	AUDIT_PAYMENT_UPDATE_STATUS              = "payment.update_status"
+	AUDIT_USER_GRANT_ROLE                    = "user.grant_role"
+	AUDIT_USER_REVOKE_ROLE                   = "user.revoke_role"
+	AUDIT_USER_ERASE                         = "user.erase"
+	AUDIT_API_KEY_CREATE                     = "api_key.create"
+	AUDIT_API_KEY_REVOKE                     = "api_key.revoke"
+	AUDIT_WEBHOOK_CREATE                     = "webhook.create"
+	AUDIT_WEBHOOK_DELETE                     = "webhook.delete"
+	AUDIT_WEBHOOK_RETRY_DELIVERY             = "webhook.retry_delivery"
+)
+
+// AuditEntityType is the kind of record changed by an audited action.
+type AuditEntityType string
+
+const (
+	AUDIT_ENTITY_PRODUCT  AuditEntityType = "product"
+	AUDIT_ENTITY_CATEGORY                 = "category"
+	AUDIT_ENTITY_CATALOG                  = "catalog"
+	AUDIT_ENTITY_ORDER                    = "order"
+	AUDIT_ENTITY_PAYMENT                  = "payment"
+	AUDIT_ENTITY_USER                     = "user"
+	AUDIT_ENTITY_API_KEY                  = "api_key"
+	AUDIT_ENTITY_WEBHOOK                  = "webhook"
+)
+
+// AuditEntry records who changed what, with the state of the record before and after the change.
+// Entries are never updated nor deleted.
+type AuditEntry struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ActorUserID   uuid.UUID
+	ActorAPIKeyID uuid.UUID
+	Action        AuditAction
+	EntityType    AuditEntityType
+	EntityID      uuid.UUID
+	Before        json.RawMessage
+	After         json.RawMessage
+	RequestID     string
+}
+
+func NewAuditEntry(ID uuid.UUID, createdAt time.Time, action AuditAction, entityType AuditEntityType, entityID uuid.UUID) *AuditEntry {
+	return &AuditEntry{ID: ID, CreatedAt: createdAt, Action: action, EntityType: entityType, EntityID: entityID}
+}
+
+// AuditFilter narrows the audit log listing, zero values match everything.
+type AuditFilter struct {
+	ActorID    uuid.UUID
+	Action     AuditAction
+	EntityType AuditEntityType
+	EntityID   uuid.UUID
+	From, To   time.Time
+	RequestID  string
+}
+
+type AuditList struct {
+	Entries       []*AuditEntry
+	Limit, Offset int
+	Total         int64
+}
+
+type requestIDContextKey struct{}
+
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID correlating the logs and audit entries of a request, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
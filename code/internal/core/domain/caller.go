@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Caller is the authenticated identity behind a request, either a registered user, a guest session
+// or a device authenticated by an API key.
+type Caller struct {
+	UserID         uuid.UUID
+	GuestSessionID uuid.UUID
+	APIKeyID       uuid.UUID
+	Scopes         []Permission
+}
+
+// IsGuest tells whether the caller is an anonymous kiosk session instead of a registered user.
+func (c Caller) IsGuest() bool {
+	return c.UserID == uuid.Nil && c.GuestSessionID != uuid.Nil
+}
+
+// IsAPIKey tells whether the caller is a device authenticated by an API key, limited to its scopes.
+func (c Caller) IsAPIKey() bool {
+	return c.APIKeyID != uuid.Nil
+}
+
+// HasScope tells whether the API key of the caller was given the permission.
+func (c Caller) HasScope(p Permission) bool {
+	for _, v := range c.Scopes {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+type AuthToken struct {
+	Token          string
+	UserID         uuid.UUID
+	GuestSessionID uuid.UUID
+	ExpiresAt      time.Time
+}
+
+// GuestSession lets customers order at the kiosk without registering. The CPF may be attached
+// later for the fiscal receipt, and the orders are moved to the user account on registration.
+type GuestSession struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Document   string
+	MergedInto uuid.UUID
+	MergedAt   time.Time
+}
+
+func NewGuestSession(ID uuid.UUID, createdAt, expiresAt time.Time) *GuestSession {
+	return &GuestSession{ID: ID, CreatedAt: createdAt, ExpiresAt: expiresAt}
+}
+
+type callerContextKey struct{}
+
+func ContextWithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, caller)
+}
+
+// CallerFromContext returns the caller authenticated for the request, if any.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerContextKey{}).(Caller)
+	return caller, ok && (caller.UserID != uuid.Nil || caller.GuestSessionID != uuid.Nil || caller.APIKeyID != uuid.Nil)
+}
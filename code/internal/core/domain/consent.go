@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/google/uuid"
+)
+
+// ConsentPurpose is a use of the customer contact data that requires their opt-in.
+type ConsentPurpose string
+
+const (
+	CONSENT_PURPOSE_EMAIL_PROMOTIONS ConsentPurpose = "email_promotions"
+	CONSENT_PURPOSE_SMS_PROMOTIONS                  = "sms_promotions"
+	CONSENT_PURPOSE_PERSONALIZED                    = "personalized_offers"
+)
+
+var ConsentPurposes = []ConsentPurpose{CONSENT_PURPOSE_EMAIL_PROMOTIONS, CONSENT_PURPOSE_SMS_PROMOTIONS, CONSENT_PURPOSE_PERSONALIZED}
+
+func (p ConsentPurpose) IsValid() bool {
+	for _, v := range ConsentPurposes {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsentChannel is where the customer granted or revoked the consent.
+type ConsentChannel string
+
+const (
+	CONSENT_CHANNEL_KIOSK   ConsentChannel = "kiosk"
+	CONSENT_CHANNEL_WEB                    = "web"
+	CONSENT_CHANNEL_APP                    = "app"
+	CONSENT_CHANNEL_COUNTER                = "counter"
+)
+
+var ConsentChannels = []ConsentChannel{CONSENT_CHANNEL_KIOSK, CONSENT_CHANNEL_WEB, CONSENT_CHANNEL_APP, CONSENT_CHANNEL_COUNTER}
+
+func (c ConsentChannel) IsValid() bool {
+	for _, v := range ConsentChannels {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Consent records a customer granting or revoking a purpose. Records are never changed, the latest one
+// per purpose is the current choice, and without any the customer has not opted in.
+type Consent struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   ConsentPurpose
+	Granted   bool
+	Channel   ConsentChannel
+	CreatedAt time.Time
+}
+
+func NewConsent(ID, userID uuid.UUID, purpose ConsentPurpose, granted bool, channel ConsentChannel, createdAt time.Time) *Consent {
+	return &Consent{ID: ID, UserID: userID, Purpose: purpose, Granted: granted, Channel: channel, CreatedAt: createdAt}
+}
+
+// Validate checks the purpose and the channel.
+func (c *Consent) Validate() error {
+	if !c.Purpose.IsValid() {
+		return helpers.NewValidationError("purpose", "unknown consent purpose")
+	}
+	if !c.Channel.IsValid() {
+		return helpers.NewValidationError("channel", "unknown consent channel")
+	}
+	return nil
+}
+
+// Contact is a customer eligible to be contacted for a purpose.
+type Contact struct {
+	UserID uuid.UUID
+	Name   string
+	Email  string
+}
+
+type ContactList struct {
+	Contacts      []*Contact
+	Limit, Offset int
+	Total         int64
+}
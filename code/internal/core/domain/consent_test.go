@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+)
+
+func TestConsentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		consent Consent
+		wantErr bool
+	}{
+		{name: "001_should_accept_known_purpose_and_channel", consent: Consent{Purpose: CONSENT_PURPOSE_EMAIL_PROMOTIONS, Granted: true, Channel: CONSENT_CHANNEL_KIOSK}},
+		{name: "002_should_refuse_unknown_purpose", consent: Consent{Purpose: "calls", Channel: CONSENT_CHANNEL_WEB}, wantErr: true},
+		{name: "003_should_refuse_missing_channel", consent: Consent{Purpose: CONSENT_PURPOSE_SMS_PROMOTIONS}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.consent.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, helpers.ErrInvalidInput) {
+				t.Errorf("Validate() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
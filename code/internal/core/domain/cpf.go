@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+)
+
+const cpfLength = 11
+
+// NormalizeCPF returns the CPF with digits only, the form it is stored and looked up in.
+// Dots, dashes and spaces are accepted in the input, and the check digits are verified.
+func NormalizeCPF(document string) (string, error) {
+	var b strings.Builder
+	for _, r := range document {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == ' ':
+		default:
+			return "", helpers.NewValidationError("document", "CPF must have only digits, dots and dash")
+		}
+	}
+
+	cpf := b.String()
+	if len(cpf) != cpfLength {
+		return "", helpers.NewValidationError("document", "CPF must have 11 digits")
+	}
+	if strings.Count(cpf, cpf[:1]) == cpfLength {
+		return "", helpers.NewValidationError("document", "CPF with all digits equal is not valid")
+	}
+	if cpfCheckDigit(cpf[:9]) != cpf[9] || cpfCheckDigit(cpf[:10]) != cpf[10] {
+		return "", helpers.NewValidationError("document", "CPF check digits do not match")
+	}
+
+	return cpf, nil
+}
+
+// FormatCPF formats a normalized CPF for display, as 975.800.530-80.
+// Anything else is returned unchanged.
+func FormatCPF(cpf string) string {
+	if len(cpf) != cpfLength {
+		return cpf
+	}
+	return cpf[:3] + "." + cpf[3:6] + "." + cpf[6:9] + "-" + cpf[9:]
+}
+
+func cpfCheckDigit(digits string) byte {
+	sum := 0
+	weight := len(digits) + 1
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weight
+		weight--
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+)
+
+func TestNormalizeCPF(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+		wantErr  bool
+	}{
+		{name: "001_should_accept_digits", document: "97580053080", want: "97580053080"},
+		{name: "002_should_strip_formatting", document: "975.800.530-80", want: "97580053080"},
+		{name: "003_should_accept_second_digit_zero", document: "123.456.789-09", want: "12345678909"},
+		{name: "004_should_refuse_wrong_check_digit", document: "97580053081", wantErr: true},
+		{name: "005_should_refuse_repeated_digits", document: "111.111.111-11", wantErr: true},
+		{name: "006_should_refuse_short", document: "9758005308", wantErr: true},
+		{name: "007_should_refuse_letters", document: "9758005308a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeCPF(tt.document)
+			if tt.wantErr {
+				if !errors.Is(err, helpers.ErrInvalidInput) {
+					t.Errorf("NormalizeCPF() error = %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeCPF() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeCPF() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCPF(t *testing.T) {
+	if got := FormatCPF("97580053080"); got != "975.800.530-80" {
+		t.Errorf("FormatCPF() = %v, want 975.800.530-80", got)
+	}
+}
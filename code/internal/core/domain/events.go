@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type EventType string
+
+const (
+	EVENT_PAYMENT_STATUS_CHANGED EventType = "payment.status_changed"
+	EVENT_ORDER_CREATED                    = "order.created"
+	EVENT_ORDER_STATUS_CHANGED             = "order.status_changed"
+)
+
+// EVENT_ENVELOPE_VERSION is the version of the envelope and event payloads sent to other services. It changes
+// whenever a payload changes in a way consumers can not ignore.
+const EVENT_ENVELOPE_VERSION = 1
+
+// Event is a fact that happened in the domain, delivered by the event bus to the subscribers of its type.
+// Events also travel as JSON, through the outbox and the message broker, so their fields are tagged.
+type Event interface {
+	EventType() EventType
+}
+
+// PaymentStatusChanged is published when the gateway notifies a payment, so the order follows its payment.
+type PaymentStatusChanged struct {
+	PaymentID  uuid.UUID     `json:"payment_id"`
+	OrderID    uuid.UUID     `json:"order_id"`
+	Status     PaymentStatus `json:"status"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+func (e PaymentStatusChanged) EventType() EventType {
+	return EVENT_PAYMENT_STATUS_CHANGED
+}
+
+type OrderCreated struct {
+	OrderID        uuid.UUID       `json:"order_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	GuestSessionID uuid.UUID       `json:"guest_session_id"`
+	Price          decimal.Decimal `json:"price"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+}
+
+func (e OrderCreated) EventType() EventType {
+	return EVENT_ORDER_CREATED
+}
+
+// OrderStatusChanged is published whenever an order moves, by checkout, payment or the kitchen.
+type OrderStatusChanged struct {
+	OrderID    uuid.UUID   `json:"order_id"`
+	PaymentID  uuid.UUID   `json:"payment_id"`
+	Status     OrderStatus `json:"status"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+func (e OrderStatusChanged) EventType() EventType {
+	return EVENT_ORDER_STATUS_CHANGED
+}
+
+// DecodeEvent rebuilds the typed event saved as JSON, such as in the outbox.
+func DecodeEvent(eventType EventType, payload []byte) (Event, error) {
+	var event Event
+	switch eventType {
+	case EVENT_PAYMENT_STATUS_CHANGED:
+		var e PaymentStatusChanged
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case EVENT_ORDER_CREATED:
+		var e OrderCreated
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	case EVENT_ORDER_STATUS_CHANGED:
+		var e OrderStatusChanged
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		event = e
+	default:
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	return event, nil
+}
+
+// EventEnvelope carries an event to other services through the message broker. The ID is the same on every
+// delivery of the event, for consumers to drop duplicates.
+type EventEnvelope struct {
+	ID         uuid.UUID
+	Type       EventType
+	Version    int
+	Source     string
+	OccurredAt time.Time
+	RequestID  string
+	Data       json.RawMessage
+}
+
+// NewEventEnvelope wraps an event saved to the outbox, keeping its ID across relay attempts.
+func NewEventEnvelope(event *OutboxEvent, source string) EventEnvelope {
+	return EventEnvelope{
+		ID:         event.ID,
+		Type:       event.EventType,
+		Version:    EVENT_ENVELOPE_VERSION,
+		Source:     source,
+		OccurredAt: event.CreatedAt,
+		RequestID:  event.RequestID,
+		Data:       event.Payload,
+	}
+}
+
+// Event decodes the carried event, refusing versions this service does not know.
+func (e EventEnvelope) Event() (Event, error) {
+	if e.Version != EVENT_ENVELOPE_VERSION {
+		return nil, fmt.Errorf("unsupported version %d of event %q", e.Version, e.Type)
+	}
+	return DecodeEvent(e.Type, e.Data)
+}
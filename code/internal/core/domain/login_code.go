@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/google/uuid"
+)
+
+const (
+	LOGIN_CODE_LENGTH       = 6
+	LOGIN_CODE_TTL          = 10 * time.Minute
+	LOGIN_CODE_MAX_ATTEMPTS = 5
+	// LOGIN_CODE_ATTEMPTS_WINDOW is how long the attempts on a code carry over to the codes requested after it.
+	LOGIN_CODE_ATTEMPTS_WINDOW = time.Hour
+)
+
+// LoginCode is a one-time code emailed to the user for a passwordless login. Only its hash is stored.
+type LoginCode struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	CodeHash   string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Attempts   int
+	ConsumedAt time.Time
+}
+
+func NewLoginCode(ID, userID uuid.UUID, codeHash string, createdAt time.Time) *LoginCode {
+	return &LoginCode{ID: ID, UserID: userID, CodeHash: codeHash, CreatedAt: createdAt, ExpiresAt: createdAt.Add(LOGIN_CODE_TTL)}
+}
+
+// ErrLoginCodeAttempts refuses a code once LOGIN_CODE_MAX_ATTEMPTS were made.
+var ErrLoginCodeAttempts = fmt.Errorf("%w: too many attempts, try again later", helpers.ErrUnauthorized)
+
+// InheritAttempts carries the attempts of the previous code, when still unused and issued within
+// LOGIN_CODE_ATTEMPTS_WINDOW, so requesting new codes does not renew the guesses.
+func (c *LoginCode) InheritAttempts(previous *LoginCode) {
+	if previous.ConsumedAt.IsZero() && c.CreatedAt.Sub(previous.CreatedAt) < LOGIN_CODE_ATTEMPTS_WINDOW {
+		c.Attempts = previous.Attempts
+	}
+}
+
+// CanVerify tells whether a code may still be checked at the given time.
+func (c *LoginCode) CanVerify(now time.Time) error {
+	switch {
+	case !c.ConsumedAt.IsZero():
+		return fmt.Errorf("%w: login code already used", helpers.ErrUnauthorized)
+	case !now.Before(c.ExpiresAt):
+		return fmt.Errorf("%w: login code expired", helpers.ErrUnauthorized)
+	case c.Attempts >= LOGIN_CODE_MAX_ATTEMPTS:
+		return ErrLoginCodeAttempts
+	}
+	return nil
+}
+
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
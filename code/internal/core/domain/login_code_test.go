@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/google/uuid"
+)
+
+func TestLoginCodeCanVerify(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		code    func(c *LoginCode)
+		wantErr bool
+	}{
+		{name: "001_should_accept_fresh_code", code: func(c *LoginCode) {}},
+		{name: "002_should_refuse_expired_code", code: func(c *LoginCode) { c.ExpiresAt = now }, wantErr: true},
+		{name: "003_should_refuse_used_code", code: func(c *LoginCode) { c.ConsumedAt = now }, wantErr: true},
+		{name: "004_should_refuse_after_max_attempts", code: func(c *LoginCode) { c.Attempts = LOGIN_CODE_MAX_ATTEMPTS }, wantErr: true},
+		{name: "005_should_accept_last_attempt", code: func(c *LoginCode) { c.Attempts = LOGIN_CODE_MAX_ATTEMPTS - 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLoginCode(uuid.New(), uuid.New(), "hash", now)
+			tt.code(c)
+
+			err := c.CanVerify(now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanVerify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, helpers.ErrUnauthorized) {
+				t.Errorf("CanVerify() error = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OUTBOX_RETRY_BASE_DELAY = time.Second
+	OUTBOX_RETRY_MAX_DELAY  = 5 * time.Minute
+)
+
+// OutboxEvent is a domain event saved in the same transaction as the change that raised it, so it is not
+// lost when the process stops before publishing. The relay delivers it at least once.
+type OutboxEvent struct {
+	ID            uuid.UUID
+	EventType     EventType
+	Payload       json.RawMessage
+	RequestID     string
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	// DispatchedAt is when the subscribers in this service handled the event, which is not dispatched again
+	// while the broker publish is retried.
+	DispatchedAt time.Time
+	DeliveredAt  time.Time
+}
+
+func NewOutboxEvent(ID uuid.UUID, event Event, requestID string, createdAt time.Time) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		ID:            ID,
+		EventType:     event.EventType(),
+		Payload:       payload,
+		RequestID:     requestID,
+		CreatedAt:     createdAt,
+		NextAttemptAt: createdAt,
+	}, nil
+}
+
+// Failed records a failed delivery, backing off exponentially before the next attempt.
+func (o *OutboxEvent) Failed(err error, at time.Time) {
+	o.Attempts++
+	o.LastError = err.Error()
+	o.NextAttemptAt = at.Add(OutboxRetryDelay(o.Attempts))
+}
+
+// OutboxRetryDelay doubles the wait after every failed attempt, up to OUTBOX_RETRY_MAX_DELAY.
+func OutboxRetryDelay(attempts int) time.Duration {
+	return backoff(OUTBOX_RETRY_BASE_DELAY, OUTBOX_RETRY_MAX_DELAY, attempts)
+}
+
+// backoff waits base after the first failed attempt, doubling after every other one, up to max.
+func backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "001_should_wait_base_delay_after_first_failure", attempts: 1, want: time.Second},
+		{name: "002_should_double_after_each_failure", attempts: 4, want: 8 * time.Second},
+		{name: "003_should_cap_delay", attempts: 30, want: OUTBOX_RETRY_MAX_DELAY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OutboxRetryDelay(tt.attempts); got != tt.want {
+				t.Errorf("OutboxRetryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
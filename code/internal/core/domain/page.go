@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Page selects the slice of a listing to return. By default listings are paged by offset, in their own
+// order. Keyset pages are ordered by (created_at, id) instead and start right after the item in After,
+// or at the first one when it is nil, so items created in between are neither repeated nor skipped.
+type Page struct {
+	Limit  int
+	Offset int
+	Keyset bool
+	After  *Cursor
+	// CountTotal asks for the total of items in the listing, counted by another query.
+	CountTotal bool
+}
+
+// Cursor is the position of an item in a listing ordered by (created_at, id).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// OffsetPage is the page of offset paging, counting the total as offset listings always did.
+func OffsetPage(limit, offset int) Page {
+	return Page{Limit: limit, Offset: offset, CountTotal: true}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataSubjectRequestType is a data subject right under the LGPD honored by the system.
+type DataSubjectRequestType string
+
+const (
+	DATA_SUBJECT_REQUEST_EXPORT  DataSubjectRequestType = "export"
+	DATA_SUBJECT_REQUEST_ERASURE                        = "erasure"
+)
+
+type DataSubjectRequestStatus string
+
+const (
+	DATA_SUBJECT_REQUEST_PENDING   DataSubjectRequestStatus = "pending"
+	DATA_SUBJECT_REQUEST_COMPLETED                          = "completed"
+	DATA_SUBJECT_REQUEST_FAILED                             = "failed"
+)
+
+// ANONYMIZED_USER_NAME replaces the name of users whose data was erased.
+const ANONYMIZED_USER_NAME = "Titular anonimizado"
+
+// DataSubjectRequest is the audit record of an export or erasure of the personal data of a user.
+type DataSubjectRequest struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Type        DataSubjectRequestType
+	Status      DataSubjectRequestStatus
+	RequestedBy uuid.UUID
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	Error       string
+}
+
+func NewDataSubjectRequest(ID, userID, requestedBy uuid.UUID, requestType DataSubjectRequestType, createdAt time.Time) *DataSubjectRequest {
+	return &DataSubjectRequest{
+		ID:          ID,
+		UserID:      userID,
+		Type:        requestType,
+		Status:      DATA_SUBJECT_REQUEST_PENDING,
+		RequestedBy: requestedBy,
+		CreatedAt:   createdAt,
+	}
+}
+
+// UserDataExport is everything held about a user.
+type UserDataExport struct {
+	GeneratedAt time.Time
+	User        *User
+	Roles       []Role
+	Orders      []*Order
+	Payments    []*Payment
+	Consents    []*Consent
+	Requests    []*DataSubjectRequest
+}
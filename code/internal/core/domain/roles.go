@@ -0,0 +1,152 @@
+package domain
+
+type Role string
+
+const (
+	ROLE_CUSTOMER Role = "customer"
+	ROLE_CASHIER       = "cashier"
+	ROLE_KITCHEN       = "kitchen"
+	ROLE_MANAGER       = "manager"
+	ROLE_ADMIN         = "admin"
+)
+
+var Roles = []Role{ROLE_CUSTOMER, ROLE_CASHIER, ROLE_KITCHEN, ROLE_MANAGER, ROLE_ADMIN}
+
+func (r Role) IsValid() bool {
+	for _, v := range Roles {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Permission is an operation of the use cases restricted to some roles.
+type Permission string
+
+const (
+	PERMISSION_PRODUCTS_CREATE         Permission = "products:create"
+	PERMISSION_PRODUCTS_UPDATE                    = "products:update"
+	PERMISSION_PRODUCTS_DELETE                    = "products:delete"
+	PERMISSION_PRODUCTS_RESTORE                   = "products:restore"
+	PERMISSION_PRODUCTS_LIST_DELETED              = "products:list_deleted"
+	PERMISSION_PRODUCTS_SCHEDULE_PRICE            = "products:schedule_price"
+	PERMISSION_CATEGORIES_CREATE                  = "categories:create"
+	PERMISSION_CATEGORIES_UPDATE                  = "categories:update"
+	PERMISSION_CATEGORIES_REORDER                 = "categories:reorder"
+	PERMISSION_CATEGORIES_DELETE                  = "categories:delete"
+	PERMISSION_CATEGORIES_RESTORE                 = "categories:restore"
+	PERMISSION_CATEGORIES_LIST                    = "categories:list"
+	PERMISSION_CATALOG_IMPORT                     = "catalog:import"
+	PERMISSION_CATALOG_EXPORT                     = "catalog:export"
+	PERMISSION_ORDERS_READ_ALL                    = "orders:read_all"
+	PERMISSION_ORDERS_MODIFY_ALL                  = "orders:modify_all"
+	PERMISSION_ORDERS_UPDATE_STATUS               = "orders:update_status"
+	PERMISSION_ROLES_MANAGE                       = "roles:manage"
+	PERMISSION_USERS_LIST                         = "users:list"
+	PERMISSION_USERS_PRIVACY                      = "users:privacy"
+	PERMISSION_USERS_CONTACTS                     = "users:contacts"
+	PERMISSION_PAYMENTS_NOTIFY                    = "payments:notify"
+	PERMISSION_API_KEYS_MANAGE                    = "api_keys:manage"
+	PERMISSION_AUDIT_READ                         = "audit:read"
+	PERMISSION_WEBHOOKS_MANAGE                    = "webhooks:manage"
+)
+
+var Permissions = []Permission{
+	PERMISSION_PRODUCTS_CREATE,
+	PERMISSION_PRODUCTS_UPDATE,
+	PERMISSION_PRODUCTS_DELETE,
+	PERMISSION_PRODUCTS_RESTORE,
+	PERMISSION_PRODUCTS_LIST_DELETED,
+	PERMISSION_PRODUCTS_SCHEDULE_PRICE,
+	PERMISSION_CATEGORIES_CREATE,
+	PERMISSION_CATEGORIES_UPDATE,
+	PERMISSION_CATEGORIES_REORDER,
+	PERMISSION_CATEGORIES_DELETE,
+	PERMISSION_CATEGORIES_RESTORE,
+	PERMISSION_CATEGORIES_LIST,
+	PERMISSION_CATALOG_IMPORT,
+	PERMISSION_CATALOG_EXPORT,
+	PERMISSION_ORDERS_READ_ALL,
+	PERMISSION_ORDERS_MODIFY_ALL,
+	PERMISSION_ORDERS_UPDATE_STATUS,
+	PERMISSION_ROLES_MANAGE,
+	PERMISSION_USERS_LIST,
+	PERMISSION_USERS_PRIVACY,
+	PERMISSION_USERS_CONTACTS,
+	PERMISSION_PAYMENTS_NOTIFY,
+	PERMISSION_API_KEYS_MANAGE,
+	PERMISSION_AUDIT_READ,
+	PERMISSION_WEBHOOKS_MANAGE,
+}
+
+func (p Permission) IsValid() bool {
+	for _, v := range Permissions {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDelegable tells whether the permission may be given to an API key. Managing roles and keys is
+// kept to people.
+func (p Permission) IsDelegable() bool {
+	return p.IsValid() && p != PERMISSION_ROLES_MANAGE && p != PERMISSION_API_KEYS_MANAGE
+}
+
+var (
+	orderStaffPermissions = []Permission{
+		PERMISSION_ORDERS_READ_ALL,
+		PERMISSION_ORDERS_UPDATE_STATUS,
+		PERMISSION_CATEGORIES_LIST,
+	}
+	managerPermissions = append([]Permission{
+		PERMISSION_PRODUCTS_CREATE,
+		PERMISSION_PRODUCTS_UPDATE,
+		PERMISSION_PRODUCTS_DELETE,
+		PERMISSION_PRODUCTS_RESTORE,
+		PERMISSION_PRODUCTS_LIST_DELETED,
+		PERMISSION_PRODUCTS_SCHEDULE_PRICE,
+		PERMISSION_CATEGORIES_CREATE,
+		PERMISSION_CATEGORIES_UPDATE,
+		PERMISSION_CATEGORIES_REORDER,
+		PERMISSION_CATEGORIES_DELETE,
+		PERMISSION_CATEGORIES_RESTORE,
+		PERMISSION_CATALOG_IMPORT,
+		PERMISSION_CATALOG_EXPORT,
+		PERMISSION_ORDERS_MODIFY_ALL,
+		PERMISSION_USERS_CONTACTS,
+		PERMISSION_PAYMENTS_NOTIFY,
+	}, orderStaffPermissions...)
+)
+
+// RolePermissions is the permission matrix. Customers have no permission listed, they only
+// reach the public operations and their own orders.
+var RolePermissions = map[Role][]Permission{
+	ROLE_CUSTOMER: {},
+	ROLE_KITCHEN:  orderStaffPermissions,
+	ROLE_CASHIER:  append([]Permission{PERMISSION_ORDERS_MODIFY_ALL, PERMISSION_PAYMENTS_NOTIFY}, orderStaffPermissions...),
+	ROLE_MANAGER:  managerPermissions,
+	ROLE_ADMIN:    append([]Permission{PERMISSION_ROLES_MANAGE, PERMISSION_USERS_LIST, PERMISSION_USERS_PRIVACY, PERMISSION_API_KEYS_MANAGE, PERMISSION_AUDIT_READ, PERMISSION_WEBHOOKS_MANAGE}, managerPermissions...),
+}
+
+// Allows tells whether the role has the permission.
+func (r Role) Allows(p Permission) bool {
+	for _, v := range RolePermissions[r] {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesAllow tells whether any of the roles has the permission.
+func RolesAllow(roles []Role, p Permission) bool {
+	for _, r := range roles {
+		if r.Allows(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "testing"
+
+func TestRolesAllow(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []Role
+		permission Permission
+		want       bool
+	}{
+		{name: "001_should_refuse_customer_product_changes", roles: []Role{ROLE_CUSTOMER}, permission: PERMISSION_PRODUCTS_UPDATE},
+		{name: "002_should_allow_kitchen_status_updates", roles: []Role{ROLE_KITCHEN}, permission: PERMISSION_ORDERS_UPDATE_STATUS, want: true},
+		{name: "003_should_refuse_kitchen_order_changes", roles: []Role{ROLE_KITCHEN}, permission: PERMISSION_ORDERS_MODIFY_ALL},
+		{name: "004_should_allow_cashier_order_changes", roles: []Role{ROLE_CASHIER}, permission: PERMISSION_ORDERS_MODIFY_ALL, want: true},
+		{name: "005_should_allow_manager_catalog_import", roles: []Role{ROLE_MANAGER}, permission: PERMISSION_CATALOG_IMPORT, want: true},
+		{name: "006_should_refuse_manager_role_changes", roles: []Role{ROLE_MANAGER}, permission: PERMISSION_ROLES_MANAGE},
+		{name: "007_should_allow_admin_role_changes", roles: []Role{ROLE_CUSTOMER, ROLE_ADMIN}, permission: PERMISSION_ROLES_MANAGE, want: true},
+		{name: "008_should_refuse_without_roles", permission: PERMISSION_ORDERS_READ_ALL},
+		{name: "009_should_refuse_manager_user_listing", roles: []Role{ROLE_MANAGER}, permission: PERMISSION_USERS_LIST},
+		{name: "010_should_allow_admin_data_requests", roles: []Role{ROLE_ADMIN}, permission: PERMISSION_USERS_PRIVACY, want: true},
+		{name: "011_should_refuse_manager_audit_log", roles: []Role{ROLE_MANAGER}, permission: PERMISSION_AUDIT_READ},
+		{name: "012_should_refuse_manager_webhooks", roles: []Role{ROLE_MANAGER}, permission: PERMISSION_WEBHOOKS_MANAGE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RolesAllow(tt.roles, tt.permission); got != tt.want {
+				t.Errorf("RolesAllow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionIsDelegable(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission Permission
+		want       bool
+	}{
+		{name: "001_should_delegate_status_updates", permission: PERMISSION_ORDERS_UPDATE_STATUS, want: true},
+		{name: "002_should_not_delegate_roles", permission: PERMISSION_ROLES_MANAGE},
+		{name: "003_should_not_delegate_api_keys", permission: PERMISSION_API_KEYS_MANAGE},
+		{name: "004_should_not_delegate_unknown", permission: "orders:everything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permission.IsDelegable(); got != tt.want {
+				t.Errorf("IsDelegable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
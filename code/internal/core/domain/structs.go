@@ -8,16 +8,23 @@ import (
 )
 
 type User struct {
-	ID       uuid.UUID
-	Document string
-	Name     string
-	Email    string
+	ID           uuid.UUID
+	Document     string
+	Name         string
+	Email        string
+	PasswordHash string
 }
 
 func NewUser(ID uuid.UUID, document string, name string, email string) *User {
 	return &User{ID: ID, Document: document, Name: name, Email: email}
 }
 
+type UserList struct {
+	Users         []*User
+	Limit, Offset int
+	Total         int64
+}
+
 type Product struct {
 	ID          uuid.UUID
 	CategoryID  uuid.UUID
@@ -27,6 +34,66 @@ type Product struct {
 	Name        string
 	Description string
 	Price       decimal.Decimal
+	Nutrition   *NutritionFacts
+	Allergens   []Allergen
+	// Version is bumped on every saved change, see Order.Version.
+	Version int
+}
+
+// NutritionFacts per serving, as shown on the kiosk.
+type NutritionFacts struct {
+	ServingSizeGrams   decimal.Decimal
+	Calories           decimal.Decimal // kcal
+	CarbohydratesGrams decimal.Decimal
+	SugarsGrams        decimal.Decimal
+	ProteinsGrams      decimal.Decimal
+	FatsGrams          decimal.Decimal
+	SaturatedFatsGrams decimal.Decimal
+	FibersGrams        decimal.Decimal
+	SodiumMilligrams   decimal.Decimal
+}
+
+type Allergen string
+
+const (
+	ALLERGEN_GLUTEN      Allergen = "gluten"
+	ALLERGEN_LACTOSE              = "lactose"
+	ALLERGEN_MILK                 = "milk"
+	ALLERGEN_EGGS                 = "eggs"
+	ALLERGEN_PEANUTS              = "peanuts"
+	ALLERGEN_TREE_NUTS            = "tree_nuts"
+	ALLERGEN_SOY                  = "soy"
+	ALLERGEN_FISH                 = "fish"
+	ALLERGEN_CRUSTACEANS          = "crustaceans"
+	ALLERGEN_SESAME               = "sesame"
+)
+
+var Allergens = []Allergen{
+	ALLERGEN_GLUTEN,
+	ALLERGEN_LACTOSE,
+	ALLERGEN_MILK,
+	ALLERGEN_EGGS,
+	ALLERGEN_PEANUTS,
+	ALLERGEN_TREE_NUTS,
+	ALLERGEN_SOY,
+	ALLERGEN_FISH,
+	ALLERGEN_CRUSTACEANS,
+	ALLERGEN_SESAME,
+}
+
+func (a Allergen) IsValid() bool {
+	for _, v := range Allergens {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ProductFilter narrows product listings.
+type ProductFilter struct {
+	IncludeDeleted   bool
+	ExcludeAllergens []Allergen
 }
 
 func ParseProductToDomain(
@@ -54,16 +121,38 @@ func NewProduct(ID uuid.UUID, categoryID uuid.UUID, name string, description str
 	return &Product{ID: ID, CategoryID: categoryID, CreatedAt: time.Now(), Name: name, Description: description, Price: p}
 }
 
+// ProductPrice is an entry of a product's price history. Entries with EffectiveFrom in the
+// future are scheduled changes, applied to the product once they become due.
+type ProductPrice struct {
+	ID            uuid.UUID
+	ProductID     uuid.UUID
+	CreatedAt     time.Time
+	CreatedBy     uuid.UUID
+	EffectiveFrom time.Time
+	AppliedAt     time.Time
+	Price         decimal.Decimal
+}
+
+func NewProductPrice(ID uuid.UUID, productID uuid.UUID, createdBy uuid.UUID, price decimal.Decimal, effectiveFrom time.Time) *ProductPrice {
+	return &ProductPrice{ID: ID, ProductID: productID, CreatedAt: time.Now(), CreatedBy: createdBy, Price: price, EffectiveFrom: effectiveFrom}
+}
+
 type ProductList struct {
 	Products      []*Product
 	Limit, Offset int
-	Total         int64
+	// Total is nil unless asked by Page.CountTotal
+	Total *int64
+	// Next is the cursor of the following keyset page, nil on the last one and on offset pages
+	Next *Cursor
 }
 
 type OrderList struct {
 	Orders        []*Order
 	Limit, Offset int
-	Total         int64
+	// Total is nil unless asked by Page.CountTotal
+	Total *int64
+	// Next is the cursor of the following keyset page, nil on the last one and on offset pages
+	Next *Cursor
 }
 
 type ProductsSum struct {
@@ -72,33 +161,61 @@ type ProductsSum struct {
 	Sum         decimal.Decimal
 }
 
+// Order belongs to a registered user, or to a guest session while the customer is not registered.
 type Order struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	PaymentID uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt time.Time
-	Price     decimal.Decimal
-	Status    OrderStatus
-	Products  []Product
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	GuestSessionID uuid.UUID
+	PaymentID      uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      time.Time
+	Price          decimal.Decimal
+	Status         OrderStatus
+	Products       []Product
+	// Version counts the saved changes, an update made on another version than the saved one is refused.
+	Version int
 }
 
 func NewOrder(ID uuid.UUID, userID uuid.UUID, createdAt time.Time, products []Product) *Order {
 	return &Order{ID: ID, UserID: userID, CreatedAt: createdAt, Products: products, Status: ORDER_STATUS_OPEN}
 }
 
+func NewGuestOrder(ID uuid.UUID, guestSessionID uuid.UUID, createdAt time.Time, products []Product) *Order {
+	return &Order{ID: ID, GuestSessionID: guestSessionID, CreatedAt: createdAt, Products: products, Status: ORDER_STATUS_OPEN}
+}
+
+// IsOwnedBy tells whether the order was placed by the caller.
+func (o *Order) IsOwnedBy(caller Caller) bool {
+	if caller.UserID != uuid.Nil {
+		return o.UserID == caller.UserID
+	}
+	return caller.GuestSessionID != uuid.Nil && o.GuestSessionID == caller.GuestSessionID
+}
+
 type Category struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt time.Time
 	Name      string
+	Position  int
 }
 
 func NewCategory(ID uuid.UUID, createdAt time.Time, name string) *Category {
 	return &Category{ID: ID, CreatedAt: createdAt, Name: name}
 }
 
+// CategoryDeletePolicy tells what happens to the products of a category being deleted.
+type CategoryDeletePolicy string
+
+const (
+	CATEGORY_DELETE_UNSET    CategoryDeletePolicy = ""
+	CATEGORY_DELETE_REFUSE                        = "refuse"
+	CATEGORY_DELETE_REASSIGN                      = "reassign"
+	CATEGORY_DELETE_CASCADE                       = "cascade"
+)
+
 type CategoryList struct {
 	Categories    []*Category
 	Limit, Offset int
@@ -112,6 +229,7 @@ type Payment struct {
 	Price     decimal.Decimal
 	OrderID   uuid.UUID
 	Status    PaymentStatus
+	Version   int
 }
 
 func NewPayment(ID uuid.UUID, createdAt time.Time, orderID uuid.UUID, price decimal.Decimal, status PaymentStatus) *Payment {
@@ -145,8 +263,6 @@ type PaymentStatusNotification struct {
 	Status    PaymentStatus // Can be "approved" or "denied"
 }
 
-var PaymentStatusChannel chan PaymentStatusNotification
-
 func OrderStatusFromNotification(status PaymentStatus) OrderStatus {
 	switch status {
 	case PAYMENT_STATUS_APPROVED:
@@ -156,3 +272,52 @@ func OrderStatusFromNotification(status PaymentStatus) OrderStatus {
 	}
 	return PAYMENT_SATUS_REFUSED
 }
+
+type CatalogFormat string
+
+const (
+	CATALOG_FORMAT_CSV  CatalogFormat = "csv"
+	CATALOG_FORMAT_JSON               = "json"
+)
+
+// CatalogRow is a flat catalog entry, one product per row. Rows without product name
+// only declare the category.
+type CatalogRow struct {
+	Line        int
+	Category    string
+	Name        string
+	Description string
+	Price       string
+	Allergens   []Allergen
+	Nutrition   *NutritionFacts
+	// HasDetails tells whether the row carries allergens and nutrition facts. Files written before they
+	// were part of the catalog do not, and leave those of existing products as they are.
+	HasDetails bool
+}
+
+type CatalogImportAction string
+
+const (
+	CATALOG_ACTION_CREATE CatalogImportAction = "create"
+	CATALOG_ACTION_UPDATE                     = "update"
+	CATALOG_ACTION_NONE                       = "none"
+	CATALOG_ACTION_ERROR                      = "error"
+)
+
+type CatalogImportResult struct {
+	Line           int
+	Category       string
+	Product        string
+	CategoryAction CatalogImportAction
+	ProductAction  CatalogImportAction
+	Error          string
+}
+
+type CatalogImportReport struct {
+	DryRun            bool
+	Results           []CatalogImportResult
+	CategoriesCreated int
+	ProductsCreated   int
+	ProductsUpdated   int
+	Failed            int
+}
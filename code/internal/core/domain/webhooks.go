@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WEBHOOK_MAX_ATTEMPTS is how many times a delivery is tried before going to the dead letters.
+	WEBHOOK_MAX_ATTEMPTS       = 8
+	WEBHOOK_RETRY_BASE_DELAY   = 30 * time.Second
+	WEBHOOK_RETRY_MAX_DELAY    = time.Hour
+	WEBHOOK_SIGNATURE_HEADER   = "X-Lanchonete-Signature"
+	WEBHOOK_EVENT_HEADER       = "X-Lanchonete-Event"
+	WEBHOOK_DELIVERY_HEADER    = "X-Lanchonete-Delivery"
+	WEBHOOK_SECRET_MIN_LENGTH  = 16
+	WEBHOOK_LAST_ERROR_MAX_LEN = 500
+)
+
+// WebhookEventTypes are the events partners may subscribe to.
+var WebhookEventTypes = []EventType{EVENT_ORDER_CREATED, EVENT_ORDER_STATUS_CHANGED, EVENT_PAYMENT_STATUS_CHANGED}
+
+func IsWebhookEventType(eventType EventType) bool {
+	for _, v := range WebhookEventTypes {
+		if v == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription sends the events of the given types to a partner URL, signed with the secret shared
+// with the partner. The secret is only shown on creation.
+type WebhookSubscription struct {
+	ID         uuid.UUID
+	URL        string
+	EventTypes []EventType
+	Secret     string
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+	DeletedAt  time.Time
+}
+
+func NewWebhookSubscription(ID uuid.UUID, url string, eventTypes []EventType, secret string, createdBy uuid.UUID, createdAt time.Time) *WebhookSubscription {
+	return &WebhookSubscription{ID: ID, URL: url, EventTypes: eventTypes, Secret: secret, CreatedBy: createdBy, CreatedAt: createdAt}
+}
+
+func (w *WebhookSubscription) IsDeleted() bool {
+	return !w.DeletedAt.IsZero()
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WEBHOOK_DELIVERY_PENDING   WebhookDeliveryStatus = "pending"
+	WEBHOOK_DELIVERY_DELIVERED                       = "delivered"
+	WEBHOOK_DELIVERY_DEAD                            = "dead"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WEBHOOK_DELIVERY_PENDING, WEBHOOK_DELIVERY_DELIVERED, WEBHOOK_DELIVERY_DEAD:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is one event to be sent to one subscription. It is retried with exponential backoff and,
+// after WEBHOOK_MAX_ATTEMPTS failures, kept as a dead letter until retried by hand.
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Envelope       EventEnvelope
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	LastStatusCode int
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+func NewWebhookDelivery(ID, subscriptionID uuid.UUID, envelope EventEnvelope, createdAt time.Time) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             ID,
+		SubscriptionID: subscriptionID,
+		Envelope:       envelope,
+		Status:         WEBHOOK_DELIVERY_PENDING,
+		NextAttemptAt:  createdAt,
+		CreatedAt:      createdAt,
+	}
+}
+
+func (d *WebhookDelivery) Delivered(statusCode int, at time.Time) {
+	d.Attempts++
+	d.Status = WEBHOOK_DELIVERY_DELIVERED
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = at
+}
+
+// Failed records a failed attempt. The status code is zero when the partner could not be reached.
+func (d *WebhookDelivery) Failed(err error, statusCode int, at time.Time) {
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = err.Error()
+	if len(d.LastError) > WEBHOOK_LAST_ERROR_MAX_LEN {
+		d.LastError = d.LastError[:WEBHOOK_LAST_ERROR_MAX_LEN]
+	}
+
+	if d.Attempts >= WEBHOOK_MAX_ATTEMPTS {
+		d.Status = WEBHOOK_DELIVERY_DEAD
+		return
+	}
+	d.NextAttemptAt = at.Add(backoff(WEBHOOK_RETRY_BASE_DELAY, WEBHOOK_RETRY_MAX_DELAY, d.Attempts))
+}
+
+// Retry takes a dead letter back to the pending deliveries, with a fresh count of attempts.
+func (d *WebhookDelivery) Retry(at time.Time) {
+	d.Status = WEBHOOK_DELIVERY_PENDING
+	d.Attempts = 0
+	d.NextAttemptAt = at
+}
+
+// WebhookDeliveryFilter narrows the delivery log, zero values match everything.
+type WebhookDeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         WebhookDeliveryStatus
+	EventID        uuid.UUID
+}
+
+type WebhookDeliveryList struct {
+	Deliveries    []*WebhookDelivery
+	Limit, Offset int
+	Total         int64
+}
+
+// SignWebhook signs the body sent at the given time, as t=<unix seconds>,v1=<hex HMAC-SHA256>. The HMAC
+// covers "<unix seconds>.<body>", so partners can refuse old deliveries being replayed.
+func SignWebhook(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhookDeliveryFailed(t *testing.T) {
+	at := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		failures   int
+		wantStatus WebhookDeliveryStatus
+		wantNext   time.Time
+	}{
+		{name: "001_should_retry_after_base_delay", failures: 1, wantStatus: WEBHOOK_DELIVERY_PENDING, wantNext: at.Add(WEBHOOK_RETRY_BASE_DELAY)},
+		{name: "002_should_double_delay", failures: 3, wantStatus: WEBHOOK_DELIVERY_PENDING, wantNext: at.Add(4 * WEBHOOK_RETRY_BASE_DELAY)},
+		{name: "003_should_dead_letter_after_max_attempts", failures: WEBHOOK_MAX_ATTEMPTS, wantStatus: WEBHOOK_DELIVERY_DEAD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewWebhookDelivery(uuid.New(), uuid.New(), EventEnvelope{}, at)
+			for i := 0; i < tt.failures; i++ {
+				d.Failed(errors.New("503 Service Unavailable"), 503, at)
+			}
+
+			if d.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", d.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == WEBHOOK_DELIVERY_PENDING && !d.NextAttemptAt.Equal(tt.wantNext) {
+				t.Errorf("NextAttemptAt = %v, want %v", d.NextAttemptAt, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	got := SignWebhook("0123456789abcdef", time.Unix(1700000000, 0), []byte(`{"id":1}`))
+	want := "t=1700000000,v1=4bcaced68dfea90a68df035b89cb7fb26692d899d32a1ccb1b0616cf48e4d1ed"
+	if got != want {
+		t.Errorf("SignWebhook() = %v, want %v", got, want)
+	}
+}
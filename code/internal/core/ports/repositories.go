@@ -4,45 +4,196 @@ import (
 	"context"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/google/uuid"
+	"time"
 )
 
 // UsersRepository Secondary actors
 type UsersRepository interface {
 	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetUserByDocument(ctx context.Context, document string) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	ListUsers(ctx context.Context, limit, offset int, search string) (*domain.UserList, error)
 	InsertUser(ctx context.Context, user *domain.User) error
+	UpdateUser(ctx context.Context, user *domain.User) error
 	ValidateUser(ctx context.Context, document string) (uuid.UUID, error)
-	IsUserAdmin(ctx context.Context, id uuid.UUID) (bool, error)
+	ListUserRoles(ctx context.Context, id uuid.UUID) ([]domain.Role, error)
+	GrantUserRole(ctx context.Context, id uuid.UUID, role domain.Role, grantedBy uuid.UUID) error
+	RevokeUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error
+	AnonymizeUser(ctx context.Context, id uuid.UUID) error
+	InsertUserConsents(ctx context.Context, consents []*domain.Consent) error
+	ListUserConsents(ctx context.Context, id uuid.UUID) ([]*domain.Consent, error)
+	ListEligibleContacts(ctx context.Context, purpose domain.ConsentPurpose, limit, offset int) (*domain.ContactList, error)
+}
+
+type DataSubjectRequestsRepository interface {
+	InsertDataSubjectRequest(ctx context.Context, request *domain.DataSubjectRequest) error
+	UpdateDataSubjectRequest(ctx context.Context, request *domain.DataSubjectRequest) error
+	ListDataSubjectRequests(ctx context.Context, userID uuid.UUID) ([]*domain.DataSubjectRequest, error)
+}
+
+type GuestSessionsRepository interface {
+	InsertGuestSession(ctx context.Context, session *domain.GuestSession) error
+	GetGuestSession(ctx context.Context, id uuid.UUID) (*domain.GuestSession, error)
+	SetGuestSessionDocument(ctx context.Context, id uuid.UUID, document string) error
+	MergeGuestSession(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// LoginCodesRepository stores the one-time codes of the passwordless login.
+type LoginCodesRepository interface {
+	InsertLoginCode(ctx context.Context, code *domain.LoginCode) error
+	GetLatestLoginCode(ctx context.Context, userID uuid.UUID) (*domain.LoginCode, error)
+	// ClaimLoginCodeAttempt counts an attempt on the code, failing with ErrNotFound once it is used or
+	// maxAttempts were made.
+	ClaimLoginCodeAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) error
+	ConsumeLoginCode(ctx context.Context, id uuid.UUID) error
+}
+
+type APIKeysRepository interface {
+	InsertAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// UnitOfWork runs several repository calls in one transaction, through the context given to fn. The
+// transaction is committed when fn returns nil, and rolled back when it fails or panics. A unit of work
+// run within another one only rolls back its own calls when it fails.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AuditRepository keeps the append-only audit log, entries are never updated nor deleted.
+type AuditRepository interface {
+	InsertAuditEntry(ctx context.Context, entry *domain.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter, limit, offset int) (*domain.AuditList, error)
+}
+
+// EventHandler handles an event delivered by the bus. Errors are logged by the bus, handlers decide
+// themselves whether a failed event is worth retrying.
+type EventHandler func(ctx context.Context, event domain.Event) error
+
+// EventBus delivers the domain events to their subscribers, in the background and in publishing order
+// for each subscriber.
+type EventBus interface {
+	Publish(ctx context.Context, event domain.Event) error
+	Subscribe(eventType domain.EventType, name string, handler EventHandler)
+	// Dispatch runs the subscribers of the event right away, returning their errors, for callers that must
+	// know whether the event was handled.
+	Dispatch(ctx context.Context, event domain.Event) error
+	// Close stops accepting events and waits for the subscribers to handle the pending ones, until ctx is done.
+	Close(ctx context.Context) error
+}
+
+// BrokerHandler handles an envelope consumed from the message broker. Returning an error redelivers it.
+type BrokerHandler func(ctx context.Context, envelope domain.EventEnvelope) error
+
+// MessageBroker carries the event envelopes between services. Delivery is at least once, consumers drop
+// duplicates by the envelope ID.
+type MessageBroker interface {
+	Publish(ctx context.Context, envelope domain.EventEnvelope) error
+	// Subscribe consumes the envelopes of the event type from the named queue, shared by every instance of
+	// a consumer so each envelope is handled by one of them.
+	Subscribe(eventType domain.EventType, queue string, handler BrokerHandler) error
+	Close() error
+}
+
+// WebhooksRepository keeps the partner subscriptions and the log of deliveries to them.
+type WebhooksRepository interface {
+	InsertWebhookSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*domain.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	// ListWebhookSubscriptionsByEventType lists the subscriptions not deleted receiving the event type.
+	ListWebhookSubscriptionsByEventType(ctx context.Context, eventType domain.EventType) ([]*domain.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID, at time.Time) error
+	// InsertWebhookDeliveries skips the deliveries already saved for the same subscription and event.
+	InsertWebhookDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+	// ListDueWebhookDeliveries lists the pending deliveries due until the given time, oldest first.
+	ListDueWebhookDeliveries(ctx context.Context, until time.Time, limit int) ([]*domain.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter, limit, offset int) (*domain.WebhookDeliveryList, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// WebhookSender posts a delivery to the subscription URL. The status code is zero when the partner could
+// not be reached, and any status other than 2xx is an error.
+type WebhookSender interface {
+	Send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (statusCode int, err error)
+}
+
+// MailSender delivers emails to the customers.
+type MailSender interface {
+	Send(ctx context.Context, message domain.MailMessage) error
+}
+
+// TokenSigner issues and verifies the session tokens carrying the caller identity.
+type TokenSigner interface {
+	Sign(caller domain.Caller) (*domain.AuthToken, error)
+	Verify(token string) (*domain.Caller, error)
 }
 
 type ProductsRepository interface {
 	GetProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	GetProductByName(ctx context.Context, categoryID uuid.UUID, name string) (*domain.Product, error)
 	InsertProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
 	UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
 	DeleteProduct(ctx context.Context, uuid uuid.UUID) error
-	ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) (*domain.ProductList, error)
+	DeleteProductsByCategory(ctx context.Context, categoryID uuid.UUID, deletedAt time.Time) error
+	RestoreProductsByCategory(ctx context.Context, categoryID uuid.UUID, deletedAt time.Time) error
+	ReassignProductsCategory(ctx context.Context, fromCategoryID, toCategoryID uuid.UUID) error
+	CountProductsByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	RestoreProduct(ctx context.Context, uuid uuid.UUID) error
+	ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, page domain.Page, filter domain.ProductFilter) (*domain.ProductList, error)
 	GetProductsPriceSumByID(ctx context.Context, ids []uuid.UUID) (*domain.ProductsSum, error)
 }
 
+type ProductPricesRepository interface {
+	InsertProductPrice(ctx context.Context, price *domain.ProductPrice) (*domain.ProductPrice, error)
+	ListProductPrices(ctx context.Context, productID uuid.UUID) ([]*domain.ProductPrice, error)
+	GetProductPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductPrice, error)
+	ListDueProductPrices(ctx context.Context, until time.Time) ([]*domain.ProductPrice, error)
+	SetProductPriceApplied(ctx context.Context, id uuid.UUID, appliedAt time.Time) error
+}
+
 type CategoriesRepository interface {
 	InsertCategory(ctx context.Context, in *domain.Category) (*domain.Category, error)
 	GetCategoryByID(ctx context.Context, id uuid.UUID) (*domain.Category, error)
-	DeleteCategory(ctx context.Context, id uuid.UUID) error
-	ListCategories(ctx context.Context, limit int, offset int) (*domain.CategoryList, error)
+	GetCategoryByName(ctx context.Context, name string) (*domain.Category, error)
+	UpdateCategory(ctx context.Context, in *domain.Category) (*domain.Category, error)
+	ReorderCategories(ctx context.Context, ids []uuid.UUID) error
+	DeleteCategory(ctx context.Context, id uuid.UUID, deletedAt time.Time) error
+	RestoreCategory(ctx context.Context, id uuid.UUID) error
+	ListCategories(ctx context.Context, limit int, offset int, includeDeleted bool) (*domain.CategoryList, error)
 }
 
 type OrdersRepository interface {
 	GetOrder(ctx context.Context, orderID uuid.UUID) (*domain.Order, error)
 	GetOrderByPaymentID(ctx context.Context, paymentID uuid.UUID) (*domain.Order, error)
-	CreateOrder(ctx context.Context, order *domain.Order) (*domain.Order, error)
-	UpdateOrder(ctx context.Context, order *domain.Order) (*domain.Order, error)
-	DeleteOrder(ctx context.Context, orderID uuid.UUID) error
+	// CreateOrder and UpdateOrder save the order and the events it raised in the same transaction.
+	CreateOrder(ctx context.Context, order *domain.Order, events ...*domain.OutboxEvent) (*domain.Order, error)
+	// UpdateOrder and DeleteOrder only change the given version of the order, returning a
+	// helpers.VersionConflictError when it was changed since.
+	UpdateOrder(ctx context.Context, order *domain.Order, events ...*domain.OutboxEvent) (*domain.Order, error)
+	DeleteOrder(ctx context.Context, orderID uuid.UUID, version int) error
 	SetOrderAsPaid(ctx context.Context, payment *domain.Payment) error
-	ListOrdersByUser(ctx context.Context, limit, offset int, userID uuid.UUID) (*domain.OrderList, error)
-	ListOrders(ctx context.Context, limit, offset int) (*domain.OrderList, error)
+	// the listings of orders page by offset or by keyset, see domain.Page
+	ListOrdersByUser(ctx context.Context, page domain.Page, userID uuid.UUID) (*domain.OrderList, error)
+	ListOrdersByGuestSession(ctx context.Context, page domain.Page, guestSessionID uuid.UUID) (*domain.OrderList, error)
+	ListOrders(ctx context.Context, page domain.Page) (*domain.OrderList, error)
 }
 
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment *domain.Payment) (*domain.Payment, error)
 	GetPayment(ctx context.Context, paymentID uuid.UUID) (*domain.Payment, error)
-	UpdatePayment(ctx context.Context, payment *domain.Payment) (*domain.Payment, error)
+	// UpdatePayment saves the payment and the events it raised in the same transaction, when the payment is
+	// still at the given version.
+	UpdatePayment(ctx context.Context, payment *domain.Payment, events ...*domain.OutboxEvent) (*domain.Payment, error)
+}
+
+// OutboxRepository reads the events saved along with the changes that raised them, for the relay.
+type OutboxRepository interface {
+	ListPendingOutboxEvents(ctx context.Context, until time.Time, limit int) ([]*domain.OutboxEvent, error)
+	MarkOutboxEventDispatched(ctx context.Context, id uuid.UUID, at time.Time) error
+	MarkOutboxEventDelivered(ctx context.Context, id uuid.UUID, at time.Time) error
+	MarkOutboxEventFailed(ctx context.Context, event *domain.OutboxEvent) error
 }
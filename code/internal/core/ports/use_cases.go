@@ -4,41 +4,72 @@ import (
 	"context"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"io"
+	"time"
 )
 
 // UsersUseCase Primary actors
 type UsersUseCase interface {
-	CreateUser(ctx context.Context, name, document, email string) (*domain.User, error)
+	CreateUser(ctx context.Context, name, document, email, password string, consents []*domain.Consent) (*domain.User, error)
 	ValidateUser(ctx context.Context, document string) (uuid.UUID, error)
-	IsUserAdmin(ctx context.Context, id uuid.UUID) (bool, error)
+	GetMyProfile(ctx context.Context) (*domain.User, error)
+	UpdateMyProfile(ctx context.Context, name, email string) (*domain.User, error)
+	ListUsers(ctx context.Context, limit, offset int, search string) (*domain.UserList, error)
+	ListMyConsents(ctx context.Context) ([]*domain.Consent, error)
+	RecordMyConsents(ctx context.Context, consents []*domain.Consent) ([]*domain.Consent, error)
+	ListEligibleContacts(ctx context.Context, purpose domain.ConsentPurpose, limit, offset int) (*domain.ContactList, error)
+	ListUserRoles(ctx context.Context, id uuid.UUID) ([]domain.Role, error)
+	GrantUserRole(ctx context.Context, id uuid.UUID, role domain.Role) ([]domain.Role, error)
+	RevokeUserRole(ctx context.Context, id uuid.UUID, role domain.Role) ([]domain.Role, error)
+}
+
+type AuthUseCase interface {
+	Login(ctx context.Context, document, password string) (*domain.AuthToken, error)
+	Authenticate(ctx context.Context, token string) (*domain.Caller, error)
+	RequestLoginCode(ctx context.Context, document string) error
+	VerifyLoginCode(ctx context.Context, document, code string) (*domain.AuthToken, error)
+	StartGuestSession(ctx context.Context) (*domain.AuthToken, error)
+	AttachGuestDocument(ctx context.Context, document string) (*domain.GuestSession, error)
 }
 
 type ProductsUseCase interface {
 	GetProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error)
-	InsertProduct(ctx context.Context, userID uuid.UUID, product *domain.Product) (*domain.Product, error)
-	UpdateProduct(ctx context.Context, userID uuid.UUID, product *domain.Product) (*domain.Product, error)
-	DeleteProduct(ctx context.Context, userID uuid.UUID, uuid uuid.UUID) error
-	ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) (*domain.ProductList, error)
+	InsertProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	// UpdateProduct changes the product at product.Version, or whatever version is saved when 0.
+	UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, uuid uuid.UUID) error
+	RestoreProduct(ctx context.Context, uuid uuid.UUID) (*domain.Product, error)
+	ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, page domain.Page, filter domain.ProductFilter) (*domain.ProductList, error)
 	GetProductsPriceSumByID(ctx context.Context, products []uuid.UUID) (*domain.ProductsSum, error)
+	ListProductPriceHistory(ctx context.Context, productID uuid.UUID) ([]*domain.ProductPrice, error)
+	GetProductPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductPrice, error)
+	ScheduleProductPrice(ctx context.Context, productID uuid.UUID, price decimal.Decimal, effectiveFrom time.Time) (*domain.ProductPrice, error)
+	ApplyScheduledPrices(ctx context.Context) error
 }
 
 type CategoriesUseCase interface {
 	GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error)
-	InsertCategory(ctx context.Context, userID uuid.UUID, in *domain.Category) (*domain.Category, error)
-	DeleteCategory(ctx context.Context, userID, id uuid.UUID) error
-	ListCategories(ctx context.Context, userID uuid.UUID, limit, offset int) (*domain.CategoryList, error)
+	InsertCategory(ctx context.Context, in *domain.Category) (*domain.Category, error)
+	UpdateCategory(ctx context.Context, in *domain.Category) (*domain.Category, error)
+	ReorderCategories(ctx context.Context, ids []uuid.UUID) error
+	DeleteCategory(ctx context.Context, id uuid.UUID, policy domain.CategoryDeletePolicy, targetID uuid.UUID) error
+	RestoreCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	ListCategories(ctx context.Context, limit, offset int, includeDeleted bool) (*domain.CategoryList, error)
 }
 
 type OrdersUseCase interface {
-	GetOrder(ctx context.Context, userID, orderID uuid.UUID) (*domain.Order, error)
+	GetOrder(ctx context.Context, orderID uuid.UUID) (*domain.Order, error)
 	GetOrderByPaymentID(ctx context.Context, paymentID uuid.UUID) (*domain.Order, error)
-	CreateOrder(ctx context.Context, userID uuid.UUID, products []domain.Product) (*domain.Order, error)
-	InsertProductsIntoOrder(ctx context.Context, userID, orderID uuid.UUID, products []domain.Product) (*domain.Order, error)
-	RemoveProductFromOrder(ctx context.Context, userID, orderID uuid.UUID, products []domain.Product) (*domain.Order, error)
-	DeleteOrder(ctx context.Context, userID, orderID uuid.UUID) error
-	ListOrders(ctx context.Context, limit, offset int, userID uuid.UUID) (*domain.OrderList, error)
-	Checkout(ctx context.Context, userID, paymentID uuid.UUID) (*domain.Order, error)
-	UpdateOrderStatus(ctx context.Context, userID, orderID uuid.UUID, status domain.OrderStatus) (*domain.Order, error)
+	CreateOrder(ctx context.Context, products []domain.Product) (*domain.Order, error)
+	// The changes to an order take the version the caller read, refused with a helpers.VersionConflictError
+	// when the order was changed since. Version 0 changes whatever version is saved.
+	InsertProductsIntoOrder(ctx context.Context, orderID uuid.UUID, version int, products []domain.Product) (*domain.Order, error)
+	RemoveProductFromOrder(ctx context.Context, orderID uuid.UUID, version int, products []domain.Product) (*domain.Order, error)
+	DeleteOrder(ctx context.Context, orderID uuid.UUID, version int) error
+	ListOrders(ctx context.Context, page domain.Page) (*domain.OrderList, error)
+	Checkout(ctx context.Context, orderID uuid.UUID, version int) (*domain.Order, error)
+	UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, version int, status domain.OrderStatus) (*domain.Order, error)
 }
 
 type PaymentUseCase interface {
@@ -46,3 +77,44 @@ type PaymentUseCase interface {
 	CreatePayment(ctx context.Context, orderID *domain.Order) (*domain.Payment, error)
 	UpdatePayment(ctx context.Context, paymentID uuid.UUID, status domain.PaymentStatus) (*domain.Payment, error)
 }
+
+type APIKeysUseCase interface {
+	CreateAPIKey(ctx context.Context, name string, scopes []domain.Permission) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.Caller, error)
+}
+
+// PrivacyUseCase honors the LGPD data subject requests.
+type PrivacyUseCase interface {
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*domain.UserDataExport, error)
+	EraseUserData(ctx context.Context, userID uuid.UUID) (*domain.DataSubjectRequest, error)
+	ListDataSubjectRequests(ctx context.Context, userID uuid.UUID) ([]*domain.DataSubjectRequest, error)
+}
+
+type AuditUseCase interface {
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter, limit, offset int) (*domain.AuditList, error)
+}
+
+type WebhooksUseCase interface {
+	CreateWebhookSubscription(ctx context.Context, url string, eventTypes []domain.EventType, secret string) (*domain.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error
+	ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter, limit, offset int) (*domain.WebhookDeliveryList, error)
+	RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+}
+
+// WebhookDispatcher sends the due webhook deliveries to the partners.
+type WebhookDispatcher interface {
+	DeliverWebhooks(ctx context.Context) error
+}
+
+// OutboxRelay delivers the pending outbox events to the event bus and the message broker.
+type OutboxRelay interface {
+	RelayOutboxEvents(ctx context.Context) error
+}
+
+type CatalogUseCase interface {
+	ImportCatalog(ctx context.Context, format domain.CatalogFormat, in io.Reader, dryRun bool) (*domain.CatalogImportReport, error)
+	ExportCatalog(ctx context.Context, format domain.CatalogFormat, out io.Writer) error
+}
@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyMark = "lk"
+	// apiKeyTouchInterval spares a write per request, the last use is only recorded this often.
+	apiKeyTouchInterval = time.Minute
+)
+
+type apiKeysUseCase struct {
+	logger *zap.SugaredLogger
+	repo   ports.APIKeysRepository
+	userUC ports.UsersUseCase
+	audit  auditor
+}
+
+func NewAPIKeysUseCase(logger *zap.SugaredLogger, repo ports.APIKeysRepository, auditRepo ports.AuditRepository, userUC ports.UsersUseCase) ports.APIKeysUseCase {
+	return &apiKeysUseCase{logger: logger, repo: repo, userUC: userUC, audit: newAuditor(logger, auditRepo)}
+}
+
+// CreateAPIKey issues a key for a device. The returned APIKey carries the key itself, which is not stored
+// and can not be shown again.
+func (a *apiKeysUseCase) CreateAPIKey(ctx context.Context, name string, scopes []domain.Permission) (*domain.APIKey, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAllowed(a.logger, a.userUC, ctx, domain.PERMISSION_API_KEYS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_API_KEYS_MANAGE)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, helpers.NewValidationError("name", "must not be empty")
+	}
+	if len(scopes) == 0 {
+		return nil, helpers.NewValidationError("scopes", "must not be empty")
+	}
+	for _, s := range scopes {
+		if !s.IsDelegable() {
+			return nil, helpers.NewValidationError("scopes", fmt.Sprintf("scope %q can not be given to api keys", s))
+		}
+	}
+
+	key := domain.NewAPIKey(uuid.New(), name, scopes, uID, time.Now())
+	if key.Prefix, key.Key, err = newAPIKey(); err != nil {
+		return nil, err
+	}
+	key.KeyHash = hashAPIKey(key.Key)
+
+	if err = a.repo.InsertAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	// neither the key nor its hash belong in the audit log
+	audited := *key
+	audited.Key, audited.KeyHash = "", ""
+	a.audit.record(ctx, domain.AUDIT_API_KEY_CREATE, domain.AUDIT_ENTITY_API_KEY, key.ID, nil, audited)
+
+	return key, nil
+}
+
+func (a *apiKeysUseCase) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	if !isAllowed(a.logger, a.userUC, ctx, domain.PERMISSION_API_KEYS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_API_KEYS_MANAGE)
+	}
+
+	return a.repo.ListAPIKeys(ctx)
+}
+
+func (a *apiKeysUseCase) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if !isAllowed(a.logger, a.userUC, ctx, domain.PERMISSION_API_KEYS_MANAGE) {
+		return denied(ctx, domain.PERMISSION_API_KEYS_MANAGE)
+	}
+
+	now := time.Now()
+	if err := a.repo.RevokeAPIKey(ctx, id, now); err != nil {
+		return err
+	}
+	a.audit.record(ctx, domain.AUDIT_API_KEY_REVOKE, domain.AUDIT_ENTITY_API_KEY, id, nil, map[string]any{"RevokedAt": now})
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the caller of a valid, not revoked, key, limited to the key scopes.
+func (a *apiKeysUseCase) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Caller, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed api key", helpers.ErrUnauthorized)
+	}
+
+	saved, err := a.repo.GetAPIKeyByPrefix(ctx, prefix)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil, helpers.ErrUnauthorized
+	case err != nil:
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(saved.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, helpers.ErrUnauthorized
+	}
+	if saved.IsRevoked() {
+		return nil, fmt.Errorf("%w: api key revoked", helpers.ErrUnauthorized)
+	}
+
+	if now := time.Now(); now.Sub(saved.LastUsedAt) >= apiKeyTouchInterval {
+		// the last use is informative, failing to record it must not refuse the request
+		_ = a.repo.TouchAPIKey(ctx, saved.ID, now)
+	}
+
+	return &domain.Caller{APIKeyID: saved.ID, Scopes: saved.Scopes}, nil
+}
+
+// newAPIKey draws a key in the format lk_<prefix>_<secret>. The prefix finds the key, the secret proves it.
+func newAPIKey() (prefix, key string, err error) {
+	p := make([]byte, 6)
+	s := make([]byte, 32)
+	if _, err = rand.Read(p); err != nil {
+		return "", "", err
+	}
+	if _, err = rand.Read(s); err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(p)
+	return prefix, apiKeyMark + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(s), nil
+}
+
+func parseAPIKey(key string) (prefix string, ok bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyMark || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashAPIKey needs no salt nor slow hashing, the keys are long random strings.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package usecases
+
+import "testing"
+
+func TestParseAPIKey(t *testing.T) {
+	prefix, key, err := newAPIKey()
+	if err != nil {
+		t.Fatalf("newAPIKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		wantPrefix string
+		wantOk     bool
+	}{
+		{name: "001_should_parse_generated_key", key: key, wantPrefix: prefix, wantOk: true},
+		{name: "002_should_keep_underscores_in_secret", key: "lk_0a1b2c_se_cr_et", wantPrefix: "0a1b2c", wantOk: true},
+		{name: "003_should_refuse_other_mark", key: "sk_0a1b2c_secret"},
+		{name: "004_should_refuse_missing_secret", key: "lk_0a1b2c_"},
+		{name: "005_should_refuse_jwt", key: "eyJhbGciOiJIUzI1NiJ9.e30.sig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAPIKey(tt.key)
+			if ok != tt.wantOk || got != tt.wantPrefix {
+				t.Errorf("parseAPIKey() = %q, %v, want %q, %v", got, ok, tt.wantPrefix, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// auditor writes the audit log on behalf of the use cases that change data on administrative requests.
+type auditor struct {
+	logger *zap.SugaredLogger
+	repo   ports.AuditRepository
+}
+
+func newAuditor(logger *zap.SugaredLogger, repo ports.AuditRepository) auditor {
+	return auditor{logger: logger, repo: repo}
+}
+
+// record appends the change made by the caller to the audit log. before and after are stored as JSON, nil
+// when the record did not exist before or does not exist anymore. The change is already saved at this point,
+// so a failure here is logged instead of failing the request.
+func (a auditor) record(ctx context.Context, action domain.AuditAction, entityType domain.AuditEntityType, entityID uuid.UUID, before, after any) {
+	entry := domain.NewAuditEntry(uuid.New(), time.Now(), action, entityType, entityID)
+	if caller, ok := domain.CallerFromContext(ctx); ok {
+		entry.ActorUserID = caller.UserID
+		entry.ActorAPIKeyID = caller.APIKeyID
+	}
+	entry.RequestID = domain.RequestIDFromContext(ctx)
+
+	var err error
+	if entry.Before, err = auditState(before); err == nil {
+		entry.After, err = auditState(after)
+	}
+	if err == nil {
+		err = a.repo.InsertAuditEntry(ctx, entry)
+	}
+	if err != nil {
+		a.logger.Errorw(
+			"failed recording audit entry",
+			zap.String("action", string(action)),
+			zap.String("entity_id", entityID.String()),
+			zap.Error(err),
+		)
+	}
+}
+
+func auditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+type auditUseCase struct {
+	logger *zap.SugaredLogger
+	repo   ports.AuditRepository
+	userUC ports.UsersUseCase
+}
+
+func NewAuditUseCase(logger *zap.SugaredLogger, repo ports.AuditRepository, userUC ports.UsersUseCase) ports.AuditUseCase {
+	return &auditUseCase{logger: logger, repo: repo, userUC: userUC}
+}
+
+func (a *auditUseCase) ListAuditEntries(ctx context.Context, filter domain.AuditFilter, limit, offset int) (*domain.AuditList, error) {
+	if !isAllowed(a.logger, a.userUC, ctx, domain.PERMISSION_AUDIT_READ) {
+		return nil, denied(ctx, domain.PERMISSION_AUDIT_READ)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return nil, helpers.NewValidationError("to", "must be after from")
+	}
+
+	return a.repo.ListAuditEntries(ctx, filter, limit, offset)
+}
@@ -0,0 +1,309 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginCodeTimeout bounds issuing a login code in background, the request that asked for it is already answered.
+const loginCodeTimeout = time.Minute
+
+type authUseCase struct {
+	logger    *zap.SugaredLogger
+	userRepo  ports.UsersRepository
+	guestRepo ports.GuestSessionsRepository
+	codesRepo ports.LoginCodesRepository
+	mail      ports.MailSender
+	signer    ports.TokenSigner
+	// background runs the tasks outliving the request, in a goroutine
+	background func(task func())
+}
+
+func NewAuthUseCase(
+	logger *zap.SugaredLogger,
+	userRepo ports.UsersRepository,
+	guestRepo ports.GuestSessionsRepository,
+	codesRepo ports.LoginCodesRepository,
+	mail ports.MailSender,
+	signer ports.TokenSigner,
+) ports.AuthUseCase {
+	return &authUseCase{
+		logger:    logger,
+		userRepo:  userRepo,
+		guestRepo: guestRepo,
+		codesRepo: codesRepo,
+		mail:      mail,
+		signer:    signer,
+		background: func(task func()) {
+			go task()
+		},
+	}
+}
+
+// Login issues a session token for the user with the given CPF and password. Users registered without a
+// password log in with a code by email instead, see RequestLoginCode, and kiosk customers identified only by
+// their CPF get a guest session, see AttachGuestDocument. Unknown CPFs, users without a password and wrong
+// passwords all get the same answer, so the endpoint does not tell which CPFs are registered.
+func (a *authUseCase) Login(ctx context.Context, document, password string) (*domain.AuthToken, error) {
+	cpf, err := domain.NormalizeCPF(document)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetUserByDocument(ctx, cpf)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		user = &domain.User{}
+	case err != nil:
+		return nil, err
+	}
+
+	hash := user.PasswordHash
+	if hash == "" {
+		// compared all the same, so accounts without a password answer as slowly as the others
+		hash = dummyPasswordHash
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil || user.PasswordHash == "" {
+		a.logger.Errorw(
+			"failed login, unknown user, no password or wrong password",
+			zap.String("id", user.ID.String()),
+			zap.Error(helpers.ErrUnauthorized),
+		)
+		return nil, helpers.ErrUnauthorized
+	}
+
+	return a.signer.Sign(domain.Caller{UserID: user.ID})
+}
+
+// dummyPasswordHash is compared against on logins without a password hash, it is the hash of a random
+// password nobody knows.
+const dummyPasswordHash = "$2a$10$2QPNPjepFDdsOXEOiBg6eOFWUibyv///zvd6bJ7FWhSF6TU0FZDaO"
+
+// RequestLoginCode emails a one-time code to the user with the given CPF. The code is issued in background, so
+// unknown CPFs, users without an email and failures sending it all get the same answer in the same time, and
+// the endpoint does not tell which CPFs are registered.
+func (a *authUseCase) RequestLoginCode(ctx context.Context, document string) error {
+	cpf, err := domain.NormalizeCPF(document)
+	if err != nil {
+		return err
+	}
+
+	a.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), loginCodeTimeout)
+		defer cancel()
+
+		if err := a.issueLoginCode(ctx, cpf); err != nil {
+			a.logger.Errorw(
+				"failed issuing login code",
+				zap.Error(err),
+			)
+		}
+	})
+	return nil
+}
+
+// issueLoginCode emails a new code to the user, with the attempts left on the previous one.
+func (a *authUseCase) issueLoginCode(ctx context.Context, cpf string) error {
+	user, err := a.userRepo.GetUserByDocument(ctx, cpf)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case user.Email == "":
+		return nil
+	}
+
+	code, err := newLoginCode()
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	loginCode := domain.NewLoginCode(uuid.New(), user.ID, string(hash), time.Now())
+	previous, err := a.codesRepo.GetLatestLoginCode(ctx, user.ID)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		loginCode.InheritAttempts(previous)
+	}
+	if loginCode.Attempts >= domain.LOGIN_CODE_MAX_ATTEMPTS {
+		a.logger.Infow(
+			"login code not sent, attempts exhausted",
+			zap.String("user_id", user.ID.String()),
+		)
+		return nil
+	}
+
+	if err = a.codesRepo.InsertLoginCode(ctx, loginCode); err != nil {
+		return err
+	}
+
+	err = a.mail.Send(ctx, domain.MailMessage{
+		To:      user.Email,
+		Subject: "Seu código de acesso",
+		Body: fmt.Sprintf("Olá, %s.\n\nSeu código de acesso é %s. Ele vale por %d minutos.\n\nSe não foi você que pediu, ignore este email.\n",
+			user.Name, code, int(domain.LOGIN_CODE_TTL.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("failed sending login code to user %s: %w", user.ID, err)
+	}
+
+	return nil
+}
+
+// VerifyLoginCode issues a session token when the code matches the last one sent to the user. Each guess
+// counts as an attempt, and the code is refused after LOGIN_CODE_MAX_ATTEMPTS or once expired.
+func (a *authUseCase) VerifyLoginCode(ctx context.Context, document, code string) (*domain.AuthToken, error) {
+	cpf, err := domain.NormalizeCPF(document)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetUserByDocument(ctx, cpf)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil, helpers.ErrUnauthorized
+	case err != nil:
+		return nil, err
+	}
+
+	loginCode, err := a.codesRepo.GetLatestLoginCode(ctx, user.ID)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil, helpers.ErrUnauthorized
+	case err != nil:
+		return nil, err
+	}
+
+	if err = loginCode.CanVerify(time.Now()); err != nil {
+		return nil, err
+	}
+
+	// the attempt is counted before comparing, so parallel guesses can not go past LOGIN_CODE_MAX_ATTEMPTS
+	if err = a.codesRepo.ClaimLoginCodeAttempt(ctx, loginCode.ID, domain.LOGIN_CODE_MAX_ATTEMPTS); err != nil {
+		if errors.Is(err, helpers.ErrNotFound) {
+			return nil, domain.ErrLoginCodeAttempts
+		}
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(loginCode.CodeHash), []byte(code)) != nil {
+		a.logger.Errorw(
+			"failed login, wrong code",
+			zap.String("id", user.ID.String()),
+			zap.Int("attempts", loginCode.Attempts+1),
+			zap.Error(helpers.ErrUnauthorized),
+		)
+		return nil, helpers.ErrUnauthorized
+	}
+
+	if err = a.codesRepo.ConsumeLoginCode(ctx, loginCode.ID); err != nil {
+		if errors.Is(err, helpers.ErrNotFound) {
+			return nil, helpers.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return a.signer.Sign(domain.Caller{UserID: user.ID})
+}
+
+// newLoginCode draws a random numeric code of LOGIN_CODE_LENGTH digits.
+func newLoginCode() (string, error) {
+	max := big.NewInt(int64(math.Pow10(domain.LOGIN_CODE_LENGTH)))
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", domain.LOGIN_CODE_LENGTH, n), nil
+}
+
+// Authenticate verifies the token. User tokens are refused once the user is deleted or anonymized, and
+// guest tokens once their session is merged into an account.
+func (a *authUseCase) Authenticate(ctx context.Context, token string) (*domain.Caller, error) {
+	caller, err := a.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsGuest() {
+		return a.authenticateUser(ctx, caller)
+	}
+
+	session, err := a.guestRepo.GetGuestSession(ctx, caller.GuestSessionID)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil, helpers.ErrUnauthorized
+	case err != nil:
+		return nil, err
+	case session.MergedInto != uuid.Nil:
+		return nil, fmt.Errorf("%w: guest session already merged into an account", helpers.ErrUnauthorized)
+	}
+
+	return caller, nil
+}
+
+// authenticateUser checks that the user of the token still exists, the tokens issued before an erasure
+// request being otherwise valid until they expire.
+func (a *authUseCase) authenticateUser(ctx context.Context, caller *domain.Caller) (*domain.Caller, error) {
+	_, err := a.userRepo.GetUser(ctx, caller.UserID)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil, fmt.Errorf("%w: user no longer exists", helpers.ErrUnauthorized)
+	case err != nil:
+		return nil, err
+	}
+
+	return caller, nil
+}
+
+// StartGuestSession opens an anonymous kiosk session, for customers ordering without registering.
+func (a *authUseCase) StartGuestSession(ctx context.Context) (*domain.AuthToken, error) {
+	id := uuid.New()
+
+	token, err := a.signer.Sign(domain.Caller{GuestSessionID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.guestRepo.InsertGuestSession(ctx, domain.NewGuestSession(id, time.Now(), token.ExpiresAt)); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// AttachGuestDocument sets the CPF of the guest, to be printed in the fiscal receipt of the session's orders.
+func (a *authUseCase) AttachGuestDocument(ctx context.Context, document string) (*domain.GuestSession, error) {
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok || !caller.IsGuest() {
+		return nil, helpers.ErrUnauthorized
+	}
+
+	cpf, err := domain.NormalizeCPF(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.guestRepo.SetGuestSessionDocument(ctx, caller.GuestSessionID, cpf); err != nil {
+		return nil, err
+	}
+
+	return a.guestRepo.GetGuestSession(ctx, caller.GuestSessionID)
+}
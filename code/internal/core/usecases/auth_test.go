@@ -0,0 +1,320 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeUsersRepo keeps users by CPF, the methods not overridden panic.
+type fakeUsersRepo struct {
+	ports.UsersRepository
+	users map[string]*domain.User
+}
+
+func (f *fakeUsersRepo) GetUserByDocument(_ context.Context, document string) (*domain.User, error) {
+	if user, ok := f.users[document]; ok {
+		return user, nil
+	}
+	return nil, helpers.NewNotFoundError("user", uuid.Nil)
+}
+
+func (f *fakeUsersRepo) GetUser(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, helpers.NewNotFoundError("user", id)
+}
+
+// fakeSigner signs any caller, and verifies every token as caller when set.
+type fakeSigner struct {
+	caller *domain.Caller
+}
+
+func (fakeSigner) Sign(caller domain.Caller) (*domain.AuthToken, error) {
+	return &domain.AuthToken{Token: "token", UserID: caller.UserID, GuestSessionID: caller.GuestSessionID}, nil
+}
+
+func (f fakeSigner) Verify(string) (*domain.Caller, error) {
+	if f.caller == nil {
+		return nil, helpers.ErrUnauthorized
+	}
+	return f.caller, nil
+}
+
+func TestLogin(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withPassword := &domain.User{ID: uuid.New(), Document: "97580053080", PasswordHash: string(hash)}
+	withoutPassword := &domain.User{ID: uuid.New(), Document: "52998224725"}
+	auth := NewAuthUseCase(zap.NewNop().Sugar(), &fakeUsersRepo{users: map[string]*domain.User{
+		withPassword.Document:    withPassword,
+		withoutPassword.Document: withoutPassword,
+	}}, nil, nil, nil, fakeSigner{})
+
+	tests := []struct {
+		name     string
+		document string
+		password string
+		wantUser uuid.UUID
+	}{
+		{name: "001_should_login_with_password", document: "975.800.530-80", password: "s3cret", wantUser: withPassword.ID},
+		{name: "002_should_refuse_wrong_password", document: "97580053080", password: "guess"},
+		{name: "003_should_refuse_cpf_alone", document: "97580053080"},
+		{name: "004_should_refuse_user_without_password", document: "52998224725"},
+		{name: "005_should_refuse_unknown_cpf", document: "11144477735", password: "s3cret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.Login(context.Background(), tt.document, tt.password)
+			if tt.wantUser == uuid.Nil {
+				if !errors.Is(err, helpers.ErrUnauthorized) {
+					t.Fatalf("Login() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if token.UserID != tt.wantUser {
+				t.Errorf("Login() user = %v, want %v", token.UserID, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Document: "97580053080"}
+	users := &fakeUsersRepo{users: map[string]*domain.User{user.Document: user}}
+
+	tests := []struct {
+		name     string
+		caller   *domain.Caller
+		wantUser uuid.UUID
+	}{
+		{name: "001_should_authenticate_user", caller: &domain.Caller{UserID: user.ID}, wantUser: user.ID},
+		{name: "002_should_refuse_anonymized_or_deleted_user", caller: &domain.Caller{UserID: uuid.New()}},
+		{name: "003_should_refuse_invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuthUseCase(zap.NewNop().Sugar(), users, nil, nil, nil, fakeSigner{caller: tt.caller})
+
+			caller, err := auth.Authenticate(context.Background(), "token")
+			if tt.wantUser == uuid.Nil {
+				if !errors.Is(err, helpers.ErrUnauthorized) {
+					t.Fatalf("Authenticate() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if caller.UserID != tt.wantUser {
+				t.Errorf("Authenticate() user = %v, want %v", caller.UserID, tt.wantUser)
+			}
+		})
+	}
+}
+
+// fakeLoginCodesRepo keeps the codes in memory, claiming attempts under a lock like the conditional update does.
+type fakeLoginCodesRepo struct {
+	mu    sync.Mutex
+	codes []*domain.LoginCode
+}
+
+func (f *fakeLoginCodesRepo) InsertLoginCode(_ context.Context, code *domain.LoginCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *code
+	f.codes = append(f.codes, &stored)
+	return nil
+}
+
+func (f *fakeLoginCodesRepo) GetLatestLoginCode(_ context.Context, userID uuid.UUID) (*domain.LoginCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.codes) - 1; i >= 0; i-- {
+		if f.codes[i].UserID == userID {
+			latest := *f.codes[i]
+			return &latest, nil
+		}
+	}
+	return nil, helpers.NewNotFoundError("login code", uuid.Nil)
+}
+
+func (f *fakeLoginCodesRepo) ClaimLoginCodeAttempt(_ context.Context, id uuid.UUID, maxAttempts int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.codes {
+		if c.ID == id && c.Attempts < maxAttempts && c.ConsumedAt.IsZero() {
+			c.Attempts++
+			return nil
+		}
+	}
+	return helpers.NewNotFoundError("login code", id)
+}
+
+func (f *fakeLoginCodesRepo) ConsumeLoginCode(_ context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.codes {
+		if c.ID == id && c.ConsumedAt.IsZero() {
+			c.ConsumedAt = time.Now()
+			return nil
+		}
+	}
+	return helpers.NewNotFoundError("login code", id)
+}
+
+// expire moves the latest code past its expiration.
+func (f *fakeLoginCodesRepo) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes[len(f.codes)-1].ExpiresAt = time.Now()
+}
+
+type fakeMailSender struct {
+	sent []domain.MailMessage
+	err  error
+}
+
+func (f *fakeMailSender) Send(_ context.Context, message domain.MailMessage) error {
+	f.sent = append(f.sent, message)
+	return f.err
+}
+
+var loginCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// lastCode is the code in the last email sent.
+func (f *fakeMailSender) lastCode() string {
+	if len(f.sent) == 0 {
+		return ""
+	}
+	return loginCodePattern.FindString(f.sent[len(f.sent)-1].Body)
+}
+
+func TestLoginCode(t *testing.T) {
+	const document, unknown, wrongCode = "52998224725", "11144477735", "wrong!"
+	user := &domain.User{ID: uuid.New(), Document: document, Name: "Ana", Email: "ana@example.com"}
+
+	tests := []struct {
+		name string
+		// steps run after a first code is requested, and return the error of the last verification
+		steps    func(auth *authUseCase, codes *fakeLoginCodesRepo, mail *fakeMailSender) error
+		wantErr  error
+		wantSent int
+	}{
+		{
+			name: "001_should_login_with_emailed_code",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				_, err := auth.VerifyLoginCode(context.Background(), document, mail.lastCode())
+				return err
+			},
+			wantSent: 1,
+		},
+		{
+			name: "002_should_refuse_expired_code",
+			steps: func(auth *authUseCase, codes *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				codes.expire()
+				_, err := auth.VerifyLoginCode(context.Background(), document, mail.lastCode())
+				return err
+			},
+			wantErr:  helpers.ErrUnauthorized,
+			wantSent: 1,
+		},
+		{
+			name: "003_should_lock_out_after_max_attempts",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				for i := 0; i < domain.LOGIN_CODE_MAX_ATTEMPTS; i++ {
+					_, _ = auth.VerifyLoginCode(context.Background(), document, wrongCode)
+				}
+				_, err := auth.VerifyLoginCode(context.Background(), document, mail.lastCode())
+				return err
+			},
+			wantErr:  domain.ErrLoginCodeAttempts,
+			wantSent: 1,
+		},
+		{
+			name: "004_should_keep_attempts_across_new_codes",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				for i := 0; i < domain.LOGIN_CODE_MAX_ATTEMPTS-1; i++ {
+					_, _ = auth.VerifyLoginCode(context.Background(), document, wrongCode)
+				}
+				_ = auth.RequestLoginCode(context.Background(), document)
+				_, _ = auth.VerifyLoginCode(context.Background(), document, wrongCode)
+				_ = auth.RequestLoginCode(context.Background(), document)
+				_, err := auth.VerifyLoginCode(context.Background(), document, mail.lastCode())
+				return err
+			},
+			wantErr:  domain.ErrLoginCodeAttempts,
+			wantSent: 2,
+		},
+		{
+			name: "005_should_not_exceed_max_attempts_with_parallel_guesses",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				var wg sync.WaitGroup
+				for i := 0; i < 4*domain.LOGIN_CODE_MAX_ATTEMPTS; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						_, _ = auth.VerifyLoginCode(context.Background(), document, wrongCode)
+					}()
+				}
+				wg.Wait()
+				_, err := auth.VerifyLoginCode(context.Background(), document, mail.lastCode())
+				return err
+			},
+			wantErr:  domain.ErrLoginCodeAttempts,
+			wantSent: 1,
+		},
+		{
+			name: "006_should_answer_unknown_cpf_alike",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, _ *fakeMailSender) error {
+				return auth.RequestLoginCode(context.Background(), unknown)
+			},
+			wantSent: 1,
+		},
+		{
+			name: "007_should_answer_failed_email_alike",
+			steps: func(auth *authUseCase, _ *fakeLoginCodesRepo, mail *fakeMailSender) error {
+				mail.err = errors.New("smtp: connection refused")
+				return auth.RequestLoginCode(context.Background(), document)
+			},
+			wantSent: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, mail := &fakeLoginCodesRepo{}, &fakeMailSender{}
+			auth := NewAuthUseCase(zap.NewNop().Sugar(), &fakeUsersRepo{users: map[string]*domain.User{document: user}},
+				nil, codes, mail, fakeSigner{}).(*authUseCase)
+			auth.background = func(task func()) { task() }
+
+			if err := auth.RequestLoginCode(context.Background(), document); err != nil {
+				t.Fatalf("RequestLoginCode() error = %v", err)
+			}
+			err := tt.steps(auth, codes, mail)
+			if (tt.wantErr == nil && err != nil) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(mail.sent) != tt.wantSent {
+				t.Errorf("emails sent = %d, want %d", len(mail.sent), tt.wantSent)
+			}
+		})
+	}
+}
@@ -3,29 +3,74 @@ package usecases
 import (
 	"context"
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-func isAdmin(log *zap.SugaredLogger, uRepo ports.UsersUseCase, ctx context.Context, userID uuid.UUID) bool {
-	admin, err := uRepo.IsUserAdmin(ctx, userID)
+// callerID returns the ID of the user authenticated for the request. Guests and API keys are not users,
+// so they get ErrUnauthorized.
+func callerID(ctx context.Context) (uuid.UUID, error) {
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok || caller.IsGuest() || caller.IsAPIKey() {
+		return uuid.Nil, helpers.ErrUnauthorized
+	}
+	return caller.UserID, nil
+}
+
+// isAllowed tells whether any role of the caller grants the permission, see domain.RolePermissions.
+// API keys are limited to their scopes instead.
+func isAllowed(log *zap.SugaredLogger, uRepo ports.UsersUseCase, ctx context.Context, permission domain.Permission) bool {
+	if caller, ok := domain.CallerFromContext(ctx); ok && caller.IsAPIKey() {
+		if !caller.HasScope(permission) {
+			log.Errorw(
+				"api key out of scope",
+				zap.String("api_key_id", caller.APIKeyID.String()),
+				zap.String("permission", string(permission)),
+				zap.Error(helpers.ErrUnauthorized),
+			)
+			return false
+		}
+		return true
+	}
+
+	userID, err := callerID(ctx)
+	if err != nil {
+		log.Errorw(
+			"unauthenticated caller",
+			zap.String("permission", string(permission)),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	roles, err := uRepo.ListUserRoles(ctx, userID)
 	switch {
 	case err != nil:
 		log.Errorw(
-			"failed checking user is admin",
+			"failed checking user roles",
 			zap.String("userID", userID.String()),
 			zap.Error(err),
 		)
 		return false
-	case !admin:
+	case !domain.RolesAllow(roles, permission):
 		log.Errorw(
 			"unauthorized user",
 			zap.String("id", userID.String()),
+			zap.String("permission", string(permission)),
 			zap.Error(helpers.ErrUnauthorized),
 		)
-		err = helpers.ErrUnauthorized
 		return false
 	}
 	return true
 }
+
+// denied is the error for a caller isAllowed refused: ErrUnauthorized when it is not authenticated at all,
+// a ForbiddenError when it is but lacks the permission.
+func denied(ctx context.Context, permission domain.Permission) error {
+	if _, ok := domain.CallerFromContext(ctx); !ok {
+		return helpers.ErrUnauthorized
+	}
+	return helpers.NewForbiddenError(string(permission))
+}
@@ -0,0 +1,523 @@
+package usecases
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const catalogExportPageSize = 100
+
+var (
+	catalogCSVHeader = []string{
+		"category", "name", "description", "price", "allergens",
+		"serving_size_g", "calories_kcal", "carbohydrates_g", "sugars_g", "proteins_g",
+		"fats_g", "saturated_fats_g", "fibers_g", "sodium_mg",
+	}
+	// catalogCSVBasicColumns are the columns of files without allergens and nutrition facts.
+	catalogCSVBasicColumns = 4
+)
+
+// catalogAllergenSeparator separates the allergens within their CSV column.
+const catalogAllergenSeparator = ";"
+
+type catalogJSONRow struct {
+	Category    string `json:"category"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Price       string `json:"price,omitempty"`
+	// Allergens is only absent, rather than empty, in files without allergens and nutrition facts.
+	Allergens *[]domain.Allergen    `json:"allergens,omitempty"`
+	Nutrition *catalogJSONNutrition `json:"nutrition,omitempty"`
+}
+
+type catalogJSONNutrition struct {
+	ServingSizeGrams   decimal.Decimal `json:"serving_size_g"`
+	Calories           decimal.Decimal `json:"calories_kcal"`
+	CarbohydratesGrams decimal.Decimal `json:"carbohydrates_g"`
+	SugarsGrams        decimal.Decimal `json:"sugars_g"`
+	ProteinsGrams      decimal.Decimal `json:"proteins_g"`
+	FatsGrams          decimal.Decimal `json:"fats_g"`
+	SaturatedFatsGrams decimal.Decimal `json:"saturated_fats_g"`
+	FibersGrams        decimal.Decimal `json:"fibers_g"`
+	SodiumMilligrams   decimal.Decimal `json:"sodium_mg"`
+}
+
+type catalogUseCase struct {
+	logger      *zap.SugaredLogger
+	catRepo     ports.CategoriesRepository
+	productRepo ports.ProductsRepository
+	prodUC      ports.ProductsUseCase
+	userUC      ports.UsersUseCase
+	uow         ports.UnitOfWork
+	audit       auditor
+}
+
+func NewCatalogUseCase(
+	logger *zap.SugaredLogger,
+	catRepo ports.CategoriesRepository,
+	productRepo ports.ProductsRepository,
+	auditRepo ports.AuditRepository,
+	prodUC ports.ProductsUseCase,
+	userUC ports.UsersUseCase,
+	uow ports.UnitOfWork,
+) ports.CatalogUseCase {
+	return &catalogUseCase{
+		logger:      logger,
+		catRepo:     catRepo,
+		productRepo: productRepo,
+		prodUC:      prodUC,
+		userUC:      userUC,
+		uow:         uow,
+		audit:       newAuditor(logger, auditRepo),
+	}
+}
+
+// errCatalogDryRun rolls back the unit of work of a dry run.
+var errCatalogDryRun = errors.New("catalog dry run")
+
+// ImportCatalog upserts categories by name and products by name within their category.
+// Every row is validated and reported individually, so one bad row does not stop the others.
+// When dryRun is set the import runs the same way in a transaction that is rolled back, so the
+// report shows what a real import would do and nothing is written.
+func (c *catalogUseCase) ImportCatalog(ctx context.Context, format domain.CatalogFormat, in io.Reader, dryRun bool) (*domain.CatalogImportReport, error) {
+	if !isAllowed(c.logger, c.userUC, ctx, domain.PERMISSION_CATALOG_IMPORT) {
+		return nil, denied(ctx, domain.PERMISSION_CATALOG_IMPORT)
+	}
+
+	rows, err := decodeCatalog(format, in)
+	if err != nil {
+		c.logger.Errorw(
+			"failed decoding catalog",
+			zap.String("format", string(format)),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if !dryRun {
+		report := c.importRows(ctx, rows)
+
+		// products are audited one by one as they go through the products use case, the import itself
+		// is recorded by its totals
+		totals := *report
+		totals.Results = nil
+		c.audit.record(ctx, domain.AUDIT_CATALOG_IMPORT, domain.AUDIT_ENTITY_CATALOG, uuid.Nil, nil, totals)
+
+		return report, nil
+	}
+
+	var report *domain.CatalogImportReport
+	err = c.uow.Do(ctx, func(ctx context.Context) error {
+		report = c.importRows(ctx, rows)
+		return errCatalogDryRun
+	})
+	if !errors.Is(err, errCatalogDryRun) {
+		return nil, err
+	}
+	report.DryRun = true
+
+	return report, nil
+}
+
+func (c *catalogUseCase) importRows(ctx context.Context, rows []domain.CatalogRow) *domain.CatalogImportReport {
+	report := &domain.CatalogImportReport{}
+	// categories resolved so far, by name
+	categories := make(map[string]*domain.Category)
+
+	for _, row := range rows {
+		result := c.importRow(ctx, row, categories)
+		switch {
+		case result.Error != "":
+			report.Failed++
+		case result.ProductAction == domain.CATALOG_ACTION_CREATE:
+			report.ProductsCreated++
+		case result.ProductAction == domain.CATALOG_ACTION_UPDATE:
+			report.ProductsUpdated++
+		}
+		if result.CategoryAction == domain.CATALOG_ACTION_CREATE {
+			report.CategoriesCreated++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// importRow writes the row in its own unit of work, so a failing row leaves nothing behind, not even
+// the category it created.
+func (c *catalogUseCase) importRow(ctx context.Context, row domain.CatalogRow, categories map[string]*domain.Category) domain.CatalogImportResult {
+	result := domain.CatalogImportResult{
+		Line:           row.Line,
+		Category:       row.Category,
+		Product:        row.Name,
+		CategoryAction: domain.CATALOG_ACTION_NONE,
+		ProductAction:  domain.CATALOG_ACTION_NONE,
+	}
+
+	var cat *domain.Category
+	err := c.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		cat, err = c.upsertRow(ctx, row, categories, &result)
+		return err
+	})
+	if err != nil {
+		result.CategoryAction = domain.CATALOG_ACTION_NONE
+		result.ProductAction = domain.CATALOG_ACTION_ERROR
+		result.Error = err.Error()
+		return result
+	}
+	categories[row.Category] = cat
+
+	return result
+}
+
+// upsertRow creates the category of the row when missing and creates or updates its product, returning
+// the category.
+func (c *catalogUseCase) upsertRow(ctx context.Context, row domain.CatalogRow, categories map[string]*domain.Category, result *domain.CatalogImportResult) (*domain.Category, error) {
+	price, err := validateCatalogRow(row)
+	if err != nil {
+		return nil, err
+	}
+
+	cat, seen := categories[row.Category]
+	if !seen {
+		cat, err = c.catRepo.GetCategoryByName(ctx, row.Category)
+		switch {
+		case errors.Is(err, helpers.ErrNotFound):
+			result.CategoryAction = domain.CATALOG_ACTION_CREATE
+			cat, err = c.catRepo.InsertCategory(ctx, domain.NewCategory(uuid.New(), time.Now(), row.Category))
+			if err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if row.Name == "" {
+		return cat, nil
+	}
+
+	existing, err := c.productRepo.GetProductByName(ctx, cat.ID, row.Name)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		result.ProductAction = domain.CATALOG_ACTION_CREATE
+		product := domain.NewProduct(uuid.New(), cat.ID, row.Name, row.Description, row.Price)
+		product.Price = price
+		product.Allergens = row.Allergens
+		product.Nutrition = row.Nutrition
+		if _, err = c.prodUC.InsertProduct(ctx, product); err != nil {
+			return nil, err
+		}
+		return cat, nil
+	case err != nil:
+		return nil, err
+	}
+
+	result.ProductAction = domain.CATALOG_ACTION_UPDATE
+	existing.Description = row.Description
+	existing.Price = price
+	if row.HasDetails {
+		existing.Allergens = row.Allergens
+		existing.Nutrition = row.Nutrition
+	}
+	if _, err = c.prodUC.UpdateProduct(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	return cat, nil
+}
+
+func validateCatalogRow(row domain.CatalogRow) (decimal.Decimal, error) {
+	if row.Category == "" {
+		return decimal.Zero, fmt.Errorf("%w: category is required", helpers.ErrInvalidInput)
+	}
+	if row.Name == "" {
+		if row.Description != "" || row.Price != "" || len(row.Allergens) > 0 || row.Nutrition != nil {
+			return decimal.Zero, fmt.Errorf("%w: product name is required", helpers.ErrInvalidInput)
+		}
+		return decimal.Zero, nil
+	}
+
+	price, err := helpers.ParseDecimalFromString(row.Price)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: price must be greater than zero", helpers.ErrInvalidInput)
+	}
+
+	return price, nil
+}
+
+// ExportCatalog writes every category and its products in the same format read by ImportCatalog.
+func (c *catalogUseCase) ExportCatalog(ctx context.Context, format domain.CatalogFormat, out io.Writer) error {
+	if !isAllowed(c.logger, c.userUC, ctx, domain.PERMISSION_CATALOG_EXPORT) {
+		return denied(ctx, domain.PERMISSION_CATALOG_EXPORT)
+	}
+
+	var rows []domain.CatalogRow
+	for offset := 0; ; offset += catalogExportPageSize {
+		catList, err := c.catRepo.ListCategories(ctx, catalogExportPageSize, offset, false)
+		if err != nil {
+			return err
+		}
+
+		for _, cat := range catList.Categories {
+			catRows, err := c.exportCategory(ctx, cat)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, catRows...)
+		}
+
+		if len(catList.Categories) < catalogExportPageSize {
+			break
+		}
+	}
+
+	return encodeCatalog(format, out, rows)
+}
+
+func (c *catalogUseCase) exportCategory(ctx context.Context, cat *domain.Category) ([]domain.CatalogRow, error) {
+	var rows []domain.CatalogRow
+	for offset := 0; ; offset += catalogExportPageSize {
+		prodList, err := c.productRepo.ListProductsByCategory(ctx, cat.ID, domain.Page{Limit: catalogExportPageSize, Offset: offset}, domain.ProductFilter{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range prodList.Products {
+			rows = append(rows, domain.CatalogRow{
+				Category:    cat.Name,
+				Name:        p.Name,
+				Description: p.Description,
+				Price:       helpers.ParseDecimalToString(p.Price),
+				Allergens:   p.Allergens,
+				Nutrition:   p.Nutrition,
+				HasDetails:  true,
+			})
+		}
+
+		if len(prodList.Products) < catalogExportPageSize {
+			break
+		}
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, domain.CatalogRow{Category: cat.Name})
+	}
+
+	return rows, nil
+}
+
+func decodeCatalog(format domain.CatalogFormat, in io.Reader) ([]domain.CatalogRow, error) {
+	switch format {
+	case domain.CATALOG_FORMAT_CSV:
+		return decodeCatalogCSV(in)
+	case domain.CATALOG_FORMAT_JSON:
+		return decodeCatalogJSON(in)
+	}
+	return nil, fmt.Errorf("%w: unknown catalog format %q", helpers.ErrBadRequest, format)
+}
+
+func decodeCatalogCSV(in io.Reader) ([]domain.CatalogRow, error) {
+	reader := csv.NewReader(in)
+	reader.TrimLeadingSpace = true
+
+	// every record has as many columns as the first one
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", helpers.ErrBadRequest, err)
+	}
+	if len(records) > 0 && len(records[0]) != catalogCSVBasicColumns && len(records[0]) != len(catalogCSVHeader) {
+		return nil, fmt.Errorf("%w: catalog must have the columns %s, the ones after price being optional",
+			helpers.ErrBadRequest, strings.Join(catalogCSVHeader, ","))
+	}
+
+	var rows []domain.CatalogRow
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], catalogCSVHeader[0]) {
+			continue
+		}
+		row := domain.CatalogRow{
+			Line:        i + 1,
+			Category:    strings.TrimSpace(record[0]),
+			Name:        strings.TrimSpace(record[1]),
+			Description: strings.TrimSpace(record[2]),
+			Price:       strings.TrimSpace(record[3]),
+		}
+		if len(record) > catalogCSVBasicColumns {
+			if row.Allergens, row.Nutrition, err = decodeCatalogCSVDetails(record[catalogCSVBasicColumns:]); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %s", helpers.ErrBadRequest, i+1, err)
+			}
+			row.HasDetails = row.Name != ""
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// decodeCatalogCSVDetails reads the allergens column and the nutrition facts columns, all of them empty
+// meaning the product has no nutrition facts.
+func decodeCatalogCSVDetails(columns []string) ([]domain.Allergen, *domain.NutritionFacts, error) {
+	var allergens []domain.Allergen
+	for _, a := range strings.Split(columns[0], catalogAllergenSeparator) {
+		if a = strings.TrimSpace(a); a != "" {
+			allergens = append(allergens, domain.Allergen(a))
+		}
+	}
+
+	values := make([]decimal.Decimal, 0, len(columns)-1)
+	empty := true
+	for _, column := range columns[1:] {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			values = append(values, decimal.Zero)
+			continue
+		}
+		v, err := decimal.NewFromString(column)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid nutrition value %q", column)
+		}
+		values = append(values, v)
+		empty = false
+	}
+	if empty {
+		return allergens, nil, nil
+	}
+
+	return allergens, &domain.NutritionFacts{
+		ServingSizeGrams:   values[0],
+		Calories:           values[1],
+		CarbohydratesGrams: values[2],
+		SugarsGrams:        values[3],
+		ProteinsGrams:      values[4],
+		FatsGrams:          values[5],
+		SaturatedFatsGrams: values[6],
+		FibersGrams:        values[7],
+		SodiumMilligrams:   values[8],
+	}, nil
+}
+
+// encodeCatalogCSVDetails writes the allergens and nutrition facts columns of the row.
+func encodeCatalogCSVDetails(row domain.CatalogRow) []string {
+	allergens := make([]string, 0, len(row.Allergens))
+	for _, a := range row.Allergens {
+		allergens = append(allergens, string(a))
+	}
+	columns := []string{strings.Join(allergens, catalogAllergenSeparator)}
+
+	n := row.Nutrition
+	if n == nil {
+		return append(columns, make([]string, len(catalogCSVHeader)-catalogCSVBasicColumns-1)...)
+	}
+	for _, v := range []decimal.Decimal{
+		n.ServingSizeGrams, n.Calories, n.CarbohydratesGrams, n.SugarsGrams, n.ProteinsGrams,
+		n.FatsGrams, n.SaturatedFatsGrams, n.FibersGrams, n.SodiumMilligrams,
+	} {
+		columns = append(columns, v.String())
+	}
+	return columns
+}
+
+func decodeCatalogJSON(in io.Reader) ([]domain.CatalogRow, error) {
+	var jsonRows []catalogJSONRow
+	if err := json.NewDecoder(in).Decode(&jsonRows); err != nil {
+		return nil, fmt.Errorf("%w: %s", helpers.ErrBadRequest, err)
+	}
+
+	rows := make([]domain.CatalogRow, 0, len(jsonRows))
+	for i, r := range jsonRows {
+		row := domain.CatalogRow{
+			Line:        i + 1,
+			Category:    strings.TrimSpace(r.Category),
+			Name:        strings.TrimSpace(r.Name),
+			Description: strings.TrimSpace(r.Description),
+			Price:       strings.TrimSpace(r.Price),
+			HasDetails:  r.Allergens != nil || r.Nutrition != nil,
+		}
+		if r.Allergens != nil && len(*r.Allergens) > 0 {
+			row.Allergens = *r.Allergens
+		}
+		if n := r.Nutrition; n != nil {
+			row.Nutrition = &domain.NutritionFacts{
+				ServingSizeGrams:   n.ServingSizeGrams,
+				Calories:           n.Calories,
+				CarbohydratesGrams: n.CarbohydratesGrams,
+				SugarsGrams:        n.SugarsGrams,
+				ProteinsGrams:      n.ProteinsGrams,
+				FatsGrams:          n.FatsGrams,
+				SaturatedFatsGrams: n.SaturatedFatsGrams,
+				FibersGrams:        n.FibersGrams,
+				SodiumMilligrams:   n.SodiumMilligrams,
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func encodeCatalog(format domain.CatalogFormat, out io.Writer, rows []domain.CatalogRow) error {
+	switch format {
+	case domain.CATALOG_FORMAT_CSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write(catalogCSVHeader); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := writer.Write(append([]string{r.Category, r.Name, r.Description, r.Price}, encodeCatalogCSVDetails(r)...)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case domain.CATALOG_FORMAT_JSON:
+		jsonRows := make([]catalogJSONRow, 0, len(rows))
+		for _, r := range rows {
+			jsonRow := catalogJSONRow{
+				Category:    r.Category,
+				Name:        r.Name,
+				Description: r.Description,
+				Price:       r.Price,
+			}
+			if r.HasDetails {
+				allergens := append([]domain.Allergen{}, r.Allergens...)
+				jsonRow.Allergens = &allergens
+			}
+			if n := r.Nutrition; n != nil {
+				jsonRow.Nutrition = &catalogJSONNutrition{
+					ServingSizeGrams:   n.ServingSizeGrams,
+					Calories:           n.Calories,
+					CarbohydratesGrams: n.CarbohydratesGrams,
+					SugarsGrams:        n.SugarsGrams,
+					ProteinsGrams:      n.ProteinsGrams,
+					FatsGrams:          n.FatsGrams,
+					SaturatedFatsGrams: n.SaturatedFatsGrams,
+					FibersGrams:        n.FibersGrams,
+					SodiumMilligrams:   n.SodiumMilligrams,
+				}
+			}
+			jsonRows = append(jsonRows, jsonRow)
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(jsonRows)
+	}
+	return fmt.Errorf("%w: unknown catalog format %q", helpers.ErrBadRequest, format)
+}
@@ -0,0 +1,335 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func TestCatalogRoundTrip(t *testing.T) {
+	rows := []domain.CatalogRow{
+		{
+			Category:    "Lanche",
+			Name:        "X-Burger",
+			Description: "Pão, carne e queijo",
+			Price:       "R$ 25,90",
+			Allergens:   []domain.Allergen{domain.ALLERGEN_GLUTEN, domain.ALLERGEN_MILK},
+			Nutrition: &domain.NutritionFacts{
+				ServingSizeGrams:   decimal.RequireFromString("180"),
+				Calories:           decimal.RequireFromString("512.5"),
+				CarbohydratesGrams: decimal.RequireFromString("40"),
+				SugarsGrams:        decimal.RequireFromString("6.2"),
+				ProteinsGrams:      decimal.RequireFromString("28"),
+				FatsGrams:          decimal.RequireFromString("25"),
+				SaturatedFatsGrams: decimal.RequireFromString("11"),
+				FibersGrams:        decimal.RequireFromString("2"),
+				SodiumMilligrams:   decimal.RequireFromString("890"),
+			},
+			HasDetails: true,
+		},
+		{Category: "Bebida", Name: "Água", Price: "R$ 4,00", HasDetails: true},
+		{Category: "Sobremesa"},
+	}
+
+	tests := []struct {
+		name   string
+		format domain.CatalogFormat
+	}{
+		{name: "001_should_round_trip_csv", format: domain.CATALOG_FORMAT_CSV},
+		{name: "002_should_round_trip_json", format: domain.CATALOG_FORMAT_JSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeCatalog(tt.format, &buf, rows); err != nil {
+				t.Fatalf("encodeCatalog() error = %v", err)
+			}
+
+			got, err := decodeCatalog(tt.format, &buf)
+			if err != nil {
+				t.Fatalf("decodeCatalog() error = %v", err)
+			}
+			for i := range got {
+				got[i].Line = 0
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Errorf("decodeCatalog() got = %v, want %v", got, rows)
+			}
+		})
+	}
+}
+
+func TestDecodeCatalogCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "001_should_skip_header",
+			in:   "category,name,description,price\nBebida,Suco,Laranja,\"R$ 8,00\"\n",
+			want: 1,
+		},
+		{
+			name: "002_should_read_without_header",
+			in:   "Bebida,Suco,Laranja,\"R$ 8,00\"\nBebida,Água,,\"R$ 4,00\"\n",
+			want: 2,
+		},
+		{
+			name: "003_should_read_allergens_and_nutrition",
+			in:   "Bebida,Suco,Laranja,\"R$ 8,00\",,200,90,22,20,1,0,0,0,5\nLanche,Misto,,\"R$ 12,00\",gluten;milk,,,,,,,,,\n",
+			want: 2,
+		},
+		{
+			name:    "004_should_fail_on_invalid_nutrition",
+			in:      "Bebida,Suco,Laranja,\"R$ 8,00\",,200,many,22,20,1,0,0,0,5\n",
+			wantErr: true,
+		},
+		{
+			name:    "005_should_fail_on_wrong_column_count",
+			in:      "Bebida,Suco\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCatalogCSV(strings.NewReader(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeCatalogCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("decodeCatalogCSV() got %d rows, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCatalogRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     domain.CatalogRow
+		wantErr bool
+	}{
+		{name: "001_should_accept_product", row: domain.CatalogRow{Category: "Bebida", Name: "Suco", Price: "R$ 8,00"}},
+		{name: "002_should_accept_category_only", row: domain.CatalogRow{Category: "Bebida"}},
+		{name: "003_should_refuse_missing_category", row: domain.CatalogRow{Name: "Suco", Price: "8,00"}, wantErr: true},
+		{name: "004_should_refuse_zero_price", row: domain.CatalogRow{Category: "Bebida", Name: "Suco", Price: "0"}, wantErr: true},
+		{name: "005_should_refuse_bad_price", row: domain.CatalogRow{Category: "Bebida", Name: "Suco", Price: "oito"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validateCatalogRow(tt.row); (err != nil) != tt.wantErr {
+				t.Errorf("validateCatalogRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeCatalogStore keeps the categories and products written by an import, by name.
+type fakeCatalogStore struct {
+	ports.CategoriesRepository
+	ports.ProductsRepository
+	ports.ProductPricesRepository
+	ports.AuditRepository
+	categories map[string]domain.Category
+	products   map[string]domain.Product
+}
+
+func (f *fakeCatalogStore) snapshot() func() {
+	categories, products := map[string]domain.Category{}, map[string]domain.Product{}
+	for k, v := range f.categories {
+		categories[k] = v
+	}
+	for k, v := range f.products {
+		products[k] = v
+	}
+	return func() { f.categories, f.products = categories, products }
+}
+
+func (f *fakeCatalogStore) GetCategoryByName(_ context.Context, name string) (*domain.Category, error) {
+	if cat, ok := f.categories[name]; ok {
+		return &cat, nil
+	}
+	return nil, helpers.NewNotFoundError("category", uuid.Nil)
+}
+
+func (f *fakeCatalogStore) InsertCategory(_ context.Context, in *domain.Category) (*domain.Category, error) {
+	f.categories[in.Name] = *in
+	return in, nil
+}
+
+func (f *fakeCatalogStore) GetProductByName(_ context.Context, categoryID uuid.UUID, name string) (*domain.Product, error) {
+	if product, ok := f.products[name]; ok && product.CategoryID == categoryID {
+		return &product, nil
+	}
+	return nil, helpers.NewNotFoundError("product", uuid.Nil)
+}
+
+func (f *fakeCatalogStore) GetProduct(_ context.Context, id uuid.UUID) (*domain.Product, error) {
+	for _, product := range f.products {
+		if product.ID == id {
+			return &product, nil
+		}
+	}
+	return nil, helpers.NewNotFoundError("product", id)
+}
+
+func (f *fakeCatalogStore) InsertProduct(_ context.Context, in *domain.Product) (*domain.Product, error) {
+	f.products[in.Name] = *in
+	return in, nil
+}
+
+func (f *fakeCatalogStore) UpdateProduct(_ context.Context, in *domain.Product) (*domain.Product, error) {
+	f.products[in.Name] = *in
+	return in, nil
+}
+
+func (f *fakeCatalogStore) InsertProductPrice(_ context.Context, in *domain.ProductPrice) (*domain.ProductPrice, error) {
+	return in, nil
+}
+
+func (f *fakeCatalogStore) InsertAuditEntry(context.Context, *domain.AuditEntry) error {
+	return nil
+}
+
+func TestImportCatalog(t *testing.T) {
+	const rows = `[
+		{"category": "Lanche", "name": "X-Burger", "price": "R$ 25,90"},
+		{"category": "Bebida", "name": "Suco", "price": "R$ 8,00"}
+	]`
+	importer := []domain.Permission{domain.PERMISSION_CATALOG_IMPORT, domain.PERMISSION_PRODUCTS_CREATE, domain.PERMISSION_PRODUCTS_UPDATE}
+
+	tests := []struct {
+		name           string
+		scopes         []domain.Permission
+		dryRun         bool
+		wantCreated    int
+		wantFailed     int
+		wantCategories int
+		wantProducts   int
+	}{
+		{
+			name:           "001_should_import_rows",
+			scopes:         importer,
+			wantCreated:    2,
+			wantCategories: 3,
+			wantProducts:   3,
+		},
+		{
+			name:           "002_should_write_nothing_on_dry_run",
+			scopes:         importer,
+			dryRun:         true,
+			wantCreated:    2,
+			wantCategories: 1,
+			wantProducts:   1,
+		},
+		{
+			name:           "003_should_report_rows_the_products_use_case_refuses_on_dry_run",
+			scopes:         []domain.Permission{domain.PERMISSION_CATALOG_IMPORT, domain.PERMISSION_PRODUCTS_UPDATE},
+			dryRun:         true,
+			wantFailed:     2,
+			wantCategories: 1,
+			wantProducts:   1,
+		},
+		{
+			name:           "004_should_not_keep_category_of_failed_row",
+			scopes:         []domain.Permission{domain.PERMISSION_CATALOG_IMPORT, domain.PERMISSION_PRODUCTS_UPDATE},
+			wantFailed:     2,
+			wantCategories: 1,
+			wantProducts:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sobremesa := domain.NewCategory(uuid.New(), time.Now(), "Sobremesa")
+			store := &fakeCatalogStore{
+				categories: map[string]domain.Category{sobremesa.Name: *sobremesa},
+				products: map[string]domain.Product{
+					"Pudim": *domain.NewProduct(uuid.New(), sobremesa.ID, "Pudim", "", "R$ 9,00"),
+				},
+			}
+			uow := fakeUnitOfWork{snapshot: store.snapshot}
+			log := zap.NewNop().Sugar()
+			products := NewProductsUseCase(store, store, store, uow, nil, log)
+			catalog := NewCatalogUseCase(log, store, store, store, products, nil, uow)
+			ctx := domain.ContextWithCaller(context.Background(), domain.Caller{APIKeyID: uuid.New(), Scopes: tt.scopes})
+
+			report, err := catalog.ImportCatalog(ctx, domain.CATALOG_FORMAT_JSON, strings.NewReader(rows), tt.dryRun)
+			if err != nil {
+				t.Fatalf("ImportCatalog() error = %v", err)
+			}
+			if report.DryRun != tt.dryRun || report.ProductsCreated != tt.wantCreated || report.Failed != tt.wantFailed {
+				t.Errorf("ImportCatalog() report = %+v, want created %d and failed %d", report, tt.wantCreated, tt.wantFailed)
+			}
+			if len(store.categories) != tt.wantCategories || len(store.products) != tt.wantProducts {
+				t.Errorf("stored %d categories and %d products, want %d and %d",
+					len(store.categories), len(store.products), tt.wantCategories, tt.wantProducts)
+			}
+		})
+	}
+}
+
+func TestImportCatalogDetails(t *testing.T) {
+	tests := []struct {
+		name          string
+		rows          string
+		wantAllergens []domain.Allergen
+		wantNutrition bool
+	}{
+		{
+			name:          "001_should_update_allergens_and_nutrition",
+			rows:          "category,name,description,price,allergens,serving_size_g,calories_kcal,carbohydrates_g,sugars_g,proteins_g,fats_g,saturated_fats_g,fibers_g,sodium_mg\nSobremesa,Pudim,,\"R$ 9,00\",eggs,120,250,35,30,6,8,5,0,80\n",
+			wantAllergens: []domain.Allergen{domain.ALLERGEN_EGGS},
+			wantNutrition: true,
+		},
+		{
+			name: "002_should_clear_allergens_left_empty",
+			rows: "Sobremesa,Pudim,,\"R$ 9,00\",,,,,,,,,,\n",
+		},
+		{
+			name:          "003_should_keep_details_of_files_without_them",
+			rows:          "category,name,description,price\nSobremesa,Pudim,,\"R$ 9,00\"\n",
+			wantAllergens: []domain.Allergen{domain.ALLERGEN_MILK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sobremesa := domain.NewCategory(uuid.New(), time.Now(), "Sobremesa")
+			pudim := domain.NewProduct(uuid.New(), sobremesa.ID, "Pudim", "", "R$ 9,00")
+			pudim.Allergens = []domain.Allergen{domain.ALLERGEN_MILK}
+			store := &fakeCatalogStore{
+				categories: map[string]domain.Category{sobremesa.Name: *sobremesa},
+				products:   map[string]domain.Product{pudim.Name: *pudim},
+			}
+			uow := fakeUnitOfWork{snapshot: store.snapshot}
+			log := zap.NewNop().Sugar()
+			catalog := NewCatalogUseCase(log, store, store, store, NewProductsUseCase(store, store, store, uow, nil, log), nil, uow)
+			ctx := domain.ContextWithCaller(context.Background(), domain.Caller{APIKeyID: uuid.New(), Scopes: []domain.Permission{
+				domain.PERMISSION_CATALOG_IMPORT, domain.PERMISSION_PRODUCTS_UPDATE,
+			}})
+
+			report, err := catalog.ImportCatalog(ctx, domain.CATALOG_FORMAT_CSV, strings.NewReader(tt.rows), false)
+			if err != nil || report.ProductsUpdated != 1 {
+				t.Fatalf("ImportCatalog() = %+v, %v, want one product updated", report, err)
+			}
+			got := store.products[pudim.Name]
+			if len(got.Allergens) != len(tt.wantAllergens) || (len(got.Allergens) > 0 && !reflect.DeepEqual(got.Allergens, tt.wantAllergens)) {
+				t.Errorf("allergens = %v, want %v", got.Allergens, tt.wantAllergens)
+			}
+			if (got.Nutrition != nil) != tt.wantNutrition {
+				t.Errorf("nutrition = %+v, want present %v", got.Nutrition, tt.wantNutrition)
+			}
+		})
+	}
+}
@@ -2,29 +2,38 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
+const categoryNameMaxLength = 40
+
 type categoriesUseCase struct {
-	log     *zap.SugaredLogger
-	catRepo ports.CategoriesRepository
-	userUC  ports.UsersUseCase
+	log      *zap.SugaredLogger
+	catRepo  ports.CategoriesRepository
+	prodRepo ports.ProductsRepository
+	uow      ports.UnitOfWork
+	userUC   ports.UsersUseCase
+	audit    auditor
 }
 
-func NewCategoriesUseCase(logger *zap.SugaredLogger, repo ports.CategoriesRepository, userUC ports.UsersUseCase) ports.CategoriesUseCase {
-	return &categoriesUseCase{log: logger, catRepo: repo, userUC: userUC}
+func NewCategoriesUseCase(logger *zap.SugaredLogger, repo ports.CategoriesRepository, prodRepo ports.ProductsRepository, auditRepo ports.AuditRepository, uow ports.UnitOfWork, userUC ports.UsersUseCase) ports.CategoriesUseCase {
+	return &categoriesUseCase{log: logger, catRepo: repo, prodRepo: prodRepo, uow: uow, userUC: userUC, audit: newAuditor(logger, auditRepo)}
 }
 
-func (c *categoriesUseCase) ListCategories(ctx context.Context, userID uuid.UUID, limit, offset int) (*domain.CategoryList, error) {
-	if !isAdmin(c.log, c.userUC, ctx, userID) {
-		return nil, helpers.ErrUnauthorized
+func (c *categoriesUseCase) ListCategories(ctx context.Context, limit, offset int, includeDeleted bool) (*domain.CategoryList, error) {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_LIST) {
+		return nil, denied(ctx, domain.PERMISSION_CATEGORIES_LIST)
 	}
 
-	return c.catRepo.ListCategories(ctx, limit, offset)
+	return c.catRepo.ListCategories(ctx, limit, offset, includeDeleted)
 }
 
 func (c *categoriesUseCase) GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
@@ -32,22 +41,202 @@ func (c *categoriesUseCase) GetCategory(ctx context.Context, id uuid.UUID) (*dom
 	return out, err
 }
 
-func (c *categoriesUseCase) InsertCategory(ctx context.Context, userID uuid.UUID, in *domain.Category) (*domain.Category, error) {
-	if !isAdmin(c.log, c.userUC, ctx, userID) {
-		return nil, helpers.ErrUnauthorized
+func (c *categoriesUseCase) InsertCategory(ctx context.Context, in *domain.Category) (*domain.Category, error) {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_CREATE) {
+		return nil, denied(ctx, domain.PERMISSION_CATEGORIES_CREATE)
 	}
 	newCat := domain.NewCategory(uuid.New(), in.CreatedAt, in.Name)
 
 	out, err := c.catRepo.InsertCategory(ctx, newCat)
+	if err != nil {
+		return nil, err
+	}
+	c.audit.record(ctx, domain.AUDIT_CATEGORY_CREATE, domain.AUDIT_ENTITY_CATEGORY, out.ID, nil, out)
 
-	return out, err
+	return out, nil
 }
 
-func (c *categoriesUseCase) DeleteCategory(ctx context.Context, userID, id uuid.UUID) error {
-	if !isAdmin(c.log, c.userUC, ctx, userID) {
-		return helpers.ErrUnauthorized
+func (c *categoriesUseCase) UpdateCategory(ctx context.Context, in *domain.Category) (*domain.Category, error) {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_UPDATE) {
+		return nil, denied(ctx, domain.PERMISSION_CATEGORIES_UPDATE)
+	}
+
+	current, err := c.catRepo.GetCategoryByID(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		name = current.Name
+	}
+	if len([]rune(name)) > categoryNameMaxLength || in.Position < 0 {
+		c.log.Errorw(
+			"invalid category update",
+			zap.Any("in_category", in),
+			zap.Error(helpers.ErrInvalidInput),
+		)
+		return nil, helpers.ErrInvalidInput
+	}
+
+	if name != current.Name {
+		other, err := c.catRepo.GetCategoryByName(ctx, name)
+		switch {
+		case err == nil && other.ID != current.ID:
+			return nil, helpers.NewConflictError("category", fmt.Sprintf("name %q is already in use", name))
+		case err != nil && !errors.Is(err, helpers.ErrNotFound):
+			return nil, err
+		}
+	}
+
+	before := *current
+	current.Name = name
+	if in.Position != 0 {
+		current.Position = in.Position
+	}
+	current.UpdatedAt = time.Now()
+
+	out, err := c.catRepo.UpdateCategory(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	c.audit.record(ctx, domain.AUDIT_CATEGORY_UPDATE, domain.AUDIT_ENTITY_CATEGORY, out.ID, before, out)
+
+	return out, nil
+}
+
+// ReorderCategories sets the display order of the menu. The given categories come first, in
+// that order, followed by any category left out.
+func (c *categoriesUseCase) ReorderCategories(ctx context.Context, ids []uuid.UUID) error {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_REORDER) {
+		return denied(ctx, domain.PERMISSION_CATEGORIES_REORDER)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			c.log.Errorw(
+				"duplicated category on reorder",
+				zap.String("category_id", id.String()),
+				zap.Error(helpers.ErrInvalidInput),
+			)
+			return helpers.ErrInvalidInput
+		}
+		seen[id] = true
+
+		cat, err := c.catRepo.GetCategoryByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !cat.DeletedAt.IsZero() {
+			return helpers.NewConflictError("category", fmt.Sprintf("category %s is deleted", id))
+		}
+	}
+
+	if err := c.catRepo.ReorderCategories(ctx, ids); err != nil {
+		return err
+	}
+	c.audit.record(ctx, domain.AUDIT_CATEGORY_REORDER, domain.AUDIT_ENTITY_CATEGORY, uuid.Nil, nil, ids)
+
+	return nil
+}
+
+// DeleteCategory removes the category according to the policy chosen for its products:
+// refuse while it still has products, move them to targetID, or delete them too.
+func (c *categoriesUseCase) DeleteCategory(ctx context.Context, id uuid.UUID, policy domain.CategoryDeletePolicy, targetID uuid.UUID) error {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_DELETE) {
+		return denied(ctx, domain.PERMISSION_CATEGORIES_DELETE)
+	}
+
+	current, err := c.catRepo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// products deleted with the category share its deletion time, telling RestoreCategory which to bring back.
+	// Postgres keeps microseconds, so the time is truncated to be matched exactly.
+	deletedAt := time.Now().Truncate(time.Microsecond)
+
+	// the products and the category go together, a failed delete must not leave the products moved or deleted
+	err = c.uow.Do(ctx, func(ctx context.Context) error {
+		if err := c.applyDeletePolicy(ctx, id, policy, targetID, deletedAt); err != nil {
+			return err
+		}
+		return c.catRepo.DeleteCategory(ctx, id, deletedAt)
+	})
+	if err != nil {
+		return err
+	}
+	c.audit.record(ctx, domain.AUDIT_CATEGORY_DELETE, domain.AUDIT_ENTITY_CATEGORY, id, current, map[string]any{
+		"Policy":           policy,
+		"TargetCategoryID": targetID,
+	})
+
+	return nil
+}
+
+// applyDeletePolicy handles the products of category id before it is deleted.
+func (c *categoriesUseCase) applyDeletePolicy(ctx context.Context, id uuid.UUID, policy domain.CategoryDeletePolicy, targetID uuid.UUID, deletedAt time.Time) error {
+	switch policy {
+	case domain.CATEGORY_DELETE_REFUSE, domain.CATEGORY_DELETE_UNSET:
+		total, err := c.prodRepo.CountProductsByCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return helpers.NewConflictError("category", fmt.Sprintf("category still has %d products", total))
+		}
+	case domain.CATEGORY_DELETE_REASSIGN:
+		if targetID == id {
+			return helpers.NewConflictError("category", "products can not be reassigned to the category being deleted")
+		}
+		target, err := c.catRepo.GetCategoryByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if !target.DeletedAt.IsZero() {
+			return helpers.NewConflictError("category", "target category is deleted")
+		}
+		return c.prodRepo.ReassignProductsCategory(ctx, id, targetID)
+	case domain.CATEGORY_DELETE_CASCADE:
+		return c.prodRepo.DeleteProductsByCategory(ctx, id, deletedAt)
+	default:
+		c.log.Errorw(
+			"unknown category delete policy",
+			zap.String("policy", string(policy)),
+			zap.Error(helpers.ErrInvalidInput),
+		)
+		return helpers.ErrInvalidInput
+	}
+
+	return nil
+}
+
+// RestoreCategory brings the category back along with the products deleted with it by the cascade policy.
+func (c *categoriesUseCase) RestoreCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	if !isAllowed(c.log, c.userUC, ctx, domain.PERMISSION_CATEGORIES_RESTORE) {
+		return nil, denied(ctx, domain.PERMISSION_CATEGORIES_RESTORE)
+	}
+
+	err := c.uow.Do(ctx, func(ctx context.Context) error {
+		deleted, err := c.catRepo.GetCategoryByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = c.catRepo.RestoreCategory(ctx, id); err != nil {
+			return err
+		}
+		return c.prodRepo.RestoreProductsByCategory(ctx, id, deleted.DeletedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.catRepo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	c.audit.record(ctx, domain.AUDIT_CATEGORY_RESTORE, domain.AUDIT_ENTITY_CATEGORY, id, nil, out)
 
-	err := c.catRepo.DeleteCategory(ctx, id)
-	return err
+	return out, nil
 }
@@ -2,35 +2,48 @@ package usecases
 
 import (
 	"context"
-	"github.com/shopspring/decimal"
+	"fmt"
 	"time"
 
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 type ordersUseCase struct {
 	logger     *zap.SugaredLogger
 	ordersRepo ports.OrdersRepository
+	uow        ports.UnitOfWork
 	userUC     ports.UsersUseCase
 	prodUC     ports.ProductsUseCase
 	paymentsUC ports.PaymentUseCase
+	audit      auditor
 }
 
 func NewOrdersUseCase(
 	logger *zap.SugaredLogger,
 	ordersRepo ports.OrdersRepository,
+	auditRepo ports.AuditRepository,
+	uow ports.UnitOfWork,
 	userUC ports.UsersUseCase,
 	prodUC ports.ProductsUseCase,
 	paymentsUC ports.PaymentUseCase,
+	bus ports.EventBus,
 ) ports.OrdersUseCase {
-	orderUC := &ordersUseCase{logger: logger, ordersRepo: ordersRepo, userUC: userUC, prodUC: prodUC, paymentsUC: paymentsUC}
+	orderUC := &ordersUseCase{
+		logger:     logger,
+		ordersRepo: ordersRepo,
+		uow:        uow,
+		userUC:     userUC,
+		prodUC:     prodUC,
+		paymentsUC: paymentsUC,
+		audit:      newAuditor(logger, auditRepo),
+	}
 
-	domain.PaymentStatusChannel = make(chan domain.PaymentStatusNotification)
-	go orderUC.SubscribeToPaymentStatusUpdates()
+	bus.Subscribe(domain.EVENT_PAYMENT_STATUS_CHANGED, "orders.payment_status", orderUC.onPaymentStatusChanged)
 
 	return orderUC
 }
@@ -39,72 +52,134 @@ func (o *ordersUseCase) GetOrderByPaymentID(ctx context.Context, paymentID uuid.
 	return o.ordersRepo.GetOrderByPaymentID(ctx, paymentID)
 }
 
-func (o *ordersUseCase) UpdateOrderStatus(ctx context.Context, userID, orderID uuid.UUID, status domain.OrderStatus) (*domain.Order, error) {
-	order, err := o.GetOrder(ctx, userID, orderID)
-	if err != nil {
-		return nil, err
+func (o *ordersUseCase) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, version int, status domain.OrderStatus) (*domain.Order, error) {
+	if !isAllowed(o.logger, o.userUC, ctx, domain.PERMISSION_ORDERS_UPDATE_STATUS) {
+		return nil, denied(ctx, domain.PERMISSION_ORDERS_UPDATE_STATUS)
 	}
 
-	order.Status = status
-	order.UpdatedAt = time.Now()
+	var before domain.Order
+	var out *domain.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := o.ordersRepo.GetOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion("order", orderID, order.Version, version); err != nil {
+			return err
+		}
 
-	return o.ordersRepo.UpdateOrder(ctx, order)
-}
+		before = *order
+		order.Status = status
+		order.UpdatedAt = time.Now()
 
-func (o *ordersUseCase) Checkout(ctx context.Context, userID, orderID uuid.UUID) (*domain.Order, error) {
-	var order *domain.Order
+		event, err := orderStatusChangedEvent(ctx, order)
+		if err != nil {
+			return err
+		}
 
-	order, err := o.GetOrder(ctx, userID, orderID)
+		out, err = o.ordersRepo.UpdateOrder(ctx, order, event)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	order.Status = domain.ORDER_STATUS_WAITING_PAYMENT
+	o.audit.record(ctx, domain.AUDIT_ORDER_UPDATE_STATUS, domain.AUDIT_ENTITY_ORDER, orderID, before, out)
 
-	payment, err := o.paymentsUC.CreatePayment(ctx, order)
-	if err != nil {
-		return nil, err
-	}
-	order.PaymentID = payment.ID
+	return out, nil
+}
+
+// Checkout opens the payment of the order and waits for it, both or neither are saved.
+func (o *ordersUseCase) Checkout(ctx context.Context, orderID uuid.UUID, version int) (*domain.Order, error) {
+	var out *domain.Order
+
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := o.getOrder(ctx, orderID, domain.PERMISSION_ORDERS_MODIFY_ALL)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion("order", orderID, order.Version, version); err != nil {
+			return err
+		}
 
-	defer func() {
-		// non blocking step
+		payment, err := o.paymentsUC.CreatePayment(ctx, order)
+		if err != nil {
+			return err
+		}
+		order.PaymentID = payment.ID
+		order.Status = domain.ORDER_STATUS_WAITING_PAYMENT
 		order.UpdatedAt = time.Now()
-		order, err = o.ordersRepo.UpdateOrder(ctx, order)
+
+		event, err := orderStatusChangedEvent(ctx, order)
 		if err != nil {
-			o.logger.Errorw(
-				"failed updating order status after checkout",
-				zap.Error(err),
-			)
+			return err
 		}
-	}()
 
-	return order, err
+		out, err = o.ordersRepo.UpdateOrder(ctx, order, event)
+		return err
+	})
+	if err != nil {
+		o.logger.Errorw(
+			"failed checking out order",
+			zap.String("order_id", orderID.String()),
+			zap.Error(err),
+		)
+		return nil, err
+	}
 
+	return out, nil
 }
 
-func (o *ordersUseCase) ListOrders(ctx context.Context, limit, offset int, userID uuid.UUID) (*domain.OrderList, error) {
-	if isAdmin(o.logger, o.userUC, ctx, userID) {
-		return o.ordersRepo.ListOrders(ctx, limit, offset)
+func (o *ordersUseCase) ListOrders(ctx context.Context, page domain.Page) (*domain.OrderList, error) {
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok {
+		return nil, helpers.ErrUnauthorized
+	}
+	if caller.IsGuest() {
+		return o.ordersRepo.ListOrdersByGuestSession(ctx, page, caller.GuestSessionID)
 	}
-	return o.ordersRepo.ListOrdersByUser(ctx, limit, offset, userID)
+
+	if isAllowed(o.logger, o.userUC, ctx, domain.PERMISSION_ORDERS_READ_ALL) {
+		return o.ordersRepo.ListOrders(ctx, page)
+	}
+	if caller.IsAPIKey() {
+		return nil, helpers.ErrUnauthorized
+	}
+	return o.ordersRepo.ListOrdersByUser(ctx, page, caller.UserID)
+}
+
+func (o *ordersUseCase) GetOrder(ctx context.Context, orderID uuid.UUID) (*domain.Order, error) {
+	return o.getOrder(ctx, orderID, domain.PERMISSION_ORDERS_READ_ALL)
 }
 
-func (o *ordersUseCase) GetOrder(ctx context.Context, userID, orderID uuid.UUID) (*domain.Order, error) {
+// getOrder returns the order when it belongs to the caller, user or guest, or the caller has the
+// permission to act on everyone's orders.
+func (o *ordersUseCase) getOrder(ctx context.Context, orderID uuid.UUID, permission domain.Permission) (*domain.Order, error) {
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok {
+		return nil, helpers.ErrUnauthorized
+	}
+
 	order, err := o.ordersRepo.GetOrder(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
 
-	if order.UserID != userID && !isAdmin(o.logger, o.userUC, ctx, order.UserID) {
-		return nil, helpers.ErrUnauthorized
+	if !order.IsOwnedBy(caller) && !isAllowed(o.logger, o.userUC, ctx, permission) {
+		return nil, denied(ctx, permission)
 	}
 
 	return order, nil
 }
 
-func (o *ordersUseCase) CreateOrder(ctx context.Context, userID uuid.UUID, products []domain.Product) (*domain.Order, error) {
+func (o *ordersUseCase) CreateOrder(ctx context.Context, products []domain.Product) (*domain.Order, error) {
 	var order *domain.Order
 
+	// orders belong to people, kiosks order through guest sessions
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok || caller.IsAPIKey() {
+		return nil, helpers.ErrUnauthorized
+	}
+
 	if len(products) == 0 {
 		o.logger.Errorw(
 			"error at CreateOrder, must have at least one product in it",
@@ -114,35 +189,60 @@ func (o *ordersUseCase) CreateOrder(ctx context.Context, userID uuid.UUID, produ
 		return nil, helpers.ErrInvalidInput
 	}
 
-	for k, p := range products {
-		fullProduct, err := o.prodUC.GetProduct(ctx, p.ID)
-		if err != nil {
-			o.logger.Errorw("CreateOrder failed due to invalid product",
-				zap.String("product_id", p.ID.String()),
-				zap.Any("requested_products", products),
-				zap.Error(err),
-			)
-			return nil, err
+	var out *domain.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		for k, p := range products {
+			fullProduct, err := o.prodUC.GetProduct(ctx, p.ID)
+			if err != nil {
+				o.logger.Errorw("CreateOrder failed due to invalid product",
+					zap.String("product_id", p.ID.String()),
+					zap.Any("requested_products", products),
+					zap.Error(err),
+				)
+				return err
+			}
+			if !fullProduct.DeletedAt.IsZero() {
+				o.logger.Errorw("CreateOrder failed due to deleted product",
+					zap.String("product_id", p.ID.String()),
+					zap.Error(helpers.ErrInvalidInput),
+				)
+				return helpers.ErrInvalidInput
+			}
+			products[k] = *fullProduct
 		}
-		products[k] = *fullProduct
-	}
 
-	order = domain.NewOrder(uuid.New(), userID, time.Now(), products)
+		if caller.IsGuest() {
+			order = domain.NewGuestOrder(uuid.New(), caller.GuestSessionID, time.Now(), products)
+		} else {
+			order = domain.NewOrder(uuid.New(), caller.UserID, time.Now(), products)
+		}
 
-	for _, v := range products {
-		order.Price = order.Price.Add(v.Price)
-	}
+		for _, v := range products {
+			order.Price = order.Price.Add(v.Price)
+		}
 
-	return o.ordersRepo.CreateOrder(ctx, order)
-}
+		event, err := domain.NewOutboxEvent(uuid.New(), domain.OrderCreated{
+			OrderID:        order.ID,
+			UserID:         order.UserID,
+			GuestSessionID: order.GuestSessionID,
+			Price:          order.Price,
+			OccurredAt:     order.CreatedAt,
+		}, domain.RequestIDFromContext(ctx), order.CreatedAt)
+		if err != nil {
+			return err
+		}
 
-func (o *ordersUseCase) InsertProductsIntoOrder(ctx context.Context, userID, orderID uuid.UUID, inProducts []domain.Product) (*domain.Order, error) {
-	order, err := o.GetOrder(ctx, userID, orderID)
-	// Check ownership
+		out, err = o.ordersRepo.CreateOrder(ctx, order, event)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return out, nil
+}
+
+func (o *ordersUseCase) InsertProductsIntoOrder(ctx context.Context, orderID uuid.UUID, version int, inProducts []domain.Product) (*domain.Order, error) {
 	if len(inProducts) == 0 {
 		o.logger.Errorw(
 			"error at InsertProductsIntoOrder, must have at least one product in it",
@@ -152,20 +252,33 @@ func (o *ordersUseCase) InsertProductsIntoOrder(ctx context.Context, userID, ord
 		return nil, helpers.ErrInvalidInput
 	}
 
-	for _, v := range inProducts {
-		order.Products = append(order.Products, v)
-		order.Price = order.Price.Add(v.Price)
-	}
+	var out *domain.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		// Check ownership
+		order, err := o.getOrder(ctx, orderID, domain.PERMISSION_ORDERS_MODIFY_ALL)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion("order", orderID, order.Version, version); err != nil {
+			return err
+		}
 
-	return o.ordersRepo.UpdateOrder(ctx, order)
-}
+		for _, v := range inProducts {
+			order.Products = append(order.Products, v)
+			order.Price = order.Price.Add(v.Price)
+		}
 
-func (o *ordersUseCase) RemoveProductFromOrder(ctx context.Context, userID, orderID uuid.UUID, outProducts []domain.Product) (*domain.Order, error) {
-	order, err := o.GetOrder(ctx, userID, orderID)
+		out, err = o.ordersRepo.UpdateOrder(ctx, order)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return out, nil
+}
+
+func (o *ordersUseCase) RemoveProductFromOrder(ctx context.Context, orderID uuid.UUID, version int, outProducts []domain.Product) (*domain.Order, error) {
 	if len(outProducts) == 0 {
 		o.logger.Errorw(
 			"error at RemoveProductFromOrder, must have at least one product in it",
@@ -175,52 +288,113 @@ func (o *ordersUseCase) RemoveProductFromOrder(ctx context.Context, userID, orde
 		return nil, helpers.ErrInvalidInput
 	}
 
-	var removeSet map[uuid.UUID]bool
+	removeSet := make(map[uuid.UUID]bool, len(outProducts))
 	for _, p := range outProducts {
 		removeSet[p.ID] = true
 	}
 
-	var newProdsList []domain.Product
-	order.Price = decimal.NewFromInt(0)
-	for _, p := range order.Products {
-		if _, ok := removeSet[p.ID]; !ok {
-			newProdsList = append(newProdsList, p)
-			order.Price = order.Price.Add(p.Price)
+	var out *domain.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := o.getOrder(ctx, orderID, domain.PERMISSION_ORDERS_MODIFY_ALL)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion("order", orderID, order.Version, version); err != nil {
+			return err
+		}
+
+		var newProdsList []domain.Product
+		order.Price = decimal.NewFromInt(0)
+		for _, p := range order.Products {
+			if _, ok := removeSet[p.ID]; !ok {
+				newProdsList = append(newProdsList, p)
+				order.Price = order.Price.Add(p.Price)
+			}
 		}
-	}
 
-	order.Products = newProdsList
+		order.Products = newProdsList
+
+		out, err = o.ordersRepo.UpdateOrder(ctx, order)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return o.ordersRepo.UpdateOrder(ctx, order)
+	return out, nil
 }
 
-func (o *ordersUseCase) DeleteOrder(ctx context.Context, userID, orderID uuid.UUID) error {
-	// Check ownership
-	_, err := o.GetOrder(ctx, userID, orderID)
+// DeleteOrder removes the order. Staff removing someone else's order is recorded in the audit log.
+func (o *ordersUseCase) DeleteOrder(ctx context.Context, orderID uuid.UUID, version int) error {
+	var order *domain.Order
+	err := o.uow.Do(ctx, func(ctx context.Context) error {
+		// Check ownership
+		var err error
+		if order, err = o.getOrder(ctx, orderID, domain.PERMISSION_ORDERS_MODIFY_ALL); err != nil {
+			return err
+		}
+		if err = checkVersion("order", orderID, order.Version, version); err != nil {
+			return err
+		}
+		return o.ordersRepo.DeleteOrder(ctx, orderID, order.Version)
+	})
 	if err != nil {
 		return err
 	}
+	if caller, _ := domain.CallerFromContext(ctx); !order.IsOwnedBy(caller) {
+		o.audit.record(ctx, domain.AUDIT_ORDER_DELETE, domain.AUDIT_ENTITY_ORDER, orderID, order, nil)
+	}
 
-	return o.ordersRepo.DeleteOrder(ctx, orderID)
+	return nil
 }
 
-func (o *ordersUseCase) SubscribeToPaymentStatusUpdates() {
-	for notification := range domain.PaymentStatusChannel {
-		order, err := o.GetOrderByPaymentID(context.Background(), notification.PaymentID)
+// onPaymentStatusChanged moves the order along with its payment. Events delivered again find the order
+// already moved and change nothing, so no other OrderStatusChanged is raised.
+func (o *ordersUseCase) onPaymentStatusChanged(ctx context.Context, event domain.Event) error {
+	changed, ok := event.(domain.PaymentStatusChanged)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", event)
+	}
+
+	return o.uow.Do(ctx, func(ctx context.Context) error {
+		order, err := o.GetOrderByPaymentID(ctx, changed.PaymentID)
 		if err != nil {
-			return
+			return err
 		}
 
-		status := domain.OrderStatusFromNotification(notification.Status)
+		status := domain.OrderStatusFromNotification(changed.Status)
+		if order.Status == status {
+			// already moved, by an earlier delivery of the same event
+			return nil
+		}
 		order.Status = status
+		order.UpdatedAt = time.Now()
 
-		_, err = o.ordersRepo.UpdateOrder(context.Background(), order)
+		event, err := orderStatusChangedEvent(ctx, order)
 		if err != nil {
-			o.logger.Errorw(
-				"failed updating order",
-				zap.Error(err),
-			)
+			return err
 		}
-		return
+
+		_, err = o.ordersRepo.UpdateOrder(ctx, order, event)
+		return err
+	})
+}
+
+// checkVersion refuses a change made on another version than the saved one, version 0 skips the check.
+// The repositories check the version again when saving, for changes made in between.
+func checkVersion(resource string, ID uuid.UUID, saved, version int) error {
+	if version != 0 && version != saved {
+		return helpers.NewVersionConflictError(resource, ID, version)
 	}
+	return nil
+}
+
+// orderStatusChangedEvent is saved with the order whenever its status changes, for the outbox to publish.
+func orderStatusChangedEvent(ctx context.Context, order *domain.Order) (*domain.OutboxEvent, error) {
+	return domain.NewOutboxEvent(uuid.New(), domain.OrderStatusChanged{
+		OrderID:    order.ID,
+		PaymentID:  order.PaymentID,
+		Status:     order.Status,
+		OccurredAt: order.UpdatedAt,
+	}, domain.RequestIDFromContext(ctx), order.UpdatedAt)
 }
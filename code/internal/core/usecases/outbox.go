@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxBatchSize is how many pending events are relayed at a time.
+	outboxBatchSize = 100
+	// eventSource names this service in the envelopes published to the broker.
+	eventSource = "lanchonete"
+)
+
+type outboxRelay struct {
+	logger *zap.SugaredLogger
+	repo   ports.OutboxRepository
+	bus    ports.EventBus
+	broker ports.MessageBroker
+}
+
+func NewOutboxRelay(logger *zap.SugaredLogger, repo ports.OutboxRepository, bus ports.EventBus, broker ports.MessageBroker) ports.OutboxRelay {
+	return &outboxRelay{logger: logger, repo: repo, bus: bus, broker: broker}
+}
+
+// RelayOutboxEvents dispatches the due events, oldest first, then publishes them to the broker, and marks
+// them delivered once both succeeded. Dispatching and publishing are recorded apart, so an event failing
+// to publish is not dispatched again on retry. Failed ones are retried later with a growing delay, so
+// subscribers and consumers may still see an event more than once and must be idempotent.
+func (r *outboxRelay) RelayOutboxEvents(ctx context.Context) error {
+	pending, err := r.repo.ListPendingOutboxEvents(ctx, time.Now(), outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.relay(ctx, p)
+	}
+
+	return nil
+}
+
+func (r *outboxRelay) relay(ctx context.Context, pending *domain.OutboxEvent) {
+	var err error
+	if pending.DispatchedAt.IsZero() {
+		err = r.dispatch(ctx, pending)
+	}
+	if err == nil {
+		err = r.broker.Publish(ctx, domain.NewEventEnvelope(pending, eventSource))
+	}
+
+	if err != nil {
+		pending.Failed(err, time.Now())
+		r.logger.Errorw(
+			"failed relaying outbox event",
+			zap.String("id", pending.ID.String()),
+			zap.String("event_type", string(pending.EventType)),
+			zap.Int("attempts", pending.Attempts),
+			zap.Time("next_attempt_at", pending.NextAttemptAt),
+			zap.Error(err),
+		)
+		_ = r.repo.MarkOutboxEventFailed(ctx, pending)
+		return
+	}
+
+	_ = r.repo.MarkOutboxEventDelivered(ctx, pending.ID, time.Now())
+}
+
+// dispatch runs the subscribers of this service and records it.
+func (r *outboxRelay) dispatch(ctx context.Context, pending *domain.OutboxEvent) error {
+	event, err := domain.DecodeEvent(pending.EventType, pending.Payload)
+	if err != nil {
+		return err
+	}
+	if err = r.bus.Dispatch(domain.ContextWithRequestID(ctx, pending.RequestID), event); err != nil {
+		return err
+	}
+
+	pending.DispatchedAt = time.Now()
+	return r.repo.MarkOutboxEventDispatched(ctx, pending.ID, pending.DispatchedAt)
+}
@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeOutboxRepo hands out its events as pending until delivered, keeping what the relay marked.
+type fakeOutboxRepo struct {
+	events []*domain.OutboxEvent
+}
+
+func (f *fakeOutboxRepo) ListPendingOutboxEvents(context.Context, time.Time, int) ([]*domain.OutboxEvent, error) {
+	var pending []*domain.OutboxEvent
+	for _, e := range f.events {
+		if e.DeliveredAt.IsZero() {
+			copied := *e
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeOutboxRepo) find(id uuid.UUID) *domain.OutboxEvent {
+	for _, e := range f.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkOutboxEventDispatched(_ context.Context, id uuid.UUID, at time.Time) error {
+	f.find(id).DispatchedAt = at
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkOutboxEventDelivered(_ context.Context, id uuid.UUID, at time.Time) error {
+	f.find(id).DeliveredAt = at
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkOutboxEventFailed(_ context.Context, event *domain.OutboxEvent) error {
+	f.find(event.ID).Attempts = event.Attempts
+	return nil
+}
+
+type fakeEventBus struct {
+	ports.EventBus
+	dispatched int
+	err        error
+}
+
+func (f *fakeEventBus) Dispatch(context.Context, domain.Event) error {
+	f.dispatched++
+	return f.err
+}
+
+type fakeBroker struct {
+	ports.MessageBroker
+	published int
+	errs      []error
+}
+
+func (f *fakeBroker) Publish(context.Context, domain.EventEnvelope) error {
+	f.published++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func TestRelayOutboxEvents(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name           string
+		busErr         error
+		brokerErrs     []error
+		wantDispatched int
+		wantPublished  int
+		wantDelivered  bool
+	}{
+		{name: "001_should_dispatch_and_publish", wantDispatched: 1, wantPublished: 1, wantDelivered: true},
+		{name: "002_should_not_dispatch_again_when_publish_is_retried", brokerErrs: []error{failure, failure}, wantDispatched: 1, wantPublished: 3, wantDelivered: true},
+		{name: "003_should_not_publish_before_dispatch", busErr: failure, wantDispatched: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := domain.NewOutboxEvent(uuid.New(), domain.PaymentStatusChanged{PaymentID: uuid.New(), OrderID: uuid.New()}, "", time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo, bus, broker := &fakeOutboxRepo{events: []*domain.OutboxEvent{event}}, &fakeEventBus{err: tt.busErr}, &fakeBroker{errs: tt.brokerErrs}
+			relay := NewOutboxRelay(zap.NewNop().Sugar(), repo, bus, broker)
+
+			for i := 0; i < 3; i++ {
+				if err = relay.RelayOutboxEvents(context.Background()); err != nil {
+					t.Fatalf("RelayOutboxEvents() error = %v", err)
+				}
+			}
+
+			if bus.dispatched != tt.wantDispatched || broker.published != tt.wantPublished || !event.DeliveredAt.IsZero() != tt.wantDelivered {
+				t.Errorf("dispatched = %d, published = %d, delivered = %v, want %d, %d, %v",
+					bus.dispatched, broker.published, !event.DeliveredAt.IsZero(), tt.wantDispatched, tt.wantPublished, tt.wantDelivered)
+			}
+		})
+	}
+}
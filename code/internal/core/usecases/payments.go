@@ -13,10 +13,13 @@ import (
 type paymentsUseCase struct {
 	logger      *zap.SugaredLogger
 	paymentRepo ports.PaymentRepository
+	uow         ports.UnitOfWork
+	userUC      ports.UsersUseCase
+	audit       auditor
 }
 
-func NewPaymentsUseCase(logger *zap.SugaredLogger, repo ports.PaymentRepository) ports.PaymentUseCase {
-	return &paymentsUseCase{logger: logger, paymentRepo: repo}
+func NewPaymentsUseCase(logger *zap.SugaredLogger, repo ports.PaymentRepository, auditRepo ports.AuditRepository, uow ports.UnitOfWork, userUC ports.UsersUseCase) ports.PaymentUseCase {
+	return &paymentsUseCase{logger: logger, paymentRepo: repo, uow: uow, userUC: userUC, audit: newAuditor(logger, auditRepo)}
 }
 
 func (p *paymentsUseCase) GetPayment(ctx context.Context, paymentID uuid.UUID) (*domain.Payment, error) {
@@ -35,35 +38,43 @@ func (p *paymentsUseCase) CreatePayment(ctx context.Context, order *domain.Order
 	return receipt, nil
 }
 
+// UpdatePayment records the gateway notification. The new status is saved to the outbox along with the
+// payment, and relayed from there for the order to follow it. Only the gateway API key, or the staff at
+// the counter, may notify payments.
 func (p *paymentsUseCase) UpdatePayment(ctx context.Context, paymentID uuid.UUID, status domain.PaymentStatus) (*domain.Payment, error) {
-	payment, err := p.GetPayment(ctx, paymentID)
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PAYMENTS_NOTIFY) {
+		return nil, denied(ctx, domain.PERMISSION_PAYMENTS_NOTIFY)
+	}
+
+	var before domain.Payment
+	var updated *domain.Payment
+	err := p.uow.Do(ctx, func(ctx context.Context) error {
+		payment, err := p.GetPayment(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		before = *payment
+		payment.UpdatedAt = time.Now()
+		payment.Status = status
+
+		event, err := domain.NewOutboxEvent(uuid.New(), domain.PaymentStatusChanged{
+			PaymentID:  payment.ID,
+			OrderID:    payment.OrderID,
+			Status:     payment.Status,
+			OccurredAt: payment.UpdatedAt,
+		}, domain.RequestIDFromContext(ctx), payment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		updated, err = p.paymentRepo.UpdatePayment(ctx, payment, event)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	p.audit.record(ctx, domain.AUDIT_PAYMENT_UPDATE_STATUS, domain.AUDIT_ENTITY_PAYMENT, paymentID, before, updated)
 
-	payment.UpdatedAt = time.Now()
-	payment.Status = status
-
-	updated, err := p.paymentRepo.UpdatePayment(ctx, payment)
-
-	defer func() {
-		p.PublishPaymentStatus(domain.PaymentStatusNotification{
-			PaymentID: payment.ID,
-			Status:    status,
-			OrderID:   payment.OrderID,
-		})
-	}()
-
-	return updated, err
-}
-
-func (p *paymentsUseCase) PublishPaymentStatus(notification domain.PaymentStatusNotification) chan bool {
-	done := make(chan bool)
-
-	go func() {
-		domain.PaymentStatusChannel <- notification
-		done <- true
-	}()
-
-	return done
+	return updated, nil
 }
@@ -0,0 +1,185 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// exportPageSize is how many orders are read at a time while exporting a user's data.
+const exportPageSize = 100
+
+type privacyUseCase struct {
+	logger       *zap.SugaredLogger
+	userRepo     ports.UsersRepository
+	orderRepo    ports.OrdersRepository
+	paymentRepo  ports.PaymentRepository
+	requestsRepo ports.DataSubjectRequestsRepository
+	userUseCase  ports.UsersUseCase
+	audit        auditor
+}
+
+func NewPrivacyUseCase(
+	logger *zap.SugaredLogger,
+	userRepo ports.UsersRepository,
+	orderRepo ports.OrdersRepository,
+	paymentRepo ports.PaymentRepository,
+	requestsRepo ports.DataSubjectRequestsRepository,
+	auditRepo ports.AuditRepository,
+	userUseCase ports.UsersUseCase,
+) ports.PrivacyUseCase {
+	return &privacyUseCase{
+		logger:       logger,
+		userRepo:     userRepo,
+		orderRepo:    orderRepo,
+		paymentRepo:  paymentRepo,
+		requestsRepo: requestsRepo,
+		userUseCase:  userUseCase,
+		audit:        newAuditor(logger, auditRepo),
+	}
+}
+
+// ExportUserData gathers the profile, orders and payments of the user. Users may export their own data,
+// only admins the others'.
+func (p *privacyUseCase) ExportUserData(ctx context.Context, userID uuid.UUID) (*domain.UserDataExport, error) {
+	request, err := p.startRequest(ctx, userID, domain.DATA_SUBJECT_REQUEST_EXPORT)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := p.exportUserData(ctx, userID)
+	p.finishRequest(ctx, request, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// the export is itself recorded, so read the log only after finishing it
+	if export.Requests, err = p.requestsRepo.ListDataSubjectRequests(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (p *privacyUseCase) exportUserData(ctx context.Context, userID uuid.UUID) (*domain.UserDataExport, error) {
+	user, err := p.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+
+	roles, err := p.userRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	consents, err := p.userRepo.ListUserConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &domain.UserDataExport{GeneratedAt: time.Now(), User: user, Roles: roles, Consents: consents}
+	page := domain.Page{Limit: exportPageSize, Keyset: true}
+	for {
+		list, err := p.orderRepo.ListOrdersByUser(ctx, page, userID)
+		if err != nil {
+			return nil, err
+		}
+		export.Orders = append(export.Orders, list.Orders...)
+		if list.Next == nil {
+			break
+		}
+		page.After = list.Next
+	}
+
+	for _, order := range export.Orders {
+		if order.PaymentID == uuid.Nil {
+			continue
+		}
+		payment, err := p.paymentRepo.GetPayment(ctx, order.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		export.Payments = append(export.Payments, payment)
+	}
+
+	return export, nil
+}
+
+// EraseUserData anonymizes the personal data of the user and detaches their orders. Orders and payments are
+// kept, without an owner, for the financial records.
+func (p *privacyUseCase) EraseUserData(ctx context.Context, userID uuid.UUID) (*domain.DataSubjectRequest, error) {
+	request, err := p.startRequest(ctx, userID, domain.DATA_SUBJECT_REQUEST_ERASURE)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.userRepo.AnonymizeUser(ctx, userID)
+	p.finishRequest(ctx, request, err)
+	if err != nil {
+		return nil, err
+	}
+	p.audit.record(ctx, domain.AUDIT_USER_ERASE, domain.AUDIT_ENTITY_USER, userID, nil, request)
+
+	return request, nil
+}
+
+func (p *privacyUseCase) ListDataSubjectRequests(ctx context.Context, userID uuid.UUID) ([]*domain.DataSubjectRequest, error) {
+	if _, err := p.authorize(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return p.requestsRepo.ListDataSubjectRequests(ctx, userID)
+}
+
+func (p *privacyUseCase) authorize(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if uID != userID && !isAllowed(p.logger, p.userUseCase, ctx, domain.PERMISSION_USERS_PRIVACY) {
+		return uuid.Nil, denied(ctx, domain.PERMISSION_USERS_PRIVACY)
+	}
+	return uID, nil
+}
+
+// startRequest records the request before it is carried out, so even failed attempts are audited.
+func (p *privacyUseCase) startRequest(ctx context.Context, userID uuid.UUID, requestType domain.DataSubjectRequestType) (*domain.DataSubjectRequest, error) {
+	uID, err := p.authorize(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.userRepo.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	request := domain.NewDataSubjectRequest(uuid.New(), userID, uID, requestType, time.Now())
+	if err = p.requestsRepo.InsertDataSubjectRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (p *privacyUseCase) finishRequest(ctx context.Context, request *domain.DataSubjectRequest, err error) {
+	request.CompletedAt = time.Now()
+	request.Status = domain.DATA_SUBJECT_REQUEST_COMPLETED
+	if err != nil {
+		request.Status = domain.DATA_SUBJECT_REQUEST_FAILED
+		request.Error = err.Error()
+	}
+
+	if err = p.requestsRepo.UpdateDataSubjectRequest(ctx, request); err != nil {
+		p.logger.Errorw(
+			"failed finishing data subject request",
+			zap.String("id", request.ID.String()),
+			zap.String("status", string(request.Status)),
+			zap.Error(err),
+		)
+	}
+}
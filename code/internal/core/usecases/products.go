@@ -2,25 +2,34 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
+	"time"
 )
 
 type productsUseCase struct {
 	logger      *zap.SugaredLogger
 	productRepo ports.ProductsRepository
+	priceRepo   ports.ProductPricesRepository
+	uow         ports.UnitOfWork
 	userUC      ports.UsersUseCase
+	audit       auditor
 }
 
-func NewProductsUseCase(repository ports.ProductsRepository, userUseCase ports.UsersUseCase, logger *zap.SugaredLogger) ports.ProductsUseCase {
+func NewProductsUseCase(repository ports.ProductsRepository, priceRepository ports.ProductPricesRepository, auditRepository ports.AuditRepository, uow ports.UnitOfWork, userUseCase ports.UsersUseCase, logger *zap.SugaredLogger) ports.ProductsUseCase {
 	return &productsUseCase{
 		logger:      logger,
 		productRepo: repository,
+		priceRepo:   priceRepository,
+		uow:         uow,
 		userUC:      userUseCase,
+		audit:       newAuditor(logger, auditRepository),
 	}
 }
 
@@ -38,39 +47,268 @@ func (p productsUseCase) GetProduct(ctx context.Context, id uuid.UUID) (*domain.
 	return product, err
 }
 
-func (p productsUseCase) InsertProduct(ctx context.Context, userID uuid.UUID, in *domain.Product) (*domain.Product, error) {
-	if !isAdmin(p.logger, p.userUC, ctx, userID) {
-		return nil, helpers.ErrUnauthorized
+func (p productsUseCase) InsertProduct(ctx context.Context, in *domain.Product) (*domain.Product, error) {
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_CREATE) {
+		return nil, denied(ctx, domain.PERMISSION_PRODUCTS_CREATE)
 	}
 
 	if in.Price == decimal.Zero {
 		return nil, helpers.ErrBadRequest
 	}
-	out, err := p.productRepo.InsertProduct(ctx, in)
-	return out, err
+	if err := p.validateProductDetails(in); err != nil {
+		return nil, err
+	}
+
+	// the product and the first entry of its price history are saved together
+	var out *domain.Product
+	err := p.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if out, err = p.productRepo.InsertProduct(ctx, in); err != nil {
+			return err
+		}
+		return p.recordPriceChange(ctx, out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.audit.record(ctx, domain.AUDIT_PRODUCT_CREATE, domain.AUDIT_ENTITY_PRODUCT, out.ID, nil, out)
+
+	return out, nil
 }
 
-func (p productsUseCase) UpdateProduct(ctx context.Context, userID uuid.UUID, in *domain.Product) (*domain.Product, error) {
-	if !isAdmin(p.logger, p.userUC, ctx, userID) {
-		return nil, helpers.ErrUnauthorized
+func (p productsUseCase) UpdateProduct(ctx context.Context, in *domain.Product) (*domain.Product, error) {
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_UPDATE) {
+		return nil, denied(ctx, domain.PERMISSION_PRODUCTS_UPDATE)
 	}
 	if in.Price == decimal.Zero {
 		return nil, helpers.ErrBadRequest
 	}
-	out, err := p.productRepo.UpdateProduct(ctx, in)
-	return out, err
+	if err := p.validateProductDetails(in); err != nil {
+		return nil, err
+	}
+
+	var current, out *domain.Product
+	err := p.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if current, err = p.productRepo.GetProduct(ctx, in.ID); err != nil {
+			return err
+		}
+		if err = checkVersion("product", in.ID, current.Version, in.Version); err != nil {
+			return err
+		}
+		in.Version = current.Version
+
+		if out, err = p.productRepo.UpdateProduct(ctx, in); err != nil {
+			return err
+		}
+		if current.Price.Equal(out.Price) {
+			return nil
+		}
+		return p.recordPriceChange(ctx, out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.audit.record(ctx, domain.AUDIT_PRODUCT_UPDATE, domain.AUDIT_ENTITY_PRODUCT, out.ID, current, out)
+
+	return out, nil
+}
+
+// validateProductDetails checks the allergens against the known ones, dropping repetitions,
+// and that nutrition facts, when present, have no negative amounts.
+func (p productsUseCase) validateProductDetails(in *domain.Product) error {
+	seen := make(map[domain.Allergen]bool, len(in.Allergens))
+	allergens := make([]domain.Allergen, 0, len(in.Allergens))
+	for _, a := range in.Allergens {
+		if !a.IsValid() {
+			p.logger.Errorw(
+				"invalid product allergen",
+				zap.String("allergen", string(a)),
+				zap.Error(helpers.ErrInvalidInput),
+			)
+			return fmt.Errorf("%w: unknown allergen %q", helpers.ErrInvalidInput, a)
+		}
+		if !seen[a] {
+			seen[a] = true
+			allergens = append(allergens, a)
+		}
+	}
+	in.Allergens = allergens
+
+	if n := in.Nutrition; n != nil {
+		if !n.ServingSizeGrams.IsPositive() {
+			return fmt.Errorf("%w: nutrition serving size must be greater than zero", helpers.ErrInvalidInput)
+		}
+		for _, v := range []decimal.Decimal{
+			n.Calories, n.CarbohydratesGrams, n.SugarsGrams, n.ProteinsGrams,
+			n.FatsGrams, n.SaturatedFatsGrams, n.FibersGrams, n.SodiumMilligrams,
+		} {
+			if v.IsNegative() {
+				return fmt.Errorf("%w: nutrition facts can not be negative", helpers.ErrInvalidInput)
+			}
+		}
+		if n.SugarsGrams.GreaterThan(n.CarbohydratesGrams) || n.SaturatedFatsGrams.GreaterThan(n.FatsGrams) {
+			return fmt.Errorf("%w: nutrition facts are inconsistent", helpers.ErrInvalidInput)
+		}
+	}
+
+	return nil
+}
+
+// recordPriceChange appends the product's current price to its history, to be called in the unit of work
+// saving the product so neither is saved without the other.
+func (p productsUseCase) recordPriceChange(ctx context.Context, product *domain.Product) error {
+	userID, _ := callerID(ctx)
+	now := time.Now()
+	price := domain.NewProductPrice(uuid.New(), product.ID, userID, product.Price, now)
+	price.AppliedAt = now
+
+	_, err := p.priceRepo.InsertProductPrice(ctx, price)
+	return err
+}
+
+func (p productsUseCase) DeleteProduct(ctx context.Context, prodID uuid.UUID) error {
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_DELETE) {
+		return denied(ctx, domain.PERMISSION_PRODUCTS_DELETE)
+	}
+
+	current, err := p.productRepo.GetProduct(ctx, prodID)
+	if err != nil {
+		return err
+	}
+
+	if err = p.productRepo.DeleteProduct(ctx, prodID); err != nil {
+		return err
+	}
+	p.audit.record(ctx, domain.AUDIT_PRODUCT_DELETE, domain.AUDIT_ENTITY_PRODUCT, prodID, current, nil)
+
+	return nil
 }
 
-func (p productsUseCase) DeleteProduct(ctx context.Context, userID uuid.UUID, prodID uuid.UUID) error {
-	if !isAdmin(p.logger, p.userUC, ctx, userID) {
-		return helpers.ErrUnauthorized
+func (p productsUseCase) RestoreProduct(ctx context.Context, prodID uuid.UUID) (*domain.Product, error) {
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_RESTORE) {
+		return nil, denied(ctx, domain.PERMISSION_PRODUCTS_RESTORE)
+	}
+
+	if err := p.productRepo.RestoreProduct(ctx, prodID); err != nil {
+		return nil, err
+	}
+
+	out, err := p.productRepo.GetProduct(ctx, prodID)
+	if err != nil {
+		return nil, err
 	}
+	p.audit.record(ctx, domain.AUDIT_PRODUCT_RESTORE, domain.AUDIT_ENTITY_PRODUCT, prodID, nil, out)
 
-	return p.productRepo.DeleteProduct(ctx, prodID)
+	return out, nil
 }
 
-func (p productsUseCase) ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) (*domain.ProductList, error) {
-	out, err := p.productRepo.ListProductsByCategory(ctx, categoryID, limit, offset)
+// ListProductsByCategory lists the products available in the category, leaving out the ones
+// containing any of the excluded allergens. Only admins may include deleted products in the listing.
+func (p productsUseCase) ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, page domain.Page, filter domain.ProductFilter) (*domain.ProductList, error) {
+	if filter.IncludeDeleted && !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_LIST_DELETED) {
+		return nil, denied(ctx, domain.PERMISSION_PRODUCTS_LIST_DELETED)
+	}
+	for _, a := range filter.ExcludeAllergens {
+		if !a.IsValid() {
+			return nil, fmt.Errorf("%w: unknown allergen %q", helpers.ErrInvalidInput, a)
+		}
+	}
+
+	out, err := p.productRepo.ListProductsByCategory(ctx, categoryID, page, filter)
 	return out, err
 
 }
+
+func (p productsUseCase) ListProductPriceHistory(ctx context.Context, productID uuid.UUID) ([]*domain.ProductPrice, error) {
+	if _, err := p.productRepo.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return p.priceRepo.ListProductPrices(ctx, productID)
+}
+
+func (p productsUseCase) GetProductPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductPrice, error) {
+	return p.priceRepo.GetProductPriceAt(ctx, productID, at)
+}
+
+// ScheduleProductPrice registers a future price change, applied by ApplyScheduledPrices once due.
+func (p productsUseCase) ScheduleProductPrice(ctx context.Context, productID uuid.UUID, price decimal.Decimal, effectiveFrom time.Time) (*domain.ProductPrice, error) {
+	if !isAllowed(p.logger, p.userUC, ctx, domain.PERMISSION_PRODUCTS_SCHEDULE_PRICE) {
+		return nil, denied(ctx, domain.PERMISSION_PRODUCTS_SCHEDULE_PRICE)
+	}
+
+	if !price.IsPositive() || !effectiveFrom.After(time.Now()) {
+		p.logger.Errorw(
+			"invalid scheduled price",
+			zap.String("product_id", productID.String()),
+			zap.String("price", price.String()),
+			zap.Time("effective_from", effectiveFrom),
+			zap.Error(helpers.ErrInvalidInput),
+		)
+		return nil, helpers.ErrInvalidInput
+	}
+
+	if _, err := p.productRepo.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	userID, _ := callerID(ctx)
+	out, err := p.priceRepo.InsertProductPrice(ctx, domain.NewProductPrice(uuid.New(), productID, userID, price, effectiveFrom))
+	if err != nil {
+		return nil, err
+	}
+	p.audit.record(ctx, domain.AUDIT_PRODUCT_SCHEDULE_PRICE, domain.AUDIT_ENTITY_PRODUCT, productID, nil, out)
+
+	return out, nil
+}
+
+// ApplyScheduledPrices sets on the products every scheduled price that became due, oldest first. Each price is
+// applied in its own transaction, along with marking it applied in the history, and a failing one does not
+// hold back the others: it is logged, returned joined with the other failures, and retried on the next call.
+// Prices of deleted products are not due, they wait until the product is restored.
+func (p productsUseCase) ApplyScheduledPrices(ctx context.Context) error {
+	now := time.Now()
+	due, err := p.priceRepo.ListDueProductPrices(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, price := range due {
+		if err = p.uow.Do(ctx, func(ctx context.Context) error {
+			return p.applyScheduledPrice(ctx, price, now)
+		}); err != nil {
+			p.logger.Errorw(
+				"failed applying scheduled product price",
+				zap.String("id", price.ID.String()),
+				zap.String("product_id", price.ProductID.String()),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Errorf("scheduled price %s: %w", price.ID, err))
+			continue
+		}
+
+		p.logger.Infow(
+			"scheduled product price applied",
+			zap.String("product_id", price.ProductID.String()),
+			zap.String("price", price.Price.String()),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p productsUseCase) applyScheduledPrice(ctx context.Context, price *domain.ProductPrice, now time.Time) error {
+	product, err := p.productRepo.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return err
+	}
+
+	product.Price = price.Price
+	if _, err = p.productRepo.UpdateProduct(ctx, product); err != nil {
+		return err
+	}
+
+	return p.priceRepo.SetProductPriceApplied(ctx, price.ID, now)
+}
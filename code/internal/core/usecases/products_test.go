@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// fakeUnitOfWork runs fn right away, calling rollback, when set, if it fails.
+type fakeUnitOfWork struct {
+	snapshot func() (rollback func())
+}
+
+func (f fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	rollback := func() {}
+	if f.snapshot != nil {
+		rollback = f.snapshot()
+	}
+	err := fn(ctx)
+	if err != nil {
+		rollback()
+	}
+	return err
+}
+
+// fakeProductStore keeps products and their scheduled prices in memory, failing the calls set to fail.
+type fakeProductStore struct {
+	ports.ProductsRepository
+	ports.ProductPricesRepository
+	products   map[uuid.UUID]domain.Product
+	applied    map[uuid.UUID]time.Time
+	due        []*domain.ProductPrice
+	failUpdate map[uuid.UUID]bool
+	failApply  map[uuid.UUID]bool
+}
+
+func (f *fakeProductStore) snapshot() func() {
+	products, applied := map[uuid.UUID]domain.Product{}, map[uuid.UUID]time.Time{}
+	for k, v := range f.products {
+		products[k] = v
+	}
+	for k, v := range f.applied {
+		applied[k] = v
+	}
+	return func() { f.products, f.applied = products, applied }
+}
+
+func (f *fakeProductStore) GetProduct(_ context.Context, id uuid.UUID) (*domain.Product, error) {
+	product, ok := f.products[id]
+	if !ok {
+		return nil, helpers.NewNotFoundError("product", id)
+	}
+	return &product, nil
+}
+
+func (f *fakeProductStore) UpdateProduct(_ context.Context, in *domain.Product) (*domain.Product, error) {
+	if f.failUpdate[in.ID] {
+		return nil, helpers.NewVersionConflictError("product", in.ID, in.Version)
+	}
+	f.products[in.ID] = *in
+	return in, nil
+}
+
+func (f *fakeProductStore) ListDueProductPrices(context.Context, time.Time) ([]*domain.ProductPrice, error) {
+	return f.due, nil
+}
+
+func (f *fakeProductStore) SetProductPriceApplied(_ context.Context, id uuid.UUID, appliedAt time.Time) error {
+	if f.failApply[id] {
+		return errors.New("connection reset")
+	}
+	f.applied[id] = appliedAt
+	return nil
+}
+
+func TestApplyScheduledPrices(t *testing.T) {
+	burger, soda, missing := uuid.New(), uuid.New(), uuid.New()
+	burgerPrice := domain.NewProductPrice(uuid.New(), burger, uuid.Nil, decimal.NewFromInt(25), time.Now())
+	sodaPrice := domain.NewProductPrice(uuid.New(), soda, uuid.Nil, decimal.NewFromInt(8), time.Now())
+	missingPrice := domain.NewProductPrice(uuid.New(), missing, uuid.Nil, decimal.NewFromInt(10), time.Now())
+
+	tests := []struct {
+		name        string
+		due         []*domain.ProductPrice
+		failUpdate  uuid.UUID
+		failApply   uuid.UUID
+		wantPrices  map[uuid.UUID]int64
+		wantApplied int
+		wantErr     bool
+	}{
+		{
+			name:        "001_should_apply_every_due_price",
+			due:         []*domain.ProductPrice{burgerPrice, sodaPrice},
+			wantPrices:  map[uuid.UUID]int64{burger: 25, soda: 8},
+			wantApplied: 2,
+		},
+		{
+			name:        "002_should_continue_past_missing_product",
+			due:         []*domain.ProductPrice{missingPrice, sodaPrice},
+			wantPrices:  map[uuid.UUID]int64{burger: 20, soda: 8},
+			wantApplied: 1,
+			wantErr:     true,
+		},
+		{
+			name:        "003_should_continue_past_failed_update",
+			due:         []*domain.ProductPrice{burgerPrice, sodaPrice},
+			failUpdate:  burger,
+			wantPrices:  map[uuid.UUID]int64{burger: 20, soda: 8},
+			wantApplied: 1,
+			wantErr:     true,
+		},
+		{
+			name:        "004_should_keep_price_unchanged_when_history_fails",
+			due:         []*domain.ProductPrice{burgerPrice, sodaPrice},
+			failApply:   burgerPrice.ID,
+			wantPrices:  map[uuid.UUID]int64{burger: 20, soda: 8},
+			wantApplied: 1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeProductStore{
+				products: map[uuid.UUID]domain.Product{
+					burger: {ID: burger, Price: decimal.NewFromInt(20)},
+					soda:   {ID: soda, Price: decimal.NewFromInt(6)},
+				},
+				applied:    map[uuid.UUID]time.Time{},
+				due:        tt.due,
+				failUpdate: map[uuid.UUID]bool{tt.failUpdate: true},
+				failApply:  map[uuid.UUID]bool{tt.failApply: true},
+			}
+			products := NewProductsUseCase(store, store, nil, fakeUnitOfWork{snapshot: store.snapshot}, nil, zap.NewNop().Sugar())
+
+			err := products.ApplyScheduledPrices(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ApplyScheduledPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for id, want := range tt.wantPrices {
+				if got := store.products[id].Price; !got.Equal(decimal.NewFromInt(want)) {
+					t.Errorf("price of %v = %s, want %d", id, got, want)
+				}
+			}
+			if len(store.applied) != tt.wantApplied {
+				t.Errorf("applied = %d, want %d", len(store.applied), tt.wantApplied)
+			}
+		})
+	}
+}
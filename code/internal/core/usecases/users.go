@@ -2,48 +2,330 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
 )
 
 type usersUseCase struct {
-	logger   *zap.SugaredLogger
-	userRepo ports.UsersRepository
+	logger    *zap.SugaredLogger
+	userRepo  ports.UsersRepository
+	guestRepo ports.GuestSessionsRepository
+	uow       ports.UnitOfWork
+	audit     auditor
 }
 
-func NewUsersUseCase(userRepo ports.UsersRepository, log *zap.SugaredLogger) ports.UsersUseCase {
-	return &usersUseCase{userRepo: userRepo, logger: log}
+func NewUsersUseCase(userRepo ports.UsersRepository, guestRepo ports.GuestSessionsRepository, auditRepo ports.AuditRepository, uow ports.UnitOfWork, log *zap.SugaredLogger) ports.UsersUseCase {
+	return &usersUseCase{userRepo: userRepo, guestRepo: guestRepo, uow: uow, logger: log, audit: newAuditor(log, auditRepo)}
 }
 
-func (u usersUseCase) IsUserAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
-	isAdmin, err := u.userRepo.IsUserAdmin(ctx, id)
+// ListUserRoles lists the roles of the user. Users may list their own roles, only admins the others'.
+func (u usersUseCase) ListUserRoles(ctx context.Context, id uuid.UUID) ([]domain.Role, error) {
+	uID, err := callerID(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if uID != id && !isAllowed(u.logger, u, ctx, domain.PERMISSION_ROLES_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_ROLES_MANAGE)
 	}
 
-	return isAdmin, err
+	return u.userRepo.ListUserRoles(ctx, id)
 }
 
-func (u usersUseCase) CreateUser(ctx context.Context, name, document, email string) (*domain.User, error) {
-	user := domain.NewUser(uuid.New(), document, name, email)
+func (u usersUseCase) GrantUserRole(ctx context.Context, id uuid.UUID, role domain.Role) ([]domain.Role, error) {
+	if !isAllowed(u.logger, u, ctx, domain.PERMISSION_ROLES_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_ROLES_MANAGE)
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", helpers.ErrInvalidInput, role)
+	}
+
+	before, err := u.userRepo.ListUserRoles(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	uID, _ := callerID(ctx)
+	if err = u.userRepo.GrantUserRole(ctx, id, role, uID); err != nil {
+		return nil, err
+	}
+
+	return u.auditRoles(ctx, domain.AUDIT_USER_GRANT_ROLE, id, before)
+}
+
+// RevokeUserRole takes a role from the user. Admins can not revoke their own admin role,
+// so there is always someone left to grant it back.
+func (u usersUseCase) RevokeUserRole(ctx context.Context, id uuid.UUID, role domain.Role) ([]domain.Role, error) {
+	if !isAllowed(u.logger, u, ctx, domain.PERMISSION_ROLES_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_ROLES_MANAGE)
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", helpers.ErrInvalidInput, role)
+	}
+
+	if uID, _ := callerID(ctx); uID == id && role == domain.ROLE_ADMIN {
+		return nil, helpers.NewConflictError("role", "admins can not revoke their own admin role")
+	}
 
-	err := u.userRepo.InsertUser(ctx, user)
+	before, err := u.userRepo.ListUserRoles(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.userRepo.RevokeUserRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+
+	return u.auditRoles(ctx, domain.AUDIT_USER_REVOKE_ROLE, id, before)
+}
+
+// auditRoles records the role change of the user, returning the roles left.
+func (u usersUseCase) auditRoles(ctx context.Context, action domain.AuditAction, id uuid.UUID, before []domain.Role) ([]domain.Role, error) {
+	after, err := u.userRepo.ListUserRoles(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.audit.record(ctx, action, domain.AUDIT_ENTITY_USER, id, before, after)
+
+	return after, nil
+}
+
+// CreateUser registers a customer. The password is optional, customers without one identify themselves by CPF only.
+// The CPF is validated and stored with digits only, so the same CPF can not be registered twice in different formats.
+// When a guest registers, the orders of the guest session are moved to the new account.
+// Consents given at registration are recorded for the new user.
+func (u usersUseCase) CreateUser(ctx context.Context, name, document, email, password string, consents []*domain.Consent) (*domain.User, error) {
+	if err := validateConsents(consents); err != nil {
+		return nil, err
+	}
+
+	cpf, err := domain.NormalizeCPF(document)
 	if err != nil {
 		u.logger.Errorw(
-			"failed inserting user",
+			"invalid user document",
 			zap.String("document", document),
 			zap.Error(err),
 		)
 		return nil, err
 	}
 
-	return user, err
+	_, err = u.userRepo.GetUserByDocument(ctx, cpf)
+	switch {
+	case err == nil:
+		return nil, helpers.NewConflictError("user", "document already registered")
+	case !errors.Is(err, helpers.ErrNotFound):
+		return nil, err
+	}
+
+	if err = u.checkEmailAvailable(ctx, email, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	user := domain.NewUser(uuid.New(), cpf, name, email)
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			u.logger.Errorw(
+				"failed hashing user password",
+				zap.String("document", document),
+				zap.Error(err),
+			)
+			return nil, helpers.ErrInvalidInput
+		}
+		user.PasswordHash = string(hash)
+	}
+
+	// the user is created together with its consents and the orders of the guest session, or not at all
+	err = u.uow.Do(ctx, func(ctx context.Context) error {
+		if err := u.userRepo.InsertUser(ctx, user); err != nil {
+			u.logger.Errorw(
+				"failed inserting user",
+				zap.String("document", document),
+				zap.Error(err),
+			)
+			return err
+		}
+
+		if err := u.userRepo.InsertUserConsents(ctx, newConsents(user.ID, consents)); err != nil {
+			u.logger.Errorw(
+				"failed recording user consents at registration",
+				zap.String("user_id", user.ID.String()),
+				zap.Error(err),
+			)
+			return err
+		}
+
+		if caller, ok := domain.CallerFromContext(ctx); ok && caller.IsGuest() {
+			if err := u.guestRepo.MergeGuestSession(ctx, caller.GuestSessionID, user.ID); err != nil {
+				u.logger.Errorw(
+					"failed merging guest session into user",
+					zap.String("guest_session_id", caller.GuestSessionID.String()),
+					zap.String("user_id", user.ID.String()),
+					zap.Error(err),
+				)
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
+// GetMyProfile returns the user authenticated for the request.
+func (u usersUseCase) GetMyProfile(ctx context.Context) (*domain.User, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.GetUser(ctx, uID)
+}
+
+// UpdateMyProfile changes the name and email of the user authenticated for the request.
+// The document identifies the user, so it can not be changed.
+func (u usersUseCase) UpdateMyProfile(ctx context.Context, name, email string) (*domain.User, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name, email = strings.TrimSpace(name), strings.TrimSpace(email)
+	switch {
+	case name == "":
+		return nil, helpers.NewValidationError("name", "must not be empty")
+	case email == "":
+		return nil, helpers.NewValidationError("email", "must not be empty")
+	}
+
+	user, err := u.userRepo.GetUser(ctx, uID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.checkEmailAvailable(ctx, email, uID); err != nil {
+		return nil, err
+	}
+
+	user.Name = name
+	user.Email = email
+	if err = u.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListUsers lists the registered users, optionally searching by name, email or document. Admins only.
+func (u usersUseCase) ListUsers(ctx context.Context, limit, offset int, search string) (*domain.UserList, error) {
+	if !isAllowed(u.logger, u, ctx, domain.PERMISSION_USERS_LIST) {
+		return nil, denied(ctx, domain.PERMISSION_USERS_LIST)
+	}
+
+	return u.userRepo.ListUsers(ctx, limit, offset, strings.TrimSpace(search))
+}
+
+// ListMyConsents lists every consent record of the user authenticated for the request, oldest first.
+func (u usersUseCase) ListMyConsents(ctx context.Context) ([]*domain.Consent, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.ListUserConsents(ctx, uID)
+}
+
+// RecordMyConsents grants or revokes purposes for the user authenticated for the request, returning the
+// full consent history.
+func (u usersUseCase) RecordMyConsents(ctx context.Context, consents []*domain.Consent) ([]*domain.Consent, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(consents) == 0 {
+		return nil, helpers.NewValidationError("consents", "must not be empty")
+	}
+	if err = validateConsents(consents); err != nil {
+		return nil, err
+	}
+
+	if err = u.userRepo.InsertUserConsents(ctx, newConsents(uID, consents)); err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.ListUserConsents(ctx, uID)
+}
+
+// ListEligibleContacts lists the customers who currently grant the purpose.
+func (u usersUseCase) ListEligibleContacts(ctx context.Context, purpose domain.ConsentPurpose, limit, offset int) (*domain.ContactList, error) {
+	if !isAllowed(u.logger, u, ctx, domain.PERMISSION_USERS_CONTACTS) {
+		return nil, denied(ctx, domain.PERMISSION_USERS_CONTACTS)
+	}
+	if !purpose.IsValid() {
+		return nil, helpers.NewValidationError("purpose", "unknown consent purpose")
+	}
+
+	return u.userRepo.ListEligibleContacts(ctx, purpose, limit, offset)
+}
+
+func validateConsents(consents []*domain.Consent) error {
+	for _, c := range consents {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newConsents stamps the records of the user, ignoring any ID or date sent by the client.
+func newConsents(userID uuid.UUID, in []*domain.Consent) []*domain.Consent {
+	now := time.Now()
+	out := make([]*domain.Consent, 0, len(in))
+	for _, c := range in {
+		out = append(out, domain.NewConsent(uuid.New(), userID, c.Purpose, c.Granted, c.Channel, now))
+	}
+	return out
+}
+
+// checkEmailAvailable refuses emails already used by users other than userID.
+func (u usersUseCase) checkEmailAvailable(ctx context.Context, email string, userID uuid.UUID) error {
+	other, err := u.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, helpers.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case other.ID != userID:
+		return helpers.NewConflictError("email", "email already registered")
+	}
+	return nil
+}
+
+// ValidateUser looks up the user with the CPF, for the staff at the counter. It is restricted to
+// PERMISSION_USERS_LIST, as it tells whether a CPF is registered.
 func (u usersUseCase) ValidateUser(ctx context.Context, document string) (uuid.UUID, error) {
-	uID, err := u.userRepo.ValidateUser(ctx, document)
+	if !isAllowed(u.logger, u, ctx, domain.PERMISSION_USERS_LIST) {
+		return uuid.Nil, denied(ctx, domain.PERMISSION_USERS_LIST)
+	}
+
+	cpf, err := domain.NormalizeCPF(document)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	uID, err := u.userRepo.ValidateUser(ctx, cpf)
 	if err != nil {
 		u.logger.Errorw(
 			"failed validating user",
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeRegistrationStore keeps the users, consents and merged guest sessions written by a registration.
+type fakeRegistrationStore struct {
+	ports.UsersRepository
+	ports.GuestSessionsRepository
+	users        []*domain.User
+	consents     []*domain.Consent
+	merged       []uuid.UUID
+	failConsents bool
+	failMerge    bool
+}
+
+func (f *fakeRegistrationStore) snapshot() func() {
+	users, consents, merged := f.users, f.consents, f.merged
+	return func() { f.users, f.consents, f.merged = users, consents, merged }
+}
+
+func (f *fakeRegistrationStore) GetUserByDocument(context.Context, string) (*domain.User, error) {
+	return nil, helpers.NewNotFoundError("user", uuid.Nil)
+}
+
+func (f *fakeRegistrationStore) GetUserByEmail(context.Context, string) (*domain.User, error) {
+	return nil, helpers.NewNotFoundError("user", uuid.Nil)
+}
+
+func (f *fakeRegistrationStore) InsertUser(_ context.Context, user *domain.User) error {
+	f.users = append(f.users, user)
+	return nil
+}
+
+func (f *fakeRegistrationStore) InsertUserConsents(_ context.Context, consents []*domain.Consent) error {
+	if f.failConsents {
+		return errors.New("connection reset")
+	}
+	f.consents = append(f.consents, consents...)
+	return nil
+}
+
+func (f *fakeRegistrationStore) MergeGuestSession(_ context.Context, id, _ uuid.UUID) error {
+	if f.failMerge {
+		return errors.New("connection reset")
+	}
+	f.merged = append(f.merged, id)
+	return nil
+}
+
+func TestCreateUser(t *testing.T) {
+	guest := domain.ContextWithCaller(context.Background(), domain.Caller{GuestSessionID: uuid.New()})
+	consents := []*domain.Consent{{Purpose: domain.CONSENT_PURPOSE_EMAIL_PROMOTIONS, Granted: true, Channel: domain.CONSENT_CHANNEL_KIOSK}}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		failConsents bool
+		failMerge    bool
+		wantErr      bool
+		wantUsers    int
+		wantMerged   int
+	}{
+		{name: "001_should_register_guest_with_consents_and_orders", ctx: guest, wantUsers: 1, wantMerged: 1},
+		{name: "002_should_register_without_guest_session", ctx: context.Background(), wantUsers: 1},
+		{name: "003_should_not_register_when_consents_fail", ctx: guest, failConsents: true, wantErr: true},
+		{name: "004_should_not_register_when_merge_fails", ctx: guest, failMerge: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeRegistrationStore{failConsents: tt.failConsents, failMerge: tt.failMerge}
+			users := NewUsersUseCase(store, store, nil, fakeUnitOfWork{snapshot: store.snapshot}, zap.NewNop().Sugar())
+
+			_, err := users.CreateUser(tt.ctx, "Ana", "529.982.247-25", "ana@example.com", "", consents)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(store.users) != tt.wantUsers {
+				t.Errorf("users = %d, want %d", len(store.users), tt.wantUsers)
+			}
+			if len(store.consents) != tt.wantUsers*len(consents) {
+				t.Errorf("consents = %d, want %d", len(store.consents), tt.wantUsers*len(consents))
+			}
+			if len(store.merged) != tt.wantMerged {
+				t.Errorf("merged = %d, want %d", len(store.merged), tt.wantMerged)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// webhookBatchSize is how many due deliveries are sent at a time.
+const webhookBatchSize = 50
+
+var errWebhookSubscriptionDeleted = errors.New("webhook subscription was deleted")
+
+type webhookDispatcher struct {
+	logger *zap.SugaredLogger
+	repo   ports.WebhooksRepository
+	sender ports.WebhookSender
+}
+
+func NewWebhookDispatcher(logger *zap.SugaredLogger, repo ports.WebhooksRepository, sender ports.WebhookSender) ports.WebhookDispatcher {
+	return &webhookDispatcher{logger: logger, repo: repo, sender: sender}
+}
+
+// DeliverWebhooks sends the due deliveries, oldest first. Failed ones are retried later with a growing delay,
+// until WEBHOOK_MAX_ATTEMPTS sends them to the dead letters, so partners may receive a delivery more than once.
+func (d *webhookDispatcher) DeliverWebhooks(ctx context.Context) error {
+	due, err := d.repo.ListDueWebhookDeliveries(ctx, time.Now(), webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := make(map[uuid.UUID]*domain.WebhookSubscription)
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			if subscription, err = d.repo.GetWebhookSubscription(ctx, delivery.SubscriptionID); err != nil && !errors.Is(err, helpers.ErrNotFound) {
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		d.deliver(ctx, subscription, delivery)
+	}
+
+	return nil
+}
+
+func (d *webhookDispatcher) deliver(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) {
+	if subscription == nil || subscription.IsDeleted() {
+		// nobody is listening anymore, straight to the dead letters
+		delivery.Failed(errWebhookSubscriptionDeleted, 0, time.Now())
+		delivery.Status = domain.WEBHOOK_DELIVERY_DEAD
+		_ = d.repo.UpdateWebhookDelivery(ctx, delivery)
+		return
+	}
+
+	statusCode, err := d.sender.Send(ctx, subscription, delivery)
+	if err != nil {
+		delivery.Failed(err, statusCode, time.Now())
+		d.logger.Errorw(
+			"failed delivering webhook",
+			zap.String("id", delivery.ID.String()),
+			zap.String("subscription_id", subscription.ID.String()),
+			zap.String("event_type", string(delivery.Envelope.Type)),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("status", string(delivery.Status)),
+			zap.Error(err),
+		)
+	} else {
+		delivery.Delivered(statusCode, time.Now())
+	}
+
+	_ = d.repo.UpdateWebhookDelivery(ctx, delivery)
+}
@@ -0,0 +1,206 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhooksQueue is the broker queue feeding the webhook deliveries, shared by every instance.
+	webhooksQueue     = "lanchonete.webhooks"
+	webhookSecretMark = "whsec_"
+)
+
+type webhooksUseCase struct {
+	logger *zap.SugaredLogger
+	repo   ports.WebhooksRepository
+	userUC ports.UsersUseCase
+	audit  auditor
+}
+
+// NewWebhooksUseCase also consumes the events partners may subscribe to from the broker, saving a delivery
+// for each subscription. The dispatcher sends them afterwards.
+func NewWebhooksUseCase(
+	logger *zap.SugaredLogger,
+	repo ports.WebhooksRepository,
+	auditRepo ports.AuditRepository,
+	userUC ports.UsersUseCase,
+	broker ports.MessageBroker,
+) ports.WebhooksUseCase {
+	webhooksUC := &webhooksUseCase{logger: logger, repo: repo, userUC: userUC, audit: newAuditor(logger, auditRepo)}
+
+	for _, eventType := range domain.WebhookEventTypes {
+		if err := broker.Subscribe(eventType, webhooksQueue, webhooksUC.onEvent); err != nil {
+			logger.Errorw(
+				"failed subscribing webhooks to events",
+				zap.String("event_type", string(eventType)),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return webhooksUC
+}
+
+// CreateWebhookSubscription subscribes a partner URL to the event types. Without a secret one is drawn,
+// either way the returned subscription is the only time it is shown.
+func (w *webhooksUseCase) CreateWebhookSubscription(ctx context.Context, rawURL string, eventTypes []domain.EventType, secret string) (*domain.WebhookSubscription, error) {
+	uID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAllowed(w.logger, w.userUC, ctx, domain.PERMISSION_WEBHOOKS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_WEBHOOKS_MANAGE)
+	}
+
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, helpers.NewValidationError("url", "must be an absolute http or https url")
+	}
+	if len(eventTypes) == 0 {
+		return nil, helpers.NewValidationError("event_types", "must not be empty")
+	}
+	seen := make(map[domain.EventType]bool, len(eventTypes))
+	unique := make([]domain.EventType, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		if !domain.IsWebhookEventType(t) {
+			return nil, helpers.NewValidationError("event_types", fmt.Sprintf("event %q can not be subscribed", t))
+		}
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+
+	switch {
+	case secret == "":
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	case len(secret) < domain.WEBHOOK_SECRET_MIN_LENGTH:
+		return nil, helpers.NewValidationError("secret", fmt.Sprintf("must have at least %d characters", domain.WEBHOOK_SECRET_MIN_LENGTH))
+	}
+
+	subscription := domain.NewWebhookSubscription(uuid.New(), rawURL, unique, secret, uID, time.Now())
+	if err = w.repo.InsertWebhookSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	audited := *subscription
+	audited.Secret = ""
+	w.audit.record(ctx, domain.AUDIT_WEBHOOK_CREATE, domain.AUDIT_ENTITY_WEBHOOK, subscription.ID, nil, audited)
+
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions lists the active subscriptions, without their secrets.
+func (w *webhooksUseCase) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	if !isAllowed(w.logger, w.userUC, ctx, domain.PERMISSION_WEBHOOKS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_WEBHOOKS_MANAGE)
+	}
+
+	subscriptions, err := w.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subscriptions {
+		s.Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription stops sending events to the partner. Pending deliveries go to the dead letters
+// when the dispatcher reaches them.
+func (w *webhooksUseCase) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	if !isAllowed(w.logger, w.userUC, ctx, domain.PERMISSION_WEBHOOKS_MANAGE) {
+		return denied(ctx, domain.PERMISSION_WEBHOOKS_MANAGE)
+	}
+
+	now := time.Now()
+	if err := w.repo.DeleteWebhookSubscription(ctx, id, now); err != nil {
+		return err
+	}
+	w.audit.record(ctx, domain.AUDIT_WEBHOOK_DELETE, domain.AUDIT_ENTITY_WEBHOOK, id, nil, map[string]any{"DeletedAt": now})
+
+	return nil
+}
+
+// ListWebhookDeliveries lists the delivery log, newest first. Filtering by the dead status lists the
+// dead letters.
+func (w *webhooksUseCase) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter, limit, offset int) (*domain.WebhookDeliveryList, error) {
+	if !isAllowed(w.logger, w.userUC, ctx, domain.PERMISSION_WEBHOOKS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_WEBHOOKS_MANAGE)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, helpers.NewValidationError("status", "must be pending, delivered or dead")
+	}
+
+	return w.repo.ListWebhookDeliveries(ctx, filter, limit, offset)
+}
+
+// RetryWebhookDelivery sends a dead letter again, such as after the partner fixed its endpoint.
+func (w *webhooksUseCase) RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	if !isAllowed(w.logger, w.userUC, ctx, domain.PERMISSION_WEBHOOKS_MANAGE) {
+		return nil, denied(ctx, domain.PERMISSION_WEBHOOKS_MANAGE)
+	}
+
+	delivery, err := w.repo.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != domain.WEBHOOK_DELIVERY_DEAD {
+		return nil, helpers.NewConflictError("webhook delivery", "only dead letters can be retried")
+	}
+
+	subscription, err := w.repo.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.IsDeleted() {
+		return nil, helpers.NewConflictError("webhook delivery", "subscription was deleted")
+	}
+
+	before := *delivery
+	delivery.Retry(time.Now())
+	if err = w.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	w.audit.record(ctx, domain.AUDIT_WEBHOOK_RETRY_DELIVERY, domain.AUDIT_ENTITY_WEBHOOK, delivery.SubscriptionID, before, delivery)
+
+	return delivery, nil
+}
+
+// onEvent saves a delivery of the event for each subscription to its type. The same event consumed again
+// is not saved twice.
+func (w *webhooksUseCase) onEvent(ctx context.Context, envelope domain.EventEnvelope) error {
+	subscriptions, err := w.repo.ListWebhookSubscriptionsByEventType(ctx, envelope.Type)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*domain.WebhookDelivery, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		deliveries = append(deliveries, domain.NewWebhookDelivery(uuid.New(), s.ID, envelope, now))
+	}
+
+	return w.repo.InsertWebhookDeliveries(ctx, deliveries)
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return webhookSecretMark + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,176 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+var ErrBusClosed = errors.New("event bus is closed")
+
+// queued is an event waiting for a subscriber, with the context values it was published with.
+type queued struct {
+	ctx   context.Context
+	event domain.Event
+}
+
+type subscriber struct {
+	name    string
+	handler ports.EventHandler
+	queue   chan queued
+}
+
+type inMemoryBus struct {
+	logger     *zap.SugaredLogger
+	bufferSize int
+
+	mu          sync.RWMutex
+	closed      bool
+	subscribers map[domain.EventType][]*subscriber
+	wg          sync.WaitGroup
+}
+
+// NewInMemoryBus delivers events within the process. Every subscriber has its own queue of bufferSize events
+// and goroutine, so a slow subscriber only delays itself, and publishers wait for room when its queue is full.
+func NewInMemoryBus(logger *zap.SugaredLogger, bufferSize int) ports.EventBus {
+	return &inMemoryBus{
+		logger:      logger,
+		bufferSize:  bufferSize,
+		subscribers: make(map[domain.EventType][]*subscriber),
+	}
+}
+
+func (b *inMemoryBus) Subscribe(eventType domain.EventType, name string, handler ports.EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		b.logger.Errorw(
+			"subscribing to closed event bus",
+			zap.String("event_type", string(eventType)),
+			zap.String("subscriber", name),
+			zap.Error(ErrBusClosed),
+		)
+		return
+	}
+
+	s := &subscriber{name: name, handler: handler, queue: make(chan queued, b.bufferSize)}
+	b.subscribers[eventType] = append(b.subscribers[eventType], s)
+
+	b.wg.Add(1)
+	go b.run(s)
+}
+
+// Publish queues the event for every subscriber of its type. It blocks while a queue is full, until ctx is done.
+func (b *inMemoryBus) Publish(ctx context.Context, event domain.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	// the subscribers outlive the request that published the event, only its values are kept
+	q := queued{ctx: detachedContext(ctx), event: event}
+	for _, s := range b.subscribers[event.EventType()] {
+		select {
+		case s.queue <- q:
+		case <-ctx.Done():
+			b.logger.Errorw(
+				"event bus queue full",
+				zap.String("event_type", string(event.EventType())),
+				zap.String("subscriber", s.name),
+				zap.Error(ctx.Err()),
+			)
+			return fmt.Errorf("queueing %s for %s: %w", event.EventType(), s.name, ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (b *inMemoryBus) Dispatch(ctx context.Context, event domain.Event) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	subscribers := b.subscribers[event.EventType()]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range subscribers {
+		if err := b.handle(s, queued{ctx: ctx, event: event}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *inMemoryBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, subscribers := range b.subscribers {
+			for _, s := range subscribers {
+				close(s.queue)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("draining event bus: %w", ctx.Err())
+	}
+}
+
+func (b *inMemoryBus) run(s *subscriber) {
+	defer b.wg.Done()
+
+	for q := range s.queue {
+		_ = b.handle(s, q)
+	}
+}
+
+// handle runs the subscriber on one event, recovering from panics so one bad event does not stop the others.
+func (b *inMemoryBus) handle(s *subscriber, q queued) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+		if err != nil {
+			b.logger.Errorw(
+				"event handler failed",
+				zap.String("event_type", string(q.event.EventType())),
+				zap.String("subscriber", s.name),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	return s.handler(q.ctx, q.event)
+}
+
+// detachedContext keeps the caller and request ID of ctx, without its deadline and cancellation.
+func detachedContext(ctx context.Context) context.Context {
+	out := domain.ContextWithRequestID(context.Background(), domain.RequestIDFromContext(ctx))
+	if caller, ok := domain.CallerFromContext(ctx); ok {
+		out = domain.ContextWithCaller(out, caller)
+	}
+	return out
+}
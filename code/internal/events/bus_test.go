@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestInMemoryBus(t *testing.T) {
+	tests := []struct {
+		name      string
+		publish   int
+		panicAt   int
+		wantCalls int
+	}{
+		{name: "001_should_deliver_every_event_to_every_subscriber", publish: 5, panicAt: -1, wantCalls: 10},
+		{name: "002_should_keep_delivering_after_handler_panic", publish: 5, panicAt: 2, wantCalls: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewInMemoryBus(zap.NewNop().Sugar(), 1)
+
+			var mu sync.Mutex
+			calls := 0
+			handler := func(panicAt int) func(ctx context.Context, event domain.Event) error {
+				seen := 0
+				return func(ctx context.Context, event domain.Event) error {
+					seen++
+					if seen-1 == panicAt {
+						panic("boom")
+					}
+					mu.Lock()
+					calls++
+					mu.Unlock()
+					return nil
+				}
+			}
+			bus.Subscribe(domain.EVENT_PAYMENT_STATUS_CHANGED, "first", handler(tt.panicAt))
+			bus.Subscribe(domain.EVENT_PAYMENT_STATUS_CHANGED, "second", handler(-1))
+
+			for i := 0; i < tt.publish; i++ {
+				if err := bus.Publish(context.Background(), domain.PaymentStatusChanged{PaymentID: uuid.New()}); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := bus.Close(ctx); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handled %d events, want %d", calls, tt.wantCalls)
+			}
+			if err := bus.Publish(context.Background(), domain.PaymentStatusChanged{}); !errors.Is(err, ErrBusClosed) {
+				t.Errorf("Publish() after Close() error = %v, want %v", err, ErrBusClosed)
+			}
+		})
+	}
+}
+
+func TestInMemoryBusFullQueue(t *testing.T) {
+	bus := NewInMemoryBus(zap.NewNop().Sugar(), 1)
+
+	release := make(chan struct{})
+	bus.Subscribe(domain.EVENT_PAYMENT_STATUS_CHANGED, "slow", func(ctx context.Context, event domain.Event) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var err error
+	// one event is being handled, one waits in the queue, the third has no room
+	for i := 0; i < 3 && err == nil; i++ {
+		err = bus.Publish(ctx, domain.PaymentStatusChanged{})
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err = bus.Close(context.Background()); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestInMemoryBusDispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(ctx context.Context, event domain.Event) error
+		wantErr bool
+	}{
+		{name: "001_should_return_nil_when_handled", handler: func(ctx context.Context, event domain.Event) error { return nil }},
+		{name: "002_should_return_handler_error", handler: func(ctx context.Context, event domain.Event) error { return errors.New("failed") }, wantErr: true},
+		{name: "003_should_return_handler_panic_as_error", handler: func(ctx context.Context, event domain.Event) error { panic("boom") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewInMemoryBus(zap.NewNop().Sugar(), 1)
+			bus.Subscribe(domain.EVENT_PAYMENT_STATUS_CHANGED, "orders", tt.handler)
+			defer bus.Close(context.Background())
+
+			if err := bus.Dispatch(context.Background(), domain.PaymentStatusChanged{}); (err != nil) != tt.wantErr {
+				t.Errorf("Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
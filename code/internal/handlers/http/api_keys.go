@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+type APIKeysHttpHandler struct {
+	ctx       context.Context
+	apiKeysUC ports.APIKeysUseCase
+}
+
+func NewAPIKeysHttpHandler(ctx context.Context, apiKeysUC ports.APIKeysUseCase, ws *restful.WebService) *APIKeysHttpHandler {
+	handler := &APIKeysHttpHandler{
+		ctx:       ctx,
+		apiKeysUC: apiKeysUC,
+	}
+
+	tags := []string{"api-keys"}
+
+	ws.Route(ws.POST("/api-keys").To(handler.handleCreate).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Cria chave de API para um dispositivo, como totem, tela da cozinha ou gateway de pagamento. A chave só é exibida nesta resposta").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionAPIKey{}).
+		Returns(http.StatusOK, "Chave criada", APIKey{}).
+		Returns(http.StatusBadRequest, "Nome ou escopos inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.GET("/api-keys").To(handler.handleList).Produces(restful.MIME_JSON).
+		Doc("Lista as chaves de API, sem as chaves em si").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", []APIKey{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.DELETE("/api-keys/{id}").To(handler.handleRevoke).Produces(restful.MIME_JSON).
+		Doc("Revoga chave de API").
+		Param(ws.PathParameter("id", "ID da chave").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Chave revogada", nil).
+		Returns(http.StatusNotFound, "Chave não encontrada ou já revogada", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	return handler
+}
+
+func (aH *APIKeysHttpHandler) handleCreate(request *restful.Request, response *restful.Response) {
+	var in InsertionAPIKey
+	if err := readEntity(request, &in); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	scopes := make([]domain.Permission, 0, len(in.Scopes))
+	for _, s := range in.Scopes {
+		scopes = append(scopes, domain.Permission(s))
+	}
+
+	key, err := aH.apiKeysUC.CreateAPIKey(request.Request.Context(), in.Name, scopes)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out APIKey
+	out.fromDomain(key)
+	_ = response.WriteAsJson(out)
+}
+
+func (aH *APIKeysHttpHandler) handleList(request *restful.Request, response *restful.Response) {
+	keys, err := aH.apiKeysUC.ListAPIKeys(request.Request.Context())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	out := make([]APIKey, 0, len(keys))
+	for _, k := range keys {
+		var key APIKey
+		key.fromDomain(k)
+		out = append(out, key)
+	}
+	_ = response.WriteAsJson(out)
+}
+
+func (aH *APIKeysHttpHandler) handleRevoke(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = aH.apiKeysUC.RevokeAPIKey(request.Request.Context(), id); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
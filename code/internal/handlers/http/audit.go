@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+)
+
+type AuditHttpHandler struct {
+	ctx     context.Context
+	auditUC ports.AuditUseCase
+}
+
+func NewAuditHttpHandler(ctx context.Context, auditUC ports.AuditUseCase, ws *restful.WebService) *AuditHttpHandler {
+	handler := &AuditHttpHandler{
+		ctx:     ctx,
+		auditUC: auditUC,
+	}
+
+	tags := []string{"audit"}
+
+	ws.Route(ws.POST("/audit/all").To(handler.handleList).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Lista o log de auditoria das ações administrativas, da mais recente para a mais antiga. Filtros vazios são ignorados").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(AuditQuery{}).
+		Returns(http.StatusOK, "sucesso", AuditList{}).
+		Returns(http.StatusBadRequest, "Filtros inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	return handler
+}
+
+func (aH *AuditHttpHandler) handleList(request *restful.Request, response *restful.Response) {
+	var q AuditQuery
+	if err := readEntity(request, &q); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := q.toDomain()
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := aH.auditUC.ListAuditEntries(request.Request.Context(), filter, q.Limit, q.Offset)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	out := AuditList{
+		Entries: make([]AuditEntry, 0, len(list.Entries)),
+		Limit:   list.Limit,
+		Offset:  list.Offset,
+		Total:   list.Total,
+	}
+	for _, e := range list.Entries {
+		var entry AuditEntry
+		entry.fromDomain(e)
+		out.Entries = append(out.Entries, entry)
+	}
+	_ = response.WriteAsJson(out)
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/ratelimit"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+)
+
+const (
+	bearerPrefix = "Bearer "
+	apiKeyHeader = "X-API-Key"
+)
+
+// Requests to the login routes allowed in each authLimitWindow, by client IP and by CPF, so guessing
+// passwords and codes, or flooding a customer with emails, takes too long.
+const (
+	authLimitWindow      = 15 * time.Minute
+	authLimitPerIP       = 30
+	authLimitPerCPF      = 5
+	loginCodeLimitPerCPF = 3
+)
+
+type AuthHttpHandler struct {
+	ctx     context.Context
+	authUC  ports.AuthUseCase
+	limiter *ratelimit.Limiter
+}
+
+func NewAuthHttpHandler(ctx context.Context, authUC ports.AuthUseCase, ws *restful.WebService) *AuthHttpHandler {
+	handler := &AuthHttpHandler{
+		ctx:     ctx,
+		authUC:  authUC,
+		limiter: ratelimit.NewLimiter(authLimitWindow),
+	}
+
+	tags := []string{"auth"}
+
+	ws.Route(ws.POST("/auth/login").To(handler.handleLogin).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Autentica o cliente pelo CPF e senha, retornando o token de acesso").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(LoginRequest{}).
+		Returns(http.StatusOK, "Token de acesso", AuthToken{}).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "CPF ou senha inválidos", Problem{}).
+		Returns(http.StatusTooManyRequests, "Tentativas demais, aguarde o Retry-After", Problem{}).
+		Returns(http.StatusInternalServerError, "Erro ao autenticar", Problem{}))
+
+	ws.Route(ws.POST("/auth/code").To(handler.handleRequestLoginCode).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Envia um código de acesso de uso único para o email do cliente. A resposta é a mesma para CPFs não cadastrados").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(LoginCodeRequest{}).
+		Returns(http.StatusAccepted, "Código enviado, caso o CPF esteja cadastrado com email", nil).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusTooManyRequests, "Pedidos demais, aguarde o Retry-After", Problem{}))
+
+	ws.Route(ws.POST("/auth/code/verify").To(handler.handleVerifyLoginCode).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Autentica o cliente pelo código recebido por email, retornando o token de acesso. O código expira em 10 minutos e aceita até 5 tentativas").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(LoginCodeVerification{}).
+		Returns(http.StatusOK, "Token de acesso", AuthToken{}).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Código inválido, expirado ou tentativas esgotadas", Problem{}).
+		Returns(http.StatusTooManyRequests, "Tentativas demais, aguarde o Retry-After", Problem{}).
+		Returns(http.StatusInternalServerError, "Erro ao autenticar", Problem{}))
+
+	ws.Route(ws.POST("/auth/guest").To(handler.handleStartGuestSession).Produces(restful.MIME_JSON).
+		Doc("Inicia uma sessão de convidado no totem, para pedir sem cadastro. Ao se cadastrar com este token, os pedidos passam para a conta").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Token da sessão de convidado", AuthToken{}).
+		Returns(http.StatusInternalServerError, "Erro ao iniciar sessão", Problem{}))
+
+	ws.Route(ws.PUT("/auth/guest/document").To(handler.handleAttachGuestDocument).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Informa o CPF do convidado para a nota fiscal").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(GuestDocument{}).
+		Returns(http.StatusOK, "Sessão atualizada", GuestSession{}).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de convidado", Problem{}))
+
+	return handler
+}
+
+func (aH *AuthHttpHandler) handleLogin(request *restful.Request, response *restful.Response) {
+	var login LoginRequest
+	if err := readEntity(request, &login); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := aH.throttle(request, "login", login.Document, authLimitPerCPF); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	token, err := aH.authUC.Login(request.Request.Context(), login.Document, login.Password)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out AuthToken
+	out.fromDomain(token)
+	_ = response.WriteAsJson(out)
+}
+
+// throttle counts the request to the route by client IP and by CPF, refusing it over either limit. The IP is
+// the one connecting, X-Forwarded-For is not trusted since clients may send anything in it.
+func (aH *AuthHttpHandler) throttle(request *restful.Request, route, document string, perCPF int) error {
+	ip, _, err := net.SplitHostPort(request.Request.RemoteAddr)
+	if err != nil {
+		ip = request.Request.RemoteAddr
+	}
+	cpf := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, document)
+
+	if ok, retryAfter := aH.limiter.Allow(route+":ip:"+ip, authLimitPerIP); !ok {
+		return helpers.NewTooManyRequestsError(retryAfter)
+	}
+	if ok, retryAfter := aH.limiter.Allow(route+":cpf:"+cpf, perCPF); !ok {
+		return helpers.NewTooManyRequestsError(retryAfter)
+	}
+	return nil
+}
+
+func (aH *AuthHttpHandler) handleRequestLoginCode(request *restful.Request, response *restful.Response) {
+	var lC LoginCodeRequest
+	if err := readEntity(request, &lC); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := aH.throttle(request, "code", lC.Document, loginCodeLimitPerCPF); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	if err := aH.authUC.RequestLoginCode(request.Request.Context(), lC.Document); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusAccepted)
+}
+
+func (aH *AuthHttpHandler) handleVerifyLoginCode(request *restful.Request, response *restful.Response) {
+	var lC LoginCodeVerification
+	if err := readEntity(request, &lC); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := aH.throttle(request, "verify", lC.Document, authLimitPerCPF); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	token, err := aH.authUC.VerifyLoginCode(request.Request.Context(), lC.Document, lC.Code)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out AuthToken
+	out.fromDomain(token)
+	_ = response.WriteAsJson(out)
+}
+
+func (aH *AuthHttpHandler) handleStartGuestSession(request *restful.Request, response *restful.Response) {
+	token, err := aH.authUC.StartGuestSession(request.Request.Context())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out AuthToken
+	out.fromDomain(token)
+	_ = response.WriteAsJson(out)
+}
+
+func (aH *AuthHttpHandler) handleAttachGuestDocument(request *restful.Request, response *restful.Response) {
+	var gD GuestDocument
+	if err := readEntity(request, &gD); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := aH.authUC.AttachGuestDocument(request.Request.Context(), gD.Document)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out GuestSession
+	out.fromDomain(session)
+	_ = response.WriteAsJson(out)
+}
+
+// NewAuthFilter verifies the bearer token, or the API key of devices, and puts the caller in the request context.
+// Requests without either go through anonymously, the use cases decide what they may do.
+func NewAuthFilter(authUC ports.AuthUseCase, apiKeysUC ports.APIKeysUseCase) restful.FilterFunction {
+	return func(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+		header := request.HeaderParameter("Authorization")
+		key := request.HeaderParameter(apiKeyHeader)
+
+		var (
+			caller *domain.Caller
+			err    error
+		)
+		ctx := request.Request.Context()
+		switch {
+		case header == "" && key == "":
+			chain.ProcessFilter(request, response)
+			return
+		case header != "" && key != "":
+			writeProblem(response, http.StatusUnauthorized, fmt.Errorf("%w: send either a token or an api key", helpers.ErrUnauthorized))
+			return
+		case key != "":
+			caller, err = apiKeysUC.AuthenticateAPIKey(ctx, key)
+		case !strings.HasPrefix(header, bearerPrefix):
+			err = helpers.ErrUnauthorized
+		default:
+			caller, err = authUC.Authenticate(ctx, strings.TrimPrefix(header, bearerPrefix))
+		}
+		if err != nil {
+			writeProblem(response, http.StatusUnauthorized, err)
+			return
+		}
+
+		request.Request = request.Request.WithContext(domain.ContextWithCaller(ctx, *caller))
+		chain.ProcessFilter(request, response)
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const MIME_CSV = "text/csv"
+
+type CatalogHttpHandler struct {
+	ctx       context.Context
+	catalogUC ports.CatalogUseCase
+}
+
+func NewCatalogHttpHandler(ctx context.Context, catalogUC ports.CatalogUseCase, ws *restful.WebService) *CatalogHttpHandler {
+	handler := &CatalogHttpHandler{
+		ctx:       ctx,
+		catalogUC: catalogUC,
+	}
+
+	tags := []string{"catalog"}
+
+	ws.Route(ws.POST("/catalog/import").To(handler.handleImport).Consumes(MIME_CSV, restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Importa categorias e produtos em lote, atualizando produtos de mesmo nome na categoria").
+		Param(ws.QueryParameter("format", "Formato do arquivo, csv ou json. Padrão segue o Content-Type").DataType("string")).
+		Param(ws.QueryParameter("dry-run", "Executa a importação e a desfaz ao final, retornando o relatório sem gravar").DataType("boolean")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Relatório da importação por linha", CatalogImportReport{}).
+		Returns(http.StatusBadRequest, "Arquivo ou parâmetros inválidos", Problem{}).
+		Returns(http.StatusInternalServerError, "Erro ao importar catálogo", Problem{}))
+
+	ws.Route(ws.GET("/catalog/export").To(handler.handleExport).Produces(MIME_CSV, restful.MIME_JSON).
+		Doc("Exporta categorias e produtos no mesmo formato aceito pela importação").
+		Param(ws.QueryParameter("format", "Formato do arquivo, csv ou json").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Catálogo exportado", nil).
+		Returns(http.StatusBadRequest, "Formato desconhecido", Problem{}).
+		Returns(http.StatusInternalServerError, "Erro ao exportar catálogo", Problem{}))
+
+	return handler
+}
+
+func (cH *CatalogHttpHandler) handleImport(request *restful.Request, response *restful.Response) {
+	format := catalogFormat(request.QueryParameter("format"), request.HeaderParameter("Content-Type"))
+
+	var dryRun bool
+	if dryRunS := request.QueryParameter("dry-run"); dryRunS != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunS)
+		if err != nil {
+			writeProblem(response, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	report, err := cH.catalogUC.ImportCatalog(request.Request.Context(), format, request.Request.Body, dryRun)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out CatalogImportReport
+	out.fromDomain(report)
+	_ = response.WriteAsJson(out)
+}
+
+func (cH *CatalogHttpHandler) handleExport(request *restful.Request, response *restful.Response) {
+	format := catalogFormat(request.QueryParameter("format"), request.HeaderParameter("Accept"))
+
+	contentType := restful.MIME_JSON
+	switch format {
+	case domain.CATALOG_FORMAT_CSV:
+		contentType = MIME_CSV
+	case domain.CATALOG_FORMAT_JSON:
+	default:
+		writeProblem(response, http.StatusBadRequest, fmt.Errorf("%w: unknown catalog format %q", helpers.ErrBadRequest, format))
+		return
+	}
+
+	// exported in full before answering, so a failed export is answered as a problem and not as an attachment
+	var out bytes.Buffer
+	if err := cH.catalogUC.ExportCatalog(request.Request.Context(), format, &out); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.AddHeader("Content-Type", contentType)
+	response.AddHeader("Content-Disposition", "attachment; filename=catalog."+string(format))
+	_, _ = response.Write(out.Bytes())
+}
+
+// catalogFormat prefers the explicit format parameter, falling back to the given media type.
+func catalogFormat(param, mediaType string) domain.CatalogFormat {
+	if param != "" {
+		return domain.CatalogFormat(strings.ToLower(param))
+	}
+	if strings.Contains(mediaType, MIME_CSV) {
+		return domain.CATALOG_FORMAT_CSV
+	}
+	return domain.CATALOG_FORMAT_JSON
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// fakeCatalogUseCase exports a fixed body, or fails with err.
+type fakeCatalogUseCase struct {
+	ports.CatalogUseCase
+	err error
+}
+
+func (f fakeCatalogUseCase) ExportCatalog(_ context.Context, _ domain.CatalogFormat, out io.Writer) error {
+	if f.err != nil {
+		return f.err
+	}
+	_, err := io.WriteString(out, "category,name,description,price\n")
+	return err
+}
+
+func TestHandleExport(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		err            error
+		wantStatus     int
+		wantAttachment bool
+	}{
+		{name: "001_should_export_attachment", query: "?format=csv", wantStatus: http.StatusOK, wantAttachment: true},
+		{name: "002_should_refuse_unknown_format", query: "?format=xml", wantStatus: http.StatusBadRequest},
+		{name: "003_should_not_attach_denied_export", query: "?format=json", err: helpers.NewForbiddenError("catalog:export"), wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &CatalogHttpHandler{catalogUC: fakeCatalogUseCase{err: tt.err}}
+			recorder := httptest.NewRecorder()
+
+			handler.handleExport(restful.NewRequest(httptest.NewRequest("GET", "/v1/catalog/export"+tt.query, nil)), restful.NewResponse(recorder))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Content-Disposition") != ""; got != tt.wantAttachment {
+				t.Errorf("Content-Disposition = %q, want attachment %v", recorder.Header().Get("Content-Disposition"), tt.wantAttachment)
+			}
+		})
+	}
+}
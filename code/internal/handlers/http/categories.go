@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
@@ -37,19 +38,49 @@ func NewCategoriesHttpHandler(ctx context.Context, categoriesUseCase ports.Categ
 		Returns(200, "Categoria cadastrada", Category{}).
 		Returns(500, "Erro ao cadastrar categoria", nil))
 
+	ws.Route(ws.PUT("/categories").To(handler.handleUpdateCategory).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Renomeia ou altera a posição da categoria de produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(UpdateCategory{}). // from the request
+		Returns(200, "Categoria atualizada", Category{}).
+		Returns(400, "Dados inválidos", nil).
+		Returns(404, "Categoria não encontrada", nil).
+		Returns(409, "Nome já usado por outra categoria", nil).
+		Returns(500, "Erro ao atualizar categoria", nil))
+
+	ws.Route(ws.PUT("/categories/reorder").To(handler.handleReorderCategories).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Define a ordem de exibição das categorias").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ReorderCategories{}). // from the request
+		Returns(200, "Categorias reordenadas", nil).
+		Returns(400, "Lista de categorias inválida", nil).
+		Returns(404, "Categoria não encontrada", nil).
+		Returns(500, "Erro ao reordenar categorias", nil))
+
 	ws.Route(ws.DELETE("/categories").To(handler.handleDeleteCategory).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
-		Doc("Remove categoria de produto").
+		Doc("Remove categoria de produto. A policy define o destino dos produtos: refuse recusa se houver produtos, reassign os move para target_category_id e cascade os remove junto").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
-		Reads(QueryStruct{}). // from the request
+		Reads(DeleteCategory{}). // from the request
 		Returns(200, "Categoria removida", nil).
+		Returns(400, "Policy inválida", nil).
+		Returns(404, "Categoria não encontrada ou já removida", nil).
+		Returns(409, "Categoria ainda possui produtos ou categoria destino inválida", nil).
 		Returns(500, "Erro ao remover categoria", nil))
 
+	ws.Route(ws.PUT("/categories/restore").To(handler.handleRestoreCategory).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Restaura categoria de produto removida, junto com os produtos removidos com ela pela policy cascade").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(QueryStruct{}). // from the request
+		Returns(200, "Categoria restaurada", Category{}).
+		Returns(404, "Categoria não encontrada ou não removida", nil).
+		Returns(500, "Erro ao restaurar categoria", nil))
+
 	ws.Route(ws.POST("/categories/all").To(handler.handleListCategories).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Listagem de categorias").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(ListRequest{}).
 		Returns(http.StatusOK, "sucesso", CategoriesList{}).
-		Returns(http.StatusInternalServerError, "falha interna do servidor", nil))
+		Returns(http.StatusInternalServerError, "falha interna do servidor", Problem{}))
 	return handler
 }
 
@@ -58,13 +89,13 @@ func (cH *CategoriesHttpHandler) handleGetCategory(request *restful.Request, res
 
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := cH.categoriesUseCase.GetCategory(cH.ctx, uid)
+	result, err := cH.categoriesUseCase.GetCategory(request.Request.Context(), uid)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
@@ -76,78 +107,143 @@ func (cH *CategoriesHttpHandler) handleGetCategory(request *restful.Request, res
 func (cH *CategoriesHttpHandler) handleInsertCategory(request *restful.Request, response *restful.Response) {
 	var cat Category
 
-	if err := request.ReadEntity(&cat); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &cat); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	domainCat, err := cH.categoriesUseCase.InsertCategory(request.Request.Context(), cat.toDomain())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	cat.fromDomain(domainCat)
+	_ = response.WriteAsJson(cat)
+}
+
+func (cH *CategoriesHttpHandler) handleUpdateCategory(request *restful.Request, response *restful.Response) {
+	var uC UpdateCategory
+
+	if err := readEntity(request, &uC); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(cat.UserID)
+	cID, err := uuid.Parse(uC.ID)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
-	domainCat, err := cH.categoriesUseCase.InsertCategory(cH.ctx, uid, cat.toDomain())
+
+	result, err := cH.categoriesUseCase.UpdateCategory(request.Request.Context(), &domain.Category{ID: cID, Name: uC.Name, Position: uC.Position})
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
-	cat.fromDomain(domainCat)
+	var cat Category
+	cat.fromDomain(result)
 	_ = response.WriteAsJson(cat)
 }
 
-func (cH *CategoriesHttpHandler) handleDeleteCategory(request *restful.Request, response *restful.Response) {
-	var dS QueryStruct
+func (cH *CategoriesHttpHandler) handleReorderCategories(request *restful.Request, response *restful.Response) {
+	var rC ReorderCategories
 
-	if err := request.ReadEntity(&dS); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &rC); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(dS.UserID)
-	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	ids := make([]uuid.UUID, 0, len(rC.CategoryIDs))
+	for _, v := range rC.CategoryIDs {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeProblem(response, http.StatusBadRequest, err)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	if err := cH.categoriesUseCase.ReorderCategories(request.Request.Context(), ids); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func (cH *CategoriesHttpHandler) handleDeleteCategory(request *restful.Request, response *restful.Response) {
+	var dS DeleteCategory
+
+	if err := readEntity(request, &dS); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
+
 	cID, err := uuid.Parse(dS.ID)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = cH.categoriesUseCase.DeleteCategory(cH.ctx, uid, cID); err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+	var targetID uuid.UUID
+	if dS.TargetCategoryID != "" {
+		targetID, err = uuid.Parse(dS.TargetCategoryID)
+		if err != nil {
+			writeProblem(response, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	policy := domain.CategoryDeletePolicy(dS.Policy)
+	if err = cH.categoriesUseCase.DeleteCategory(request.Request.Context(), cID, policy, targetID); err != nil {
+		writeError(response, err)
 		return
 	}
 
 	response.WriteHeader(http.StatusOK)
 }
 
-func (cH *CategoriesHttpHandler) handleListCategories(request *restful.Request, response *restful.Response) {
-	var lR ListRequest
-	if err := request.ReadEntity(&lR); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+func (cH *CategoriesHttpHandler) handleRestoreCategory(request *restful.Request, response *restful.Response) {
+	var rS QueryStruct
+
+	if err := readEntity(request, &rS); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(lR.UserID)
+	cID, err := rS.parseToUuid()
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	list, err := cH.categoriesUseCase.ListCategories(cH.ctx, uid, lR.Limit, lR.Offset)
+	result, err := cH.categoriesUseCase.RestoreCategory(request.Request.Context(), cID)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
 
-	var cL CategoriesList
 	var cat Category
+	cat.fromDomain(result)
+	_ = response.WriteAsJson(cat)
+}
+
+func (cH *CategoriesHttpHandler) handleListCategories(request *restful.Request, response *restful.Response) {
+	var lR ListRequest
+	if err := readEntity(request, &lR); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
 
-	for _, c := range list.Categories {
-		cat.fromDomain(c)
-		cL.Categories = append(cL.Categories, cat)
+	list, err := cH.categoriesUseCase.ListCategories(request.Request.Context(), lR.Limit, lR.Offset, lR.IncludeDeleted)
+	if err != nil {
+		writeError(response, err)
+		return
 	}
-	cL.Total = list.Total
-	cL.Limit = list.Limit
-	cL.Offset = list.Offset
 
+	var cL CategoriesList
+	cL.fromDomain(list)
 	_ = response.WriteAsJson(cL)
 }
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+func NewCategoriesV2HttpHandler(ctx context.Context, categoriesUseCase ports.CategoriesUseCase, ws *restful.WebService) *CategoriesHttpHandler {
+	handler := &CategoriesHttpHandler{
+		ctx:               ctx,
+		categoriesUseCase: categoriesUseCase,
+	}
+
+	tags := []string{"categories"}
+	idParam := ws.PathParameter("id", "ID da categoria de produto").DataType("string")
+
+	ws.Route(ws.GET("/categories").To(handler.handleListCategoriesV2).Produces(restful.MIME_JSON).
+		Doc("Listagem de categorias").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("include_deleted", "Inclui categorias removidas, apenas para administradores").DataType("boolean")).
+		Param(limitParameter(ws)).
+		Param(offsetParameter(ws)).
+		Returns(http.StatusOK, "sucesso", CategoriesList{}).
+		Returns(http.StatusBadRequest, "Parâmetros inválidos", Problem{}))
+
+	ws.Route(ws.GET("/categories/{id}").To(handler.handleGetCategoryV2).Produces(restful.MIME_JSON).
+		Doc("Obtém informações sobre categoria de produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "OK", Category{}).
+		Returns(http.StatusNotFound, "Categoria não encontrada", Problem{}))
+
+	ws.Route(ws.POST("/categories").To(handler.handleInsertCategoryV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Cadastra categoria de produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionCategory{}).
+		Returns(http.StatusCreated, "Categoria cadastrada, em Location", Category{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}).
+		Returns(http.StatusConflict, "Nome já usado por outra categoria", Problem{}))
+
+	ws.Route(ws.PATCH("/categories/{id}").To(handler.handlePatchCategoryV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Renomeia ou altera a posição da categoria de produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Reads(PatchCategory{}).
+		Returns(http.StatusOK, "Categoria atualizada", Category{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}).
+		Returns(http.StatusNotFound, "Categoria não encontrada", Problem{}).
+		Returns(http.StatusConflict, "Nome já usado por outra categoria", Problem{}))
+
+	ws.Route(ws.PUT("/categories/order").To(handler.handleReorderCategories).Operation("handleReorderCategoriesV2").Consumes(restful.MIME_JSON).
+		Doc("Define a ordem de exibição das categorias").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ReorderCategories{}).
+		Returns(http.StatusOK, "Categorias reordenadas", nil).
+		Returns(http.StatusBadRequest, "Lista de categorias inválida", Problem{}).
+		Returns(http.StatusNotFound, "Categoria não encontrada", Problem{}))
+
+	ws.Route(ws.DELETE("/categories/{id}").To(handler.handleDeleteCategoryV2).
+		Doc("Remove categoria de produto. A policy define o destino dos produtos: refuse recusa se houver produtos, reassign os move para target_category_id e cascade os remove junto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ws.QueryParameter("policy", "O que fazer com os produtos da categoria").DataType("string").PossibleValues([]string{"refuse", "reassign", "cascade"}).DefaultValue("refuse")).
+		Param(ws.QueryParameter("target_category_id", "Categoria que recebe os produtos, obrigatória com policy reassign").DataType("string")).
+		Returns(http.StatusNoContent, "Categoria removida", nil).
+		Returns(http.StatusBadRequest, "Policy inválida", Problem{}).
+		Returns(http.StatusNotFound, "Categoria não encontrada ou já removida", Problem{}).
+		Returns(http.StatusConflict, "Categoria ainda possui produtos ou categoria destino inválida", Problem{}))
+
+	ws.Route(ws.POST("/categories/{id}/restore").To(handler.handleRestoreCategoryV2).Produces(restful.MIME_JSON).
+		Doc("Restaura categoria de produto removida, junto com os produtos removidos com ela pela policy cascade").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "Categoria restaurada", Category{}).
+		Returns(http.StatusNotFound, "Categoria não encontrada ou não removida", Problem{}))
+
+	return handler
+}
+
+func (cH *CategoriesHttpHandler) handleListCategoriesV2(request *restful.Request, response *restful.Response) {
+	limit, offset, err := listQuery(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	includeDeleted, err := queryBool(request, "include_deleted")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := cH.categoriesUseCase.ListCategories(request.Request.Context(), limit, offset, includeDeleted)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out CategoriesList
+	out.fromDomain(list)
+	_ = response.WriteAsJson(out)
+}
+
+func (cH *CategoriesHttpHandler) handleGetCategoryV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := cH.categoriesUseCase.GetCategory(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Category
+	out.fromDomain(category)
+	_ = response.WriteAsJson(out)
+}
+
+func (cH *CategoriesHttpHandler) handleInsertCategoryV2(request *restful.Request, response *restful.Response) {
+	var in Category
+	if err := readEntity(request, &in); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := cH.categoriesUseCase.InsertCategory(request.Request.Context(), in.toDomain())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Category
+	out.fromDomain(category)
+	writeCreated(request, response, category.ID, out)
+}
+
+func (cH *CategoriesHttpHandler) handlePatchCategoryV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var patch PatchCategory
+	if err = readEntity(request, &patch); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := cH.categoriesUseCase.UpdateCategory(request.Request.Context(), &domain.Category{ID: id, Name: patch.Name, Position: patch.Position})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Category
+	out.fromDomain(category)
+	_ = response.WriteAsJson(out)
+}
+
+func (cH *CategoriesHttpHandler) handleDeleteCategoryV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var targetID uuid.UUID
+	if target := request.QueryParameter("target_category_id"); target != "" {
+		if targetID, err = uuid.Parse(target); err != nil {
+			writeProblem(response, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	policy := domain.CategoryDeletePolicy(request.QueryParameter("policy"))
+	if err = cH.categoriesUseCase.DeleteCategory(request.Request.Context(), id, policy, targetID); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (cH *CategoriesHttpHandler) handleRestoreCategoryV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := cH.categoriesUseCase.RestoreCategory(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Category
+	out.fromDomain(category)
+	_ = response.WriteAsJson(out)
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	HEADER_ETAG     = "ETag"
+	HEADER_IF_MATCH = "If-Match"
+)
+
+// setETag tags the response with the version of the resource, to be sent back on If-Match when changing it.
+func setETag(response *restful.Response, version int) {
+	response.AddHeader(HEADER_ETAG, strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version the client is changing from If-Match, 0 when absent or "*".
+func ifMatchVersion(request *restful.Request) (int, error) {
+	return parseIfMatch(request.HeaderParameter(HEADER_IF_MATCH))
+}
+
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// versions are strong tags, a weak one can not be matched
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, fmt.Errorf("%w: %s must be an ETag such as \"1\"", helpers.ErrInvalidInput, HEADER_IF_MATCH)
+	}
+	return version, nil
+}
+
+// ifMatchParameter documents If-Match on the routes changing a versioned resource.
+func ifMatchParameter(ws *restful.WebService) *restful.Parameter {
+	return ws.HeaderParameter(HEADER_IF_MATCH, "ETag do recurso lido, a alteração é recusada se ele foi alterado desde então").DataType("string")
+}
+
+// versionErrorStatus answers 412 when the If-Match version is no longer saved, a change made in between
+// another one without If-Match is still a 409 conflict.
+func versionErrorStatus(version int, err error) int {
+	if version != 0 && errors.Is(err, helpers.ErrVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+	return errorStatus(err)
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{name: "001_should_skip_check_when_absent", header: "", want: 0},
+		{name: "002_should_skip_check_when_any", header: "*", want: 0},
+		{name: "003_should_read_quoted_version", header: `"3"`, want: 3},
+		{name: "004_should_refuse_unquoted_version", header: "3", wantErr: true},
+		{name: "005_should_refuse_weak_tag", header: `W/"3"`, wantErr: true},
+		{name: "006_should_refuse_version_below_one", header: `"0"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, helpers.ErrInvalidInput) {
+				t.Errorf("parseIfMatch() error = %v, want %v", err, helpers.ErrInvalidInput)
+			}
+			if got != tt.want {
+				t.Errorf("parseIfMatch() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
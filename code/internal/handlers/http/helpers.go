@@ -4,19 +4,13 @@ import (
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"strings"
 	"time"
 )
 
-func (q *QueryStruct) parseToUuid() (uuid.UUID, uuid.UUID, error) {
-	id, err := uuid.Parse(q.ID)
-	if err != nil {
-		return uuid.Nil, uuid.Nil, err
-	}
-	uid, err := uuid.Parse(q.UserID)
-	if err != nil {
-		return uuid.Nil, uuid.Nil, err
-	}
-	return id, uid, err
+func (q *QueryStruct) parseToUuid() (uuid.UUID, error) {
+	return uuid.Parse(q.ID)
 }
 
 func (c *Category) toDomain() *domain.Category {
@@ -39,8 +33,25 @@ func (c *Category) fromDomain(cat *domain.Category) {
 
 	c.ID = cat.ID.String()
 	c.Name = cat.Name
+	c.Position = cat.Position
 	c.CreatedAt = cat.CreatedAt
 	c.UpdatedAt = cat.UpdatedAt
+	if !cat.DeletedAt.IsZero() {
+		c.DeletedAt = cat.DeletedAt.Format(time.RFC3339)
+	} else {
+		c.DeletedAt = ""
+	}
+}
+
+func (cL *CategoriesList) fromDomain(list *domain.CategoryList) {
+	for _, c := range list.Categories {
+		var cat Category
+		cat.fromDomain(c)
+		cL.Categories = append(cL.Categories, cat)
+	}
+	cL.Total = list.Total
+	cL.Limit = list.Limit
+	cL.Offset = list.Offset
 }
 
 func (o *Order) fromDomain(order *domain.Order) {
@@ -49,6 +60,9 @@ func (o *Order) fromDomain(order *domain.Order) {
 	}
 
 	o.ID = order.ID
+	if order.GuestSessionID != uuid.Nil {
+		o.GuestSessionID = order.GuestSessionID.String()
+	}
 
 	var p string
 	if !order.Price.IsZero() {
@@ -82,6 +96,25 @@ func (o *Order) fromDomain(order *domain.Order) {
 
 	orderStatus := new(OrderStatus)
 	o.Status = orderStatus.fromDomain(order.Status)
+	o.Version = order.Version
+}
+
+func (oL *OrderList) fromDomain(list *domain.OrderList) {
+	for _, v := range list.Orders {
+		var ord Order
+		ord.fromDomain(v)
+		oL.Orders = append(oL.Orders, ord)
+	}
+	oL.Total = list.Total
+	oL.NextCursor = encodeCursor(list.Next)
+	oL.Limit = list.Limit
+	oL.Offset = list.Offset
+}
+
+func (c *Checkout) fromDomain(order *domain.Order) {
+	c.Order.fromDomain(order)
+	c.PaymentInfo.Value = c.Order.Price
+	c.PaymentInfo.PaymentID = c.Order.PaymentID
 }
 
 func (oS *OrderStatus) fromDomain(status domain.OrderStatus) OrderStatus {
@@ -114,6 +147,8 @@ func stringToDomainStatus(status string) domain.OrderStatus {
 		return domain.ORDER_STATUS_DONE
 	case ORDER_STATUS_FINISHED:
 		return domain.ORDER_STATUS_FINISHED
+	case ORDER_STATUS_CANCELED:
+		return domain.ORDER_STATUS_CANCELED
 	}
 	return domain.ORDER_STATUS_UNSET
 }
@@ -193,7 +228,7 @@ func (p *Product) fromDomain(product *domain.Product) {
 		p.UpdatedAt = ""
 	}
 	if !product.DeletedAt.IsZero() {
-		p.DeletedAt = product.UpdatedAt.Format(time.RFC3339)
+		p.DeletedAt = product.DeletedAt.Format(time.RFC3339)
 	} else {
 		p.DeletedAt = ""
 	}
@@ -202,6 +237,80 @@ func (p *Product) fromDomain(product *domain.Product) {
 	p.Description = product.Description
 	p.CategoryID = product.CategoryID.String()
 	p.Price = price
+
+	p.Nutrition = nil
+	if product.Nutrition != nil {
+		p.Nutrition = &NutritionFacts{}
+		p.Nutrition.fromDomain(product.Nutrition)
+	}
+	p.Allergens = allergensFromDomain(product.Allergens)
+	p.Version = product.Version
+}
+
+func (pL *ProductList) fromDomain(list *domain.ProductList) {
+	for _, v := range list.Products {
+		var prod Product
+		prod.fromDomain(v)
+		pL.Products = append(pL.Products, prod)
+	}
+	pL.Total = list.Total
+	pL.NextCursor = encodeCursor(list.Next)
+	pL.Limit = list.Limit
+	pL.Offset = list.Offset
+}
+
+func (n *NutritionFacts) fromDomain(in *domain.NutritionFacts) {
+	n.ServingSizeGrams = in.ServingSizeGrams.InexactFloat64()
+	n.Calories = in.Calories.InexactFloat64()
+	n.CarbohydratesGrams = in.CarbohydratesGrams.InexactFloat64()
+	n.SugarsGrams = in.SugarsGrams.InexactFloat64()
+	n.ProteinsGrams = in.ProteinsGrams.InexactFloat64()
+	n.FatsGrams = in.FatsGrams.InexactFloat64()
+	n.SaturatedFatsGrams = in.SaturatedFatsGrams.InexactFloat64()
+	n.FibersGrams = in.FibersGrams.InexactFloat64()
+	n.SodiumMilligrams = in.SodiumMilligrams.InexactFloat64()
+}
+
+func (n *NutritionFacts) toDomain() *domain.NutritionFacts {
+	if n == nil {
+		return nil
+	}
+
+	return &domain.NutritionFacts{
+		ServingSizeGrams:   decimal.NewFromFloat(n.ServingSizeGrams),
+		Calories:           decimal.NewFromFloat(n.Calories),
+		CarbohydratesGrams: decimal.NewFromFloat(n.CarbohydratesGrams),
+		SugarsGrams:        decimal.NewFromFloat(n.SugarsGrams),
+		ProteinsGrams:      decimal.NewFromFloat(n.ProteinsGrams),
+		FatsGrams:          decimal.NewFromFloat(n.FatsGrams),
+		SaturatedFatsGrams: decimal.NewFromFloat(n.SaturatedFatsGrams),
+		FibersGrams:        decimal.NewFromFloat(n.FibersGrams),
+		SodiumMilligrams:   decimal.NewFromFloat(n.SodiumMilligrams),
+	}
+}
+
+func allergensFromDomain(allergens []domain.Allergen) []string {
+	if len(allergens) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, len(allergens))
+	for _, a := range allergens {
+		out = append(out, string(a))
+	}
+	return out
+}
+
+func allergensToDomain(allergens []string) []domain.Allergen {
+	if len(allergens) == 0 {
+		return nil
+	}
+
+	out := make([]domain.Allergen, 0, len(allergens))
+	for _, a := range allergens {
+		out = append(out, domain.Allergen(strings.ToLower(strings.TrimSpace(a))))
+	}
+	return out
 }
 
 func (p *Product) toDomain() *domain.Product {
@@ -223,7 +332,11 @@ func (iP *InsertionProduct) toDomain() *domain.Product {
 		panic("empty product")
 	}
 
-	return domain.NewProduct(uuid.New(), helpers.SafeUUIDFromString(iP.CategoryID), iP.Name, iP.Description, iP.Price)
+	product := domain.NewProduct(uuid.New(), helpers.SafeUUIDFromString(iP.CategoryID), iP.Name, iP.Description, iP.Price)
+	product.Nutrition = iP.Nutrition.toDomain()
+	product.Allergens = allergensToDomain(iP.Allergens)
+
+	return product
 }
 
 func (uP *UpdateProduct) toDomain() *domain.Product {
@@ -231,13 +344,70 @@ func (uP *UpdateProduct) toDomain() *domain.Product {
 		panic("empty product")
 	}
 
-	return domain.ParseProductToDomain(
+	product := domain.ParseProductToDomain(
 		helpers.SafeUUIDFromString(uP.ID),
 		helpers.SafeUUIDFromString(uP.CategoryID),
 		uP.Name,
 		uP.Description,
 		uP.Price,
 	)
+	if product == nil {
+		return nil
+	}
+	product.Nutrition = uP.Nutrition.toDomain()
+	product.Allergens = allergensToDomain(uP.Allergens)
+
+	return product
+}
+
+// applyTo changes on the product the fields present in the patch.
+func (pP *PatchProduct) applyTo(product *domain.Product) error {
+	if pP.Name != nil {
+		product.Name = *pP.Name
+	}
+	if pP.Description != nil {
+		product.Description = *pP.Description
+	}
+	if pP.CategoryID != nil {
+		categoryID, err := uuid.Parse(*pP.CategoryID)
+		if err != nil {
+			return helpers.NewValidationError("category_id", "must be an UUID")
+		}
+		product.CategoryID = categoryID
+	}
+	if pP.Price != nil {
+		price, err := helpers.ParseDecimalFromString(*pP.Price)
+		if err != nil {
+			return helpers.NewValidationError("price", "must be a decimal number")
+		}
+		product.Price = price
+	}
+	if pP.Nutrition != nil {
+		product.Nutrition = pP.Nutrition.toDomain()
+	}
+	if pP.Allergens != nil {
+		product.Allergens = allergensToDomain(*pP.Allergens)
+	}
+	return nil
+}
+
+func (pp *ProductPrice) fromDomain(price *domain.ProductPrice) {
+	pp.ID = price.ID.String()
+	pp.ProductID = price.ProductID.String()
+	pp.Price = helpers.ParseDecimalToString(price.Price)
+	pp.EffectiveFrom = price.EffectiveFrom.Format(time.RFC3339)
+	pp.CreatedAt = price.CreatedAt.Format(time.RFC3339)
+
+	if !price.AppliedAt.IsZero() {
+		pp.AppliedAt = price.AppliedAt.Format(time.RFC3339)
+	} else {
+		pp.AppliedAt = ""
+	}
+	if price.CreatedBy != uuid.Nil {
+		pp.CreatedBy = price.CreatedBy.String()
+	} else {
+		pp.CreatedBy = ""
+	}
 }
 
 func productsToDomainProducts(products []uuid.UUID) []domain.Product {
@@ -257,8 +427,58 @@ func (u *User) fromDomain(user *domain.User) {
 
 	u.ID = user.ID.String()
 	u.Name = user.Name
-	u.Document = user.Document
+	u.Document = domain.FormatCPF(user.Document)
 	u.Email = user.Email
+	u.Password = ""
+	u.Consents = nil
+}
+
+func (uL *UserList) fromDomain(list *domain.UserList) {
+	for _, u := range list.Users {
+		var user User
+		user.fromDomain(u)
+		uL.Users = append(uL.Users, user)
+	}
+	uL.Total = list.Total
+	uL.Limit = list.Limit
+	uL.Offset = list.Offset
+}
+
+func (u *UserRoles) fromDomain(userID uuid.UUID, roles []domain.Role) {
+	if u == nil {
+		u = &UserRoles{}
+	}
+
+	u.UserID = userID.String()
+	u.Roles = make([]string, 0, len(roles))
+	for _, r := range roles {
+		u.Roles = append(u.Roles, string(r))
+	}
+}
+
+func (a *AuthToken) fromDomain(token *domain.AuthToken) {
+	if a == nil {
+		a = &AuthToken{}
+	}
+
+	a.Token = token.Token
+	if token.UserID != uuid.Nil {
+		a.UserID = token.UserID.String()
+	}
+	if token.GuestSessionID != uuid.Nil {
+		a.GuestSessionID = token.GuestSessionID.String()
+	}
+	a.ExpiresAt = token.ExpiresAt.Format(time.RFC3339)
+}
+
+func (g *GuestSession) fromDomain(session *domain.GuestSession) {
+	if g == nil {
+		g = &GuestSession{}
+	}
+
+	g.ID = session.ID.String()
+	g.Document = domain.FormatCPF(session.Document)
+	g.ExpiresAt = session.ExpiresAt.Format(time.RFC3339)
 }
 
 func (u *User) toDomain() *domain.User {
@@ -273,3 +493,231 @@ func (u *User) toDomain() *domain.User {
 		Email:    u.Email,
 	}
 }
+
+func (r *CatalogImportReport) fromDomain(report *domain.CatalogImportReport) {
+	r.DryRun = report.DryRun
+	r.CategoriesCreated = report.CategoriesCreated
+	r.ProductsCreated = report.ProductsCreated
+	r.ProductsUpdated = report.ProductsUpdated
+	r.Failed = report.Failed
+
+	r.Results = make([]CatalogImportResult, 0, len(report.Results))
+	for _, v := range report.Results {
+		r.Results = append(r.Results, CatalogImportResult{
+			Line:           v.Line,
+			Category:       v.Category,
+			Product:        v.Product,
+			CategoryAction: string(v.CategoryAction),
+			ProductAction:  string(v.ProductAction),
+			Error:          v.Error,
+		})
+	}
+}
+
+func (d *DataSubjectRequest) fromDomain(request *domain.DataSubjectRequest) {
+	d.ID = request.ID.String()
+	d.UserID = request.UserID.String()
+	d.Type = string(request.Type)
+	d.Status = string(request.Status)
+	d.RequestedBy = request.RequestedBy.String()
+	d.CreatedAt = request.CreatedAt.Format(time.RFC3339)
+	if !request.CompletedAt.IsZero() {
+		d.CompletedAt = request.CompletedAt.Format(time.RFC3339)
+	}
+	d.Error = request.Error
+}
+
+func (e *UserDataExport) fromDomain(export *domain.UserDataExport) {
+	e.GeneratedAt = export.GeneratedAt.Format(time.RFC3339)
+	e.User.fromDomain(export.User)
+
+	e.Roles = make([]string, 0, len(export.Roles))
+	for _, r := range export.Roles {
+		e.Roles = append(e.Roles, string(r))
+	}
+
+	e.Orders = make([]Order, 0, len(export.Orders))
+	for _, o := range export.Orders {
+		var order Order
+		order.fromDomain(o)
+		e.Orders = append(e.Orders, order)
+	}
+
+	e.Payments = make([]Payment, 0, len(export.Payments))
+	for _, p := range export.Payments {
+		var payment Payment
+		payment.fromDomain(p)
+		e.Payments = append(e.Payments, payment)
+	}
+
+	e.Consents = make([]Consent, 0, len(export.Consents))
+	for _, c := range export.Consents {
+		var consent Consent
+		consent.fromDomain(c)
+		e.Consents = append(e.Consents, consent)
+	}
+
+	e.Requests = make([]DataSubjectRequest, 0, len(export.Requests))
+	for _, r := range export.Requests {
+		var request DataSubjectRequest
+		request.fromDomain(r)
+		e.Requests = append(e.Requests, request)
+	}
+}
+
+func (c *ConsentRequest) toDomain() *domain.Consent {
+	return &domain.Consent{
+		Purpose: domain.ConsentPurpose(c.Purpose),
+		Granted: c.Granted,
+		Channel: domain.ConsentChannel(c.Channel),
+	}
+}
+
+func consentsToDomain(in []ConsentRequest) []*domain.Consent {
+	out := make([]*domain.Consent, 0, len(in))
+	for i := range in {
+		out = append(out, in[i].toDomain())
+	}
+	return out
+}
+
+func (c *Consent) fromDomain(consent *domain.Consent) {
+	c.ID = consent.ID.String()
+	c.Purpose = string(consent.Purpose)
+	c.Granted = consent.Granted
+	c.Channel = string(consent.Channel)
+	c.CreatedAt = consent.CreatedAt.Format(time.RFC3339)
+}
+
+func (c *ContactList) fromDomain(list *domain.ContactList) {
+	c.Contacts = make([]Contact, 0, len(list.Contacts))
+	for _, v := range list.Contacts {
+		c.Contacts = append(c.Contacts, Contact{UserID: v.UserID.String(), Name: v.Name, Email: v.Email})
+	}
+	c.Total = list.Total
+	c.Limit = list.Limit
+	c.Offset = list.Offset
+}
+
+func (a *APIKey) fromDomain(key *domain.APIKey) {
+	a.ID = key.ID.String()
+	a.Name = key.Name
+	a.Key = key.Key
+	a.Prefix = key.Prefix
+	a.CreatedBy = key.CreatedBy.String()
+	a.CreatedAt = key.CreatedAt.Format(time.RFC3339)
+	if !key.LastUsedAt.IsZero() {
+		a.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+	if key.IsRevoked() {
+		a.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+
+	a.Scopes = make([]string, 0, len(key.Scopes))
+	for _, s := range key.Scopes {
+		a.Scopes = append(a.Scopes, string(s))
+	}
+}
+
+// toDomain parses the filters of the query, empty ones match everything.
+func (q *AuditQuery) toDomain() (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Action:     domain.AuditAction(q.Action),
+		EntityType: domain.AuditEntityType(q.EntityType),
+		RequestID:  q.RequestID,
+	}
+
+	var err error
+	if q.ActorID != "" {
+		if filter.ActorID, err = uuid.Parse(q.ActorID); err != nil {
+			return filter, helpers.NewValidationError("actor_id", "must be a valid uuid")
+		}
+	}
+	if q.EntityID != "" {
+		if filter.EntityID, err = uuid.Parse(q.EntityID); err != nil {
+			return filter, helpers.NewValidationError("entity_id", "must be a valid uuid")
+		}
+	}
+	if q.From != "" {
+		if filter.From, err = time.Parse(time.RFC3339, q.From); err != nil {
+			return filter, helpers.NewValidationError("from", "must be a RFC 3339 date")
+		}
+	}
+	if q.To != "" {
+		if filter.To, err = time.Parse(time.RFC3339, q.To); err != nil {
+			return filter, helpers.NewValidationError("to", "must be a RFC 3339 date")
+		}
+	}
+
+	return filter, nil
+}
+
+func (a *AuditEntry) fromDomain(entry *domain.AuditEntry) {
+	a.ID = entry.ID.String()
+	a.CreatedAt = entry.CreatedAt.Format(time.RFC3339)
+	if entry.ActorUserID != uuid.Nil {
+		a.ActorUserID = entry.ActorUserID.String()
+	}
+	if entry.ActorAPIKeyID != uuid.Nil {
+		a.ActorAPIKeyID = entry.ActorAPIKeyID.String()
+	}
+	a.Action = string(entry.Action)
+	a.EntityType = string(entry.EntityType)
+	if entry.EntityID != uuid.Nil {
+		a.EntityID = entry.EntityID.String()
+	}
+	a.Before = entry.Before
+	a.After = entry.After
+	a.RequestID = entry.RequestID
+}
+
+func (w *Webhook) fromDomain(subscription *domain.WebhookSubscription) {
+	w.ID = subscription.ID.String()
+	w.URL = subscription.URL
+	w.Secret = subscription.Secret
+	w.CreatedBy = subscription.CreatedBy.String()
+	w.CreatedAt = subscription.CreatedAt.Format(time.RFC3339)
+
+	w.EventTypes = make([]string, 0, len(subscription.EventTypes))
+	for _, t := range subscription.EventTypes {
+		w.EventTypes = append(w.EventTypes, string(t))
+	}
+}
+
+// toDomain parses the filters of the query, empty ones match everything.
+func (q *WebhookDeliveryQuery) toDomain() (domain.WebhookDeliveryFilter, error) {
+	filter := domain.WebhookDeliveryFilter{Status: domain.WebhookDeliveryStatus(q.Status)}
+
+	var err error
+	if q.SubscriptionID != "" {
+		if filter.SubscriptionID, err = uuid.Parse(q.SubscriptionID); err != nil {
+			return filter, helpers.NewValidationError("subscription_id", "must be a valid uuid")
+		}
+	}
+	if q.EventID != "" {
+		if filter.EventID, err = uuid.Parse(q.EventID); err != nil {
+			return filter, helpers.NewValidationError("event_id", "must be a valid uuid")
+		}
+	}
+
+	return filter, nil
+}
+
+func (w *WebhookDelivery) fromDomain(delivery *domain.WebhookDelivery) {
+	w.ID = delivery.ID.String()
+	w.SubscriptionID = delivery.SubscriptionID.String()
+	w.EventID = delivery.Envelope.ID.String()
+	w.EventType = string(delivery.Envelope.Type)
+	w.Data = delivery.Envelope.Data
+	w.Status = string(delivery.Status)
+	w.Attempts = delivery.Attempts
+	if delivery.Status == domain.WEBHOOK_DELIVERY_PENDING {
+		w.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	w.LastError = delivery.LastError
+	w.LastStatusCode = delivery.LastStatusCode
+	w.CreatedAt = delivery.CreatedAt.Format(time.RFC3339)
+	if !delivery.DeliveredAt.IsZero() {
+		w.DeliveredAt = delivery.DeliveredAt.Format(time.RFC3339)
+	}
+}
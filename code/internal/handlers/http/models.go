@@ -1,6 +1,7 @@
 package http
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
@@ -8,31 +9,52 @@ import (
 // Multipurpose
 type (
 	QueryStruct struct {
-		ID     string `json:"id" description:"id do objeto a ser removido/consultado"`
-		UserID string `json:"user_id" description:"id do usuário requerente"`
+		ID string `json:"id" description:"id do objeto a ser removido/consultado" validate:"required,uuid"`
 	}
 
 	ListRequest struct {
-		UserID string `json:"user_id"`
-		Limit  int    `json:"limit" default:"10" description:"Quantidade de registros"`
-		Offset int    `json:"offset"`
+		Limit          int  `json:"limit" default:"10" description:"Quantidade de registros" validate:"min=0"`
+		Offset         int  `json:"offset" validate:"min=0"`
+		IncludeDeleted bool `json:"include_deleted,omitempty" description:"Inclui registros removidos, apenas para administradores"`
 	}
 )
 
 // Categories' models
 type (
 	InsertionCategory struct {
-		Name   string `json:"name" description:"Nome da categoria de produto"`
-		UserID string `json:"user_id,omitempty" description:"ID do usuario criando categoria"`
+		Name string `json:"name" description:"Nome da categoria de produto" validate:"required"`
 	}
 
 	Category struct {
 		ID        string    `json:"id" description:"ID da categoria de produto"`
 		CreatedAt time.Time `json:"created_at" description:"Epoch time em que categoria foi criada"`
 		UpdatedAt time.Time `json:"updated_at" description:"Epoch time em que categoria foi modificada"`
+		DeletedAt string    `json:"deleted_at,omitempty" readOnly:"true" description:"Data de remoção da categoria"`
+		Position  int       `json:"position" description:"Posição de exibição da categoria no cardápio"`
 		InsertionCategory
 	}
 
+	UpdateCategory struct {
+		ID       string `json:"id" description:"ID da categoria de produto" validate:"required,uuid"`
+		Name     string `json:"name,omitempty" description:"Novo nome da categoria, vazio mantém o atual"`
+		Position int    `json:"position,omitempty" description:"Nova posição de exibição, zero mantém a atual" validate:"min=0"`
+	}
+
+	PatchCategory struct {
+		Name     string `json:"name,omitempty" description:"Novo nome da categoria, vazio mantém o atual"`
+		Position int    `json:"position,omitempty" description:"Nova posição de exibição, zero mantém a atual" validate:"min=0"`
+	}
+
+	ReorderCategories struct {
+		CategoryIDs []string `json:"category_ids" description:"IDs das categorias na ordem de exibição desejada. As omitidas vão para o final" validate:"required,uuid"`
+	}
+
+	DeleteCategory struct {
+		QueryStruct
+		Policy           string `json:"policy,omitempty" description:"O que fazer com os produtos da categoria" enum:"refuse|reassign|cascade" default:"refuse" validate:"enum=category_delete_policy"`
+		TargetCategoryID string `json:"target_category_id,omitempty" description:"Categoria que recebe os produtos, obrigatória com policy reassign" validate:"uuid"`
+	}
+
 	CategoriesList struct {
 		Categories []Category `json:"categories"`
 		Limit      int        `json:"limit" default:"10"`
@@ -56,55 +78,64 @@ type (
 	OrderStatus string
 
 	Order struct {
-		ID        uuid.UUID   `json:"id" description:"ID do Pedido"`
-		PaymentID string      `json:"payment_id,omitempty" description:"ID do pagamento"`
-		CreatedAt string      `json:"created_at" description:"Data de criação"`
-		UpdatedAt string      `json:"updated_at,omitempty" description:"Data de atualização"`
-		DeletedAt string      `json:"deleted_at,omitempty" description:"Data de deleção"`
-		Price     string      `json:"price" description:"Preço do pedido"`
-		Status    OrderStatus `json:"status" description:"Status do pedido"`
-		Products  []Product   `json:"products" description:"Lista de Pedidos"`
+		ID             uuid.UUID   `json:"id" description:"ID do Pedido"`
+		GuestSessionID string      `json:"guest_session_id,omitempty" description:"ID da sessão de convidado que fez o pedido"`
+		PaymentID      string      `json:"payment_id,omitempty" description:"ID do pagamento"`
+		CreatedAt      string      `json:"created_at" description:"Data de criação"`
+		UpdatedAt      string      `json:"updated_at,omitempty" description:"Data de atualização"`
+		DeletedAt      string      `json:"deleted_at,omitempty" description:"Data de deleção"`
+		Price          string      `json:"price" description:"Preço do pedido"`
+		Status         OrderStatus `json:"status" description:"Status do pedido"`
+		Products       []Product   `json:"products" description:"Lista de Pedidos"`
+		Version        int         `json:"version" description:"Versão do pedido, a mesma do ETag"`
 	}
 
 	InsertionOrder struct {
-		UserID      string      `json:"user_id" description:"ID do dono do pedido"`
-		ProductsIDs []uuid.UUID `json:"products_ids" description:"ID dos produtos"`
+		ProductsIDs []uuid.UUID `json:"products_ids" description:"ID dos produtos" validate:"required"`
 	}
 
 	InsertionOrderSwagger struct {
-		UserID      string   `json:"user_id" description:"ID do dono do pedido"`
 		ProductsIDs []string `json:"products_ids" description:"Lista de ID dos produtos separados por vírgula"`
 	}
 
 	UpdateOrder struct {
-		ID string `json:"id" description:"ID do Pedido"`
+		ID string `json:"id" description:"ID do Pedido" validate:"required,uuid"`
 		InsertionOrder
 	}
 
 	OrderList struct {
-		Orders []Order `json:"orders"`
-		Limit  int     `json:"limit" default:"10"`
-		Offset int     `json:"offset"`
-		Total  int64   `json:"total"`
+		Orders     []Order `json:"orders"`
+		Limit      int     `json:"limit" default:"10"`
+		Offset     int     `json:"offset"`
+		Total      *int64  `json:"total,omitempty" description:"Ausente em páginas por cursor sem total"`
+		NextCursor string  `json:"next_cursor,omitempty" description:"Cursor da próxima página, ausente na última"`
+	}
+
+	OrderListRequest struct {
+		ListRequest
+		Cursor    *string `json:"cursor,omitempty" description:"Pagina por cursor em vez de offset: vazio para a primeira página, depois o next_cursor da anterior"`
+		Total     bool    `json:"total,omitempty" description:"Conta o total também na paginação por cursor"`
 	}
 
 	OrderCheckoutRequest struct {
-		UserID  string `json:"user_id"`
-		OrderID string `json:"order_id" description:"ID do Pedido"`
+		OrderID string `json:"order_id" description:"ID do Pedido" validate:"required,uuid"`
+	}
+
+	PatchOrder struct {
+		Status string `json:"status" description:"Status para qual deseja mudar o pedido" enum:"Recebido|Preparacao|Pronto|Finalizado|Cancelado" validate:"required,enum=order_status"`
 	}
 
 	OrderStatusUpdate struct {
-		OrderID string `json:"order_id" description:"Código de identificação do pedido"`
-		UserID  string `json:"user_id" description:"Código de descrição do usuário requerente"`
-		Status  string `json:"status" description:"Status para qual deseja mudar o pedido" enum:"Recebido|Preparacao|Pronto|Finalizado|Cancelado"`
+		OrderID string `json:"order_id" description:"Código de identificação do pedido" validate:"required,uuid"`
+		Status  string `json:"status" description:"Status para qual deseja mudar o pedido" enum:"Recebido|Preparacao|Pronto|Finalizado|Cancelado" validate:"required,enum=order_status"`
 	}
 )
 
 // Payments' models
 type (
 	PaymentNotification struct {
-		PaymentID string `json:"payment_id" description:"ID do pagamento"`
-		OrderID   string `json:"order_id" description:"ID do pedido a ser pago"`
+		PaymentID string `json:"payment_id" description:"ID do pagamento" validate:"required,uuid"`
+		OrderID   string `json:"order_id" description:"ID do pedido a ser pago" validate:"uuid"`
 		Approved  bool   `json:"approved" description:"True para aprovado false para recusado"`
 	}
 
@@ -128,35 +159,118 @@ type (
 		CreatedAt string `json:"created_at,omitempty" readOnly:"true"`
 		UpdatedAt string `json:"updated_at,omitempty" readOnly:"true"`
 		DeletedAt string `json:"deleted_at,omitempty" readOnly:"true"`
+		Version   int    `json:"version,omitempty" readOnly:"true" description:"Versão do produto, a mesma do ETag"`
 	}
 
 	InsertionProduct struct {
-		UserID      string `json:"user_id,omitempty"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		CategoryID  string `json:"category_id"`
-		Price       string `json:"price"`
+		Name        string          `json:"name" validate:"required"`
+		Description string          `json:"description"`
+		CategoryID  string          `json:"category_id" validate:"required,uuid"`
+		Price       string          `json:"price" validate:"required,price"`
+		Nutrition   *NutritionFacts `json:"nutrition,omitempty" description:"Informação nutricional por porção"`
+		Allergens   []string        `json:"allergens,omitempty" description:"Alérgenos presentes no produto" enum:"gluten|lactose|milk|eggs|peanuts|tree_nuts|soy|fish|crustaceans|sesame" validate:"enum=allergen"`
+	}
+
+	NutritionFacts struct {
+		ServingSizeGrams   float64 `json:"serving_size_g" description:"Tamanho da porção em gramas"`
+		Calories           float64 `json:"calories_kcal" description:"Valor energético em kcal"`
+		CarbohydratesGrams float64 `json:"carbohydrates_g" description:"Carboidratos em gramas"`
+		SugarsGrams        float64 `json:"sugars_g" description:"Açúcares em gramas"`
+		ProteinsGrams      float64 `json:"proteins_g" description:"Proteínas em gramas"`
+		FatsGrams          float64 `json:"fats_g" description:"Gorduras totais em gramas"`
+		SaturatedFatsGrams float64 `json:"saturated_fats_g" description:"Gorduras saturadas em gramas"`
+		FibersGrams        float64 `json:"fibers_g" description:"Fibras em gramas"`
+		SodiumMilligrams   float64 `json:"sodium_mg" description:"Sódio em miligramas"`
 	}
 
 	UpdateProduct struct {
 		InsertionProduct
-		ID string `json:"id,omitempty"`
+		ID string `json:"id,omitempty" validate:"required,uuid"`
+	}
+
+	PatchProduct struct {
+		Name        *string         `json:"name,omitempty" description:"Novo nome, ausente mantém o atual" validate:"required"`
+		Description *string         `json:"description,omitempty" description:"Nova descrição, ausente mantém a atual"`
+		CategoryID  *string         `json:"category_id,omitempty" description:"Nova categoria, ausente mantém a atual" validate:"required,uuid"`
+		Price       *string         `json:"price,omitempty" description:"Novo preço em R$, ausente mantém o atual" validate:"required,price"`
+		Nutrition   *NutritionFacts `json:"nutrition,omitempty" description:"Nova informação nutricional por porção, ausente mantém a atual"`
+		Allergens   *[]string       `json:"allergens,omitempty" description:"Novos alérgenos, ausente mantém os atuais" enum:"gluten|lactose|milk|eggs|peanuts|tree_nuts|soy|fish|crustaceans|sesame" validate:"enum=allergen"`
 	}
 
 	ProductList struct {
-		Products []Product `json:"products"`
-		Limit    int       `json:"limit" default:"10"`
-		Offset   int       `json:"offset"`
-		Total    int64     `json:"total"`
+		Products   []Product `json:"products"`
+		Limit      int       `json:"limit" default:"10"`
+		Offset     int       `json:"offset"`
+		Total      *int64    `json:"total,omitempty" description:"Ausente em páginas por cursor sem total"`
+		NextCursor string    `json:"next_cursor,omitempty" description:"Cursor da próxima página, ausente na última"`
+	}
+
+	ProductPrice struct {
+		ID            string `json:"id" description:"ID da entrada do histórico"`
+		ProductID     string `json:"product_id" description:"ID do produto"`
+		Price         string `json:"price" description:"Preço em R$"`
+		EffectiveFrom string `json:"effective_from" description:"Data a partir da qual o preço vale"`
+		AppliedAt     string `json:"applied_at,omitempty" description:"Data em que o preço foi aplicado ao produto, vazio se agendado"`
+		CreatedAt     string `json:"created_at" description:"Data de registro"`
+		CreatedBy     string `json:"created_by,omitempty" description:"ID do administrador responsável"`
+	}
+
+	ProductPriceHistory struct {
+		ProductID string         `json:"product_id" description:"ID do produto"`
+		Prices    []ProductPrice `json:"prices" description:"Preços do mais recente ao mais antigo"`
+	}
+
+	ScheduleProductPrice struct {
+		Price         string `json:"price" description:"Novo preço em R$" validate:"required,price"`
+		EffectiveFrom string `json:"effective_from" description:"Data futura, em RFC3339, em que o preço passa a valer" validate:"required,rfc3339"`
 	}
 )
 
 //Users' Models
 type (
 	InsertionUser struct {
-		Document string `json:"document" description:"CPF do cliente"`
-		Name     string `json:"name" description:"Nome do cliente"`
-		Email    string `json:"email" description:"Email do cliente"`
+		Document string           `json:"document" description:"CPF do cliente, com ou sem pontuação" validate:"required"`
+		Name     string           `json:"name" description:"Nome do cliente" validate:"required"`
+		Email    string           `json:"email" description:"Email do cliente" validate:"email"`
+		Password string           `json:"password,omitempty" description:"Senha opcional. Sem ela o cliente se identifica apenas pelo CPF"`
+		Consents []ConsentRequest `json:"consents,omitempty" description:"Consentimentos para comunicações de marketing"`
+	}
+
+	ConsentRequest struct {
+		Purpose string `json:"purpose" description:"Finalidade do consentimento" enum:"email_promotions|sms_promotions|personalized_offers" validate:"required,enum=consent_purpose"`
+		Granted bool   `json:"granted" description:"Concede ou revoga a finalidade"`
+		Channel string `json:"channel" description:"Canal em que o cliente deu ou revogou o consentimento" enum:"kiosk|web|app|counter" validate:"required,enum=consent_channel"`
+	}
+
+	ConsentsRequest struct {
+		Consents []ConsentRequest `json:"consents" validate:"required"`
+	}
+
+	Consent struct {
+		ID        string `json:"id" description:"ID do registro de consentimento"`
+		Purpose   string `json:"purpose" description:"Finalidade do consentimento"`
+		Granted   bool   `json:"granted" description:"Se a finalidade foi concedida ou revogada"`
+		Channel   string `json:"channel" description:"Canal em que o cliente deu ou revogou o consentimento"`
+		CreatedAt string `json:"created_at" description:"Data do registro"`
+	}
+
+	ContactsRequest struct {
+		Purpose string `json:"purpose" description:"Finalidade do contato" enum:"email_promotions|sms_promotions|personalized_offers" validate:"required,enum=consent_purpose"`
+		Limit   int    `json:"limit" default:"10" description:"Quantidade de registros" validate:"min=0"`
+		Offset  int    `json:"offset" validate:"min=0"`
+	}
+
+	Contact struct {
+		UserID string `json:"user_id" description:"ID do cliente"`
+		Name   string `json:"name" description:"Nome do cliente"`
+		Email  string `json:"email" description:"Email do cliente"`
+	}
+
+	ContactList struct {
+		Contacts []Contact `json:"contacts"`
+		Limit    int       `json:"limit" default:"10"`
+		Offset   int       `json:"offset"`
+		Total    int64     `json:"total"`
 	}
 
 	ValidatedUser struct {
@@ -169,7 +283,117 @@ type (
 	}
 
 	QueryUser struct {
-		Document string `json:"document"`
+		Document string `json:"document" validate:"required"`
+	}
+
+	UpdateUser struct {
+		Name  string `json:"name" description:"Nome do cliente" validate:"required"`
+		Email string `json:"email" description:"Email do cliente" validate:"required,email"`
+	}
+
+	PatchUser struct {
+		Name  *string `json:"name,omitempty" description:"Novo nome, ausente mantém o atual" validate:"required"`
+		Email *string `json:"email,omitempty" description:"Novo email, ausente mantém o atual" validate:"required,email"`
+	}
+
+	UserListRequest struct {
+		Limit  int    `json:"limit" default:"10" description:"Quantidade de registros" validate:"min=0"`
+		Offset int    `json:"offset" validate:"min=0"`
+		Search string `json:"search,omitempty" description:"Busca por nome, email ou CPF"`
+	}
+
+	UserList struct {
+		Users  []User `json:"users"`
+		Limit  int    `json:"limit" default:"10"`
+		Offset int    `json:"offset"`
+		Total  int64  `json:"total"`
+	}
+
+	RoleRequest struct {
+		Role string `json:"role" description:"Papel a conceder" enum:"customer|cashier|kitchen|manager|admin" validate:"required,enum=role"`
+	}
+
+	UserRoles struct {
+		UserID string   `json:"user_id" description:"ID do usuário"`
+		Roles  []string `json:"roles" description:"Papéis do usuário"`
+	}
+)
+
+// API keys' models
+type (
+	InsertionAPIKey struct {
+		Name   string   `json:"name" description:"Nome do dispositivo" validate:"required"`
+		Scopes []string `json:"scopes" description:"Permissões da chave, como orders:read_all e orders:update_status para a tela da cozinha" validate:"required,enum=permission"`
+	}
+
+	APIKey struct {
+		ID         string   `json:"id" description:"ID da chave"`
+		Name       string   `json:"name" description:"Nome do dispositivo"`
+		Key        string   `json:"key,omitempty" description:"Chave a ser enviada no cabeçalho X-API-Key, exibida apenas na criação"`
+		Prefix     string   `json:"prefix" description:"Início da chave, para identificá-la"`
+		Scopes     []string `json:"scopes" description:"Permissões da chave"`
+		CreatedBy  string   `json:"created_by" description:"ID do usuário que criou a chave"`
+		CreatedAt  string   `json:"created_at" description:"Data de criação"`
+		LastUsedAt string   `json:"last_used_at,omitempty" description:"Último uso da chave, registrado a cada minuto"`
+		RevokedAt  string   `json:"revoked_at,omitempty" description:"Data de revogação"`
+	}
+)
+
+// Privacy models
+type (
+	DataSubjectRequest struct {
+		ID          string `json:"id" description:"ID da solicitação"`
+		UserID      string `json:"user_id" description:"ID do titular dos dados"`
+		Type        string `json:"type" description:"Tipo da solicitação" enum:"export|erasure"`
+		Status      string `json:"status" description:"Situação da solicitação" enum:"pending|completed|failed"`
+		RequestedBy string `json:"requested_by" description:"ID do usuário que fez a solicitação"`
+		CreatedAt   string `json:"created_at" description:"Data da solicitação"`
+		CompletedAt string `json:"completed_at,omitempty" description:"Data de conclusão"`
+		Error       string `json:"error,omitempty" description:"Motivo da falha"`
+	}
+
+	UserDataExport struct {
+		GeneratedAt string               `json:"generated_at" description:"Data de geração do arquivo"`
+		User        User                 `json:"user" description:"Dados cadastrais"`
+		Roles       []string             `json:"roles" description:"Papéis do usuário"`
+		Orders      []Order              `json:"orders" description:"Pedidos do usuário"`
+		Payments    []Payment            `json:"payments" description:"Pagamentos dos pedidos"`
+		Consents    []Consent            `json:"consents" description:"Histórico de consentimentos"`
+		Requests    []DataSubjectRequest `json:"requests" description:"Solicitações de dados pessoais do usuário"`
+	}
+)
+
+// Auth models
+type (
+	LoginRequest struct {
+		Document string `json:"document" description:"CPF do cliente" validate:"required"`
+		Password string `json:"password" description:"Senha. Usuários cadastrados sem senha entram com código por email, em /auth/code" validate:"required"`
+	}
+
+	AuthToken struct {
+		Token          string `json:"token" description:"Token a ser enviado no cabeçalho Authorization: Bearer <token>"`
+		UserID         string `json:"user_id,omitempty" description:"ID do usuário autenticado"`
+		GuestSessionID string `json:"guest_session_id,omitempty" description:"ID da sessão de convidado, para pedidos sem cadastro"`
+		ExpiresAt      string `json:"expires_at" description:"Data de expiração do token"`
+	}
+
+	LoginCodeRequest struct {
+		Document string `json:"document" description:"CPF do cliente" validate:"required"`
+	}
+
+	LoginCodeVerification struct {
+		Document string `json:"document" description:"CPF do cliente" validate:"required"`
+		Code     string `json:"code" description:"Código de 6 dígitos recebido por email" validate:"required"`
+	}
+
+	GuestDocument struct {
+		Document string `json:"document" description:"CPF a constar na nota fiscal dos pedidos da sessão" validate:"required"`
+	}
+
+	GuestSession struct {
+		ID        string `json:"id" description:"ID da sessão de convidado"`
+		Document  string `json:"document,omitempty" description:"CPF informado para a nota fiscal"`
+		ExpiresAt string `json:"expires_at" description:"Data de expiração da sessão"`
 	}
 )
 
@@ -189,3 +413,107 @@ const (
 	PAYMENT_STATUS_APPROVED               = "Aprovado"
 	PAYMENT_STATUS_REFUSED                = "Recusado"
 )
+
+// Catalogs' models
+type (
+	CatalogImportResult struct {
+		Line           int    `json:"line" description:"Linha do arquivo importado"`
+		Category       string `json:"category" description:"Nome da categoria"`
+		Product        string `json:"product,omitempty" description:"Nome do produto"`
+		CategoryAction string `json:"category_action" description:"Ação sobre a categoria" enum:"create|none"`
+		ProductAction  string `json:"product_action" description:"Ação sobre o produto" enum:"create|update|none|error"`
+		Error          string `json:"error,omitempty" description:"Motivo da falha da linha"`
+	}
+
+	CatalogImportReport struct {
+		DryRun            bool                  `json:"dry_run" description:"Indica que nada foi gravado"`
+		CategoriesCreated int                   `json:"categories_created"`
+		ProductsCreated   int                   `json:"products_created"`
+		ProductsUpdated   int                   `json:"products_updated"`
+		Failed            int                   `json:"failed" description:"Quantidade de linhas com erro"`
+		Results           []CatalogImportResult `json:"results"`
+	}
+)
+
+// Audits' models
+type (
+	AuditQuery struct {
+		Limit      int    `json:"limit" default:"10" description:"Quantidade de registros" validate:"min=0"`
+		Offset     int    `json:"offset" validate:"min=0"`
+		ActorID    string `json:"actor_id,omitempty" description:"ID do usuário ou da chave de API que fez a ação" validate:"uuid"`
+		Action     string `json:"action,omitempty" description:"Ação, como product.update ou user.grant_role"`
+		EntityType string `json:"entity_type,omitempty" description:"Tipo do registro alterado" enum:"product|category|catalog|order|payment|user|api_key"`
+		EntityID   string `json:"entity_id,omitempty" description:"ID do registro alterado" validate:"uuid"`
+		From       string `json:"from,omitempty" description:"Início do período, RFC 3339" validate:"rfc3339"`
+		To         string `json:"to,omitempty" description:"Fim do período, exclusivo, RFC 3339" validate:"rfc3339"`
+		RequestID  string `json:"request_id,omitempty" description:"ID da requisição, do cabeçalho X-Request-ID"`
+	}
+
+	AuditEntry struct {
+		ID            string          `json:"id" description:"ID do registro de auditoria"`
+		CreatedAt     string          `json:"created_at" description:"Data da ação"`
+		ActorUserID   string          `json:"actor_user_id,omitempty" description:"ID do usuário que fez a ação"`
+		ActorAPIKeyID string          `json:"actor_api_key_id,omitempty" description:"ID da chave de API que fez a ação"`
+		Action        string          `json:"action" description:"Ação realizada"`
+		EntityType    string          `json:"entity_type" description:"Tipo do registro alterado"`
+		EntityID      string          `json:"entity_id,omitempty" description:"ID do registro alterado"`
+		Before        json.RawMessage `json:"before,omitempty" type:"object" description:"Estado do registro antes da ação"`
+		After         json.RawMessage `json:"after,omitempty" type:"object" description:"Estado do registro após a ação"`
+		RequestID     string          `json:"request_id,omitempty" description:"ID da requisição"`
+	}
+
+	AuditList struct {
+		Entries []AuditEntry `json:"entries"`
+		Limit   int          `json:"limit" default:"10"`
+		Offset  int          `json:"offset"`
+		Total   int64        `json:"total"`
+	}
+)
+
+// Webhooks' models
+type (
+	InsertionWebhook struct {
+		URL        string   `json:"url" description:"URL do parceiro que recebe os eventos, http ou https" validate:"required"`
+		EventTypes []string `json:"event_types" description:"Eventos assinados" enum:"order.created|order.status_changed|payment.status_changed" validate:"required,enum=event_type"`
+		Secret     string   `json:"secret,omitempty" description:"Segredo da assinatura HMAC, com ao menos 16 caracteres. Gerado quando vazio"`
+	}
+
+	Webhook struct {
+		ID         string   `json:"id" description:"ID da assinatura"`
+		URL        string   `json:"url" description:"URL do parceiro"`
+		EventTypes []string `json:"event_types" description:"Eventos assinados"`
+		Secret     string   `json:"secret,omitempty" description:"Segredo da assinatura HMAC, exibido apenas na criação"`
+		CreatedBy  string   `json:"created_by" description:"ID do usuário que criou a assinatura"`
+		CreatedAt  string   `json:"created_at" description:"Data de criação"`
+	}
+
+	WebhookDeliveryQuery struct {
+		Limit          int    `json:"limit" default:"10" description:"Quantidade de registros" validate:"min=0"`
+		Offset         int    `json:"offset" validate:"min=0"`
+		SubscriptionID string `json:"subscription_id,omitempty" description:"ID da assinatura" validate:"uuid"`
+		Status         string `json:"status,omitempty" description:"Situação da entrega, dead lista as entregas que esgotaram as tentativas" enum:"pending|delivered|dead" validate:"enum=webhook_delivery_status"`
+		EventID        string `json:"event_id,omitempty" description:"ID do evento" validate:"uuid"`
+	}
+
+	WebhookDelivery struct {
+		ID             string          `json:"id" description:"ID da entrega, enviado no cabeçalho X-Lanchonete-Delivery"`
+		SubscriptionID string          `json:"subscription_id" description:"ID da assinatura"`
+		EventID        string          `json:"event_id" description:"ID do evento"`
+		EventType      string          `json:"event_type" description:"Tipo do evento"`
+		Data           json.RawMessage `json:"data" type:"object" description:"Dados do evento"`
+		Status         string          `json:"status" description:"Situação da entrega" enum:"pending|delivered|dead"`
+		Attempts       int             `json:"attempts" description:"Tentativas feitas"`
+		NextAttemptAt  string          `json:"next_attempt_at,omitempty" description:"Próxima tentativa, quando pendente"`
+		LastError      string          `json:"last_error,omitempty" description:"Erro da última tentativa"`
+		LastStatusCode int             `json:"last_status_code,omitempty" description:"Status HTTP da última resposta do parceiro"`
+		CreatedAt      string          `json:"created_at" description:"Data de criação"`
+		DeliveredAt    string          `json:"delivered_at,omitempty" description:"Data da entrega"`
+	}
+
+	WebhookDeliveryList struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+		Limit      int               `json:"limit" default:"10"`
+		Offset     int               `json:"offset"`
+		Total      int64             `json:"total"`
+	}
+)
@@ -8,7 +8,6 @@ import (
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
-	"github.com/google/uuid"
 	"net/http"
 )
 
@@ -20,129 +19,156 @@ type OrdersHttpHandler struct {
 func (oH *OrdersHttpHandler) handleGetOrder(request *restful.Request, response *restful.Response) {
 	var queryStruct QueryStruct
 
-	if err := request.ReadEntity(&queryStruct); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &queryStruct); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
-	id, uid, err := queryStruct.parseToUuid()
+	id, err := queryStruct.parseToUuid()
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
+		return
 	}
 
-	order, err := oH.ordersUC.GetOrder(oH.ctx, uid, id)
+	order, err := oH.ordersUC.GetOrder(request.Request.Context(), id)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
 	var out Order
 	out.fromDomain(order)
+	setETag(response, order.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleCreateOrder(request *restful.Request, response *restful.Response) {
 	var insertOrder InsertionOrder
-	if err := request.ReadEntity(&insertOrder); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &insertOrder); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
 	dPs := productsToDomainProducts(insertOrder.ProductsIDs)
 
-	order, err := oH.ordersUC.CreateOrder(oH.ctx, helpers.SafeUUIDFromString(insertOrder.UserID), dPs)
+	order, err := oH.ordersUC.CreateOrder(request.Request.Context(), dPs)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 
 		return
 	}
 
 	var out Order
 	out.fromDomain(order)
+	setETag(response, order.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleAddProductsIntoOrder(request *restful.Request, response *restful.Response) {
 	var addRequest UpdateOrder
-	if err := request.ReadEntity(&addRequest); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &addRequest); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 	id := helpers.SafeUUIDFromString(addRequest.ID)
-	uid := helpers.SafeUUIDFromString(addRequest.InsertionOrder.UserID)
 
 	dPs := productsToDomainProducts(addRequest.InsertionOrder.ProductsIDs)
 
-	order, err := oH.ordersUC.InsertProductsIntoOrder(oH.ctx, uid, id, dPs)
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.InsertProductsIntoOrder(request.Request.Context(), id, version, dPs)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
 	var out Order
 	out.fromDomain(order)
+	setETag(response, order.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleStatusUpdate(request *restful.Request, response *restful.Response) {
 	var req OrderStatusUpdate
-	if err := request.ReadEntity(&req); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &req); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 	oID := helpers.SafeUUIDFromString(req.OrderID)
-	uID := helpers.SafeUUIDFromString(req.UserID)
 
 	dS := stringToDomainStatus(req.Status)
 	if dS == domain.ORDER_STATUS_UNSET {
-		_ = response.WriteError(http.StatusBadRequest, errors.New("bad request: invalid status input"))
+		writeProblem(response, http.StatusBadRequest, errors.New("bad request: invalid status input"))
 		return
 	}
 
-	resp, err := oH.ordersUC.UpdateOrderStatus(oH.ctx, uID, oID, dS)
+	version, err := ifMatchVersion(request)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := oH.ordersUC.UpdateOrderStatus(request.Request.Context(), oID, version, dS)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
 	var out Order
 	out.fromDomain(resp)
+	setETag(response, resp.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleRemoveProductsOfOrder(request *restful.Request, response *restful.Response) {
 	var removeReq UpdateOrder
-	if err := request.ReadEntity(&removeReq); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &removeReq); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 	id := helpers.SafeUUIDFromString(removeReq.ID)
-	uid := helpers.SafeUUIDFromString(removeReq.InsertionOrder.UserID)
 
 	dPs := productsToDomainProducts(removeReq.ProductsIDs)
-	order, err := oH.ordersUC.RemoveProductFromOrder(oH.ctx, uid, id, dPs)
+
+	version, err := ifMatchVersion(request)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.RemoveProductFromOrder(request.Request.Context(), id, version, dPs)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
 	var out Order
 	out.fromDomain(order)
+	setETag(response, order.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleDeleteOrder(request *restful.Request, response *restful.Response) {
 	var removeStruct QueryStruct
 
-	if err := request.ReadEntity(&removeStruct); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &removeStruct); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 	id := helpers.SafeUUIDFromString(removeStruct.ID)
-	uid := helpers.SafeUUIDFromString(removeStruct.UserID)
 
-	err := oH.ordersUC.DeleteOrder(oH.ctx, uid, id)
+	version, err := ifMatchVersion(request)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	err = oH.ordersUC.DeleteOrder(request.Request.Context(), id, version)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
@@ -151,67 +177,51 @@ func (oH *OrdersHttpHandler) handleDeleteOrder(request *restful.Request, respons
 
 func (oH *OrdersHttpHandler) handleCheckout(request *restful.Request, response *restful.Response) {
 	var oC OrderCheckoutRequest
-	if err := request.ReadEntity(&oC); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &oC); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(oC.UserID)
+	id := helpers.SafeUUIDFromString(oC.OrderID)
+
+	version, err := ifMatchVersion(request)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	id := helpers.SafeUUIDFromString(oC.OrderID)
-	order, err := oH.ordersUC.Checkout(oH.ctx, uid, id)
+	order, err := oH.ordersUC.Checkout(request.Request.Context(), id, version)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
 	var out Checkout
-	var outOrder Order
-	outOrder.fromDomain(order)
-
-	var outPayment PaymentInfo
-	outPayment.Value = outOrder.Price
-	outPayment.PaymentID = outOrder.PaymentID
-
-	out.Order = outOrder
-	out.PaymentInfo = outPayment
-
+	out.fromDomain(order)
+	setETag(response, order.Version)
 	_ = response.WriteAsJson(out)
 }
 
 func (oH *OrdersHttpHandler) handleListOrders(request *restful.Request, response *restful.Response) {
-	var lR ListRequest
-	if err := request.ReadEntity(&lR); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	var lR OrderListRequest
+	if err := readEntity(request, &lR); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
-
-	uid, err := uuid.Parse(lR.UserID)
+	page, err := listPage(lR.Limit, lR.Offset, lR.Cursor, lR.Total)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	list, err := oH.ordersUC.ListOrders(oH.ctx, lR.Limit, lR.Offset, uid)
+	list, err := oH.ordersUC.ListOrders(request.Request.Context(), page)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
 	var oL OrderList
-	var ord Order
-	for _, v := range list.Orders {
-		ord.fromDomain(v)
-		oL.Orders = append(oL.Orders, ord)
-	}
-	oL.Total = list.Total
-	oL.Limit = list.Limit
-	oL.Offset = list.Offset
-
+	oL.fromDomain(list)
 	_ = response.WriteAsJson(oL)
 }
 
@@ -228,45 +238,60 @@ func NewOrdersHttpHandler(ctx context.Context, ordersUC ports.OrdersUseCase, ws
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(QueryStruct{}).
 		Returns(http.StatusOK, "ok", Order{}).
-		Returns(http.StatusBadRequest, "bad request", nil))
+		Returns(http.StatusBadRequest, "bad request", Problem{}))
 	ws.Route(ws.POST("/orders/all").To(handler.handleListOrders).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Lista pedidos").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
-		Reads(ListRequest{}).
+		Reads(OrderListRequest{}).
 		Returns(http.StatusOK, "sucesso", OrderList{}).
-		Returns(http.StatusInternalServerError, "falha interna do servidor", nil))
+		Returns(http.StatusInternalServerError, "falha interna do servidor", Problem{}))
 	ws.Route(ws.POST("/orders").To(handler.handleCreateOrder).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Cadastra pedido").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(InsertionOrderSwagger{}).
 		Returns(http.StatusOK, "sucesso", Order{}).
-		Returns(http.StatusInternalServerError, "falha interna do servidor", nil))
+		Returns(http.StatusInternalServerError, "falha interna do servidor", Problem{}))
 	ws.Route(ws.PUT("/orders/add").To(handler.handleAddProductsIntoOrder).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Adiciona items ao pedido").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ifMatchParameter(ws)).
 		Reads(UpdateOrder{}).
-		Returns(http.StatusOK, "sucesso", Order{}))
+		Returns(http.StatusOK, "sucesso", Order{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
 	ws.Route(ws.PUT("/orders/remove").To(handler.handleRemoveProductsOfOrder).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Remove items do pedido").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ifMatchParameter(ws)).
 		Reads(UpdateOrder{}).
 		Returns(http.StatusOK, "sucesso", Order{}).
-		Returns(http.StatusBadRequest, "request incorreto", nil))
+		Returns(http.StatusBadRequest, "request incorreto", Problem{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
 	ws.Route(ws.DELETE("/orders").To(handler.handleDeleteOrder).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Remove o pedido por completo").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ifMatchParameter(ws)).
 		Reads(QueryStruct{}).
 		Returns(http.StatusOK, "sucesso", nil).
-		Returns(http.StatusBadRequest, "falha", nil))
+		Returns(http.StatusBadRequest, "falha", Problem{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
 	ws.Route(ws.POST("/orders/checkout").To(handler.handleCheckout).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Checkout de pedido").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ifMatchParameter(ws)).
 		Reads(OrderCheckoutRequest{}).
 		Returns(http.StatusOK, "sucesso", Checkout{}).
-		Returns(http.StatusInternalServerError, "falha interna do servidor", nil))
+		Returns(http.StatusInternalServerError, "falha interna do servidor", Problem{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
 	ws.Route(ws.PUT("/orders/status-update").To(handler.handleStatusUpdate).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Atualização de status por parte do lojista").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
-		Reads(OrderStatusUpdate{}))
+		Param(ifMatchParameter(ws)).
+		Reads(OrderStatusUpdate{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
 	return handler
 }
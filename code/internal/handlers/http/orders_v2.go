@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+)
+
+func NewOrdersV2HttpHandler(ctx context.Context, ordersUC ports.OrdersUseCase, ws *restful.WebService) *OrdersHttpHandler {
+	handler := &OrdersHttpHandler{
+		ctx:      ctx,
+		ordersUC: ordersUC,
+	}
+
+	tags := []string{"orders"}
+	idParam := ws.PathParameter("id", "ID do pedido").DataType("string")
+
+	ws.Route(ws.GET("/orders").To(handler.handleListOrdersV2).Produces(restful.MIME_JSON).
+		Doc("Lista pedidos").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(limitParameter(ws)).
+		Param(offsetParameter(ws)).
+		Param(cursorParameter(ws)).
+		Param(totalParameter(ws)).
+		Returns(http.StatusOK, "sucesso", OrderList{}).
+		Returns(http.StatusBadRequest, "paginação inválida", Problem{}).
+		Returns(http.StatusUnauthorized, "requisição sem token", Problem{}))
+	ws.Route(ws.GET("/orders/{id}").To(handler.handleGetOrderV2).Produces(restful.MIME_JSON).
+		Doc("Obtém o pedido, com a versão no ETag").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "sucesso", Order{}).
+		Returns(http.StatusNotFound, "pedido não encontrado", Problem{}))
+	ws.Route(ws.POST("/orders").To(handler.handleCreateOrderV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Cadastra pedido").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionOrderSwagger{}).
+		Returns(http.StatusCreated, "pedido cadastrado, em Location", Order{}).
+		Returns(http.StatusBadRequest, "produtos inválidos", Problem{}))
+	ws.Route(ws.PATCH("/orders/{id}").To(handler.handlePatchOrderV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Atualização de status por parte do lojista").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ifMatchParameter(ws)).
+		Reads(PatchOrder{}).
+		Returns(http.StatusOK, "sucesso", Order{}).
+		Returns(http.StatusBadRequest, "status inválido", Problem{}).
+		Returns(http.StatusConflict, "pedido alterado por outra requisição", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
+	ws.Route(ws.DELETE("/orders/{id}").To(handler.handleDeleteOrderV2).
+		Doc("Remove o pedido por completo").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ifMatchParameter(ws)).
+		Returns(http.StatusNoContent, "pedido removido", nil).
+		Returns(http.StatusNotFound, "pedido não encontrado", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
+	ws.Route(ws.POST("/orders/{id}/products").To(handler.handleAddProductsV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Adiciona items ao pedido").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ifMatchParameter(ws)).
+		Reads(InsertionOrderSwagger{}).
+		Returns(http.StatusOK, "sucesso", Order{}).
+		Returns(http.StatusBadRequest, "produtos inválidos", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
+	ws.Route(ws.DELETE("/orders/{id}/products/{product_id}").To(handler.handleRemoveProductV2).
+		Doc("Remove o produto do pedido, a nova versão do pedido vem no ETag").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ws.PathParameter("product_id", "ID do produto").DataType("string")).
+		Param(ifMatchParameter(ws)).
+		Returns(http.StatusNoContent, "produto removido", nil).
+		Returns(http.StatusNotFound, "pedido não encontrado", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
+	ws.Route(ws.POST("/orders/{id}/checkout").To(handler.handleCheckoutV2).Produces(restful.MIME_JSON).
+		Doc("Checkout de pedido").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ifMatchParameter(ws)).
+		Returns(http.StatusOK, "sucesso", Checkout{}).
+		Returns(http.StatusNotFound, "pedido não encontrado", Problem{}).
+		Returns(http.StatusPreconditionFailed, "pedido alterado desde a versão do If-Match", Problem{}))
+
+	return handler
+}
+
+func (oH *OrdersHttpHandler) handleListOrdersV2(request *restful.Request, response *restful.Response) {
+	page, err := pageQuery(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := oH.ordersUC.ListOrders(request.Request.Context(), page)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var oL OrderList
+	oL.fromDomain(list)
+	_ = response.WriteAsJson(oL)
+}
+
+func (oH *OrdersHttpHandler) handleGetOrderV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.GetOrder(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Order
+	out.fromDomain(order)
+	setETag(response, order.Version)
+	_ = response.WriteAsJson(out)
+}
+
+func (oH *OrdersHttpHandler) handleCreateOrderV2(request *restful.Request, response *restful.Response) {
+	var insertOrder InsertionOrder
+	if err := readEntity(request, &insertOrder); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.CreateOrder(request.Request.Context(), productsToDomainProducts(insertOrder.ProductsIDs))
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Order
+	out.fromDomain(order)
+	setETag(response, order.Version)
+	writeCreated(request, response, order.ID, out)
+}
+
+func (oH *OrdersHttpHandler) handlePatchOrderV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var patch PatchOrder
+	if err = readEntity(request, &patch); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	status := stringToDomainStatus(patch.Status)
+	if status == domain.ORDER_STATUS_UNSET {
+		writeProblem(response, http.StatusBadRequest, fmt.Errorf("%w: invalid status", helpers.ErrInvalidInput))
+		return
+	}
+
+	order, err := oH.ordersUC.UpdateOrderStatus(request.Request.Context(), id, version, status)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	var out Order
+	out.fromDomain(order)
+	setETag(response, order.Version)
+	_ = response.WriteAsJson(out)
+}
+
+func (oH *OrdersHttpHandler) handleDeleteOrderV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = oH.ordersUC.DeleteOrder(request.Request.Context(), id, version); err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (oH *OrdersHttpHandler) handleAddProductsV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var insertOrder InsertionOrder
+	if err = readEntity(request, &insertOrder); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.InsertProductsIntoOrder(request.Request.Context(), id, version, productsToDomainProducts(insertOrder.ProductsIDs))
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	var out Order
+	out.fromDomain(order)
+	setETag(response, order.Version)
+	_ = response.WriteAsJson(out)
+}
+
+func (oH *OrdersHttpHandler) handleRemoveProductV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	productID, err := pathUUID(request, "product_id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.RemoveProductFromOrder(request.Request.Context(), id, version, []domain.Product{{ID: productID}})
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	setETag(response, order.Version)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (oH *OrdersHttpHandler) handleCheckoutV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := oH.ordersUC.Checkout(request.Request.Context(), id, version)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	var out Checkout
+	out.fromDomain(order)
+	setETag(response, order.Version)
+	_ = response.WriteAsJson(out)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// listPage is the page of a listing. Listings go by limit and offset, counting the total, unless a cursor is
+// sent: then they go by keyset, an empty cursor being the first page, and count only when withTotal is set.
+func listPage(limit, offset int, cursor *string, withTotal bool) (domain.Page, error) {
+	if cursor == nil {
+		return domain.OffsetPage(limit, offset), nil
+	}
+
+	page := domain.Page{Limit: limit, Keyset: true, CountTotal: withTotal}
+	if *cursor == "" {
+		return page, nil
+	}
+	after, err := decodeCursor(*cursor)
+	if err != nil {
+		return domain.Page{}, err
+	}
+	page.After = after
+	return page, nil
+}
+
+// encodeCursor hides the position of a keyset page from clients, they only send back the next_cursor they got.
+func encodeCursor(cursor *domain.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.CreatedAt.Format(time.RFC3339Nano) + "|" + cursor.ID.String()))
+}
+
+func decodeCursor(s string) (*domain.Cursor, error) {
+	invalid := fmt.Errorf("%w: cursor must be the next_cursor of a previous page", helpers.ErrInvalidInput)
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	createdAtS, idS, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, invalid
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtS)
+	if err != nil {
+		return nil, invalid
+	}
+	id, err := uuid.Parse(idS)
+	if err != nil {
+		return nil, invalid
+	}
+	return &domain.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
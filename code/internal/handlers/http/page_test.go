@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestListPage(t *testing.T) {
+	after := &domain.Cursor{CreatedAt: time.Date(2024, 1, 31, 18, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+	empty, encoded, garbage := "", encodeCursor(after), "bm90LWEtY3Vyc29y"
+	tests := []struct {
+		name      string
+		cursor    *string
+		withTotal bool
+		want      domain.Page
+		wantErr   bool
+	}{
+		{name: "001_should_page_by_offset_without_cursor", want: domain.Page{Limit: 10, Offset: 20, CountTotal: true}},
+		{name: "002_should_start_keyset_on_empty_cursor", cursor: &empty, want: domain.Page{Limit: 10, Keyset: true}},
+		{name: "003_should_resume_after_cursor", cursor: &encoded, withTotal: true, want: domain.Page{Limit: 10, Keyset: true, After: after, CountTotal: true}},
+		{name: "004_should_refuse_invalid_cursor", cursor: &garbage, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listPage(10, 20, tt.cursor, tt.withTotal)
+			if tt.wantErr {
+				if !errors.Is(err, helpers.ErrInvalidInput) {
+					t.Fatalf("listPage() error = %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("listPage() error = %v", err)
+			}
+			if got.Limit != tt.want.Limit || got.Offset != tt.want.Offset || got.Keyset != tt.want.Keyset || got.CountTotal != tt.want.CountTotal {
+				t.Errorf("listPage() = %+v, want %+v", got, tt.want)
+			}
+			if (got.After == nil) != (tt.want.After == nil) ||
+				(got.After != nil && (!got.After.CreatedAt.Equal(tt.want.After.CreatedAt) || got.After.ID != tt.want.After.ID)) {
+				t.Errorf("listPage() after = %+v, want %+v", got.After, tt.want.After)
+			}
+		})
+	}
+}
@@ -24,27 +24,28 @@ func NewPaymentsHttpHandler(
 	tags := []string{"payments"}
 
 	ws.Route(ws.POST("/webhook/payment-notification").To(handler.handlePaymentNotification).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
-		Doc("Efetua pagamento de pedido").
+		Doc("Efetua pagamento de pedido. Chamado pelo gateway de pagamento com uma chave de API de escopo payments:notify").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(PaymentNotification{}).
 		Returns(http.StatusOK, "Pagamento efetuado com sucesso", Payment{}).
-		Returns(http.StatusBadRequest, "Requisição incorreta", nil).
-		Returns(http.StatusInternalServerError, "Falha do servidor", nil))
+		Returns(http.StatusBadRequest, "Requisição incorreta", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem permissão para notificar pagamentos", Problem{}).
+		Returns(http.StatusInternalServerError, "Falha do servidor", Problem{}))
 
 	return handler
 }
 
 func (pHH *PaymentsHttpHandler) handlePaymentNotification(request *restful.Request, response *restful.Response) {
 	var pN PaymentNotification
-	if err := request.ReadEntity(&pN); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &pN); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 	notification := pN.toDomain()
 
-	p, err := pHH.paymentsUseCase.UpdatePayment(pHH.ctx, notification.PaymentID, notification.Status)
+	p, err := pHH.paymentsUseCase.UpdatePayment(request.Request.Context(), notification.PaymentID, notification.Status)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+type PrivacyHttpHandler struct {
+	ctx       context.Context
+	privacyUC ports.PrivacyUseCase
+}
+
+func NewPrivacyHttpHandler(ctx context.Context, privacyUC ports.PrivacyUseCase, ws *restful.WebService) *PrivacyHttpHandler {
+	handler := &PrivacyHttpHandler{
+		ctx:       ctx,
+		privacyUC: privacyUC,
+	}
+
+	tags := []string{"privacy"}
+
+	ws.Route(ws.GET("/users/{id}/data-export").To(handler.handleExport).Produces(restful.MIME_JSON).
+		Doc("Exporta todos os dados pessoais do usuário (LGPD): cadastro, pedidos e pagamentos. Apenas administradores exportam dados de outros usuários").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Arquivo com os dados do usuário", UserDataExport{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}).
+		Returns(http.StatusNotFound, "Usuário não encontrado ou já anonimizado", Problem{}))
+
+	ws.Route(ws.POST("/users/{id}/erasure").To(handler.handleErase).Produces(restful.MIME_JSON).
+		Doc("Anonimiza os dados pessoais do usuário (LGPD). Pedidos e pagamentos são mantidos, sem vínculo com o usuário, para os registros fiscais").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Solicitação concluída", DataSubjectRequest{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}).
+		Returns(http.StatusNotFound, "Usuário não encontrado ou já anonimizado", Problem{}))
+
+	ws.Route(ws.GET("/users/{id}/data-requests").To(handler.handleListRequests).Produces(restful.MIME_JSON).
+		Doc("Lista as solicitações de exportação e anonimização dos dados do usuário").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", []DataSubjectRequest{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	return handler
+}
+
+func (pH *PrivacyHttpHandler) handleExport(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	export, err := pH.privacyUC.ExportUserData(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out UserDataExport
+	out.fromDomain(export)
+	response.AddHeader("Content-Disposition", "attachment; filename=user-data-"+id.String()+".json")
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *PrivacyHttpHandler) handleErase(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := pH.privacyUC.EraseUserData(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out DataSubjectRequest
+	out.fromDomain(result)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *PrivacyHttpHandler) handleListRequests(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := pH.privacyUC.ListDataSubjectRequests(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	out := make([]DataSubjectRequest, 0, len(list))
+	for _, r := range list {
+		var dR DataSubjectRequest
+		dR.fromDomain(r)
+		out = append(out, dR)
+	}
+	_ = response.WriteAsJson(out)
+}
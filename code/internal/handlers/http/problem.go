@@ -0,0 +1,97 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	restful "github.com/emicklei/go-restful/v3"
+)
+
+const MIME_PROBLEM_JSON = "application/problem+json"
+
+// Problem is the body of every error answer, see RFC 7807.
+type Problem struct {
+	Type      string       `json:"type" description:"Sempre about:blank, o status identifica o problema"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty" description:"Ausente em erros internos"`
+	RequestID string       `json:"request_id,omitempty" description:"ID da requisição, o mesmo do header X-Request-ID"`
+	Errors    []FieldError `json:"errors,omitempty" description:"Campos inválidos, em erros de validação"`
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// errorStatus is the status answering an error of the use cases, anything not in helpers is an internal error.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, helpers.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, helpers.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, helpers.ErrInvalidInput), errors.Is(err, helpers.ErrBadRequest),
+		errors.Is(err, helpers.ErrInvalidCurrencyFormat):
+		return http.StatusBadRequest
+	case errors.Is(err, helpers.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, helpers.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, helpers.ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers the error with the status errorStatus maps it to, and when to retry for rate limits.
+func writeError(response *restful.Response, err error) {
+	var tooMany *helpers.TooManyRequestsError
+	if errors.As(err, &tooMany) {
+		response.AddHeader("Retry-After", strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+	}
+	writeProblem(response, errorStatus(err), err)
+}
+
+// writeProblem answers the error as problem+json with the given status. Internal errors go without detail,
+// it may carry database or driver messages; their request ID leads to the logs instead.
+func writeProblem(response *restful.Response, status int, err error) {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		RequestID: response.Header().Get(requestIDHeader),
+	}
+	if status < http.StatusInternalServerError && err != nil {
+		problem.Detail = err.Error()
+	}
+
+	var (
+		vErr  *helpers.ValidationError
+		vErrs helpers.ValidationErrors
+	)
+	switch {
+	case errors.As(err, &vErrs):
+		for _, e := range vErrs {
+			problem.Errors = append(problem.Errors, FieldError{Field: e.Field, Reason: e.Reason})
+		}
+	case errors.As(err, &vErr):
+		problem.Errors = []FieldError{{Field: vErr.Field, Reason: vErr.Reason}}
+	}
+
+	_ = response.WriteHeaderAndJson(status, problem, MIME_PROBLEM_JSON)
+}
+
+// ServiceErrorHandler answers the errors of go-restful itself, such as unknown routes or unsupported media
+// types, as problem+json like the others.
+func ServiceErrorHandler(serviceErr restful.ServiceError, _ *restful.Request, response *restful.Response) {
+	for name, values := range serviceErr.Header {
+		for _, value := range values {
+			response.AddHeader(name, value)
+		}
+	}
+	writeProblem(response, serviceErr.Code, errors.New(serviceErr.Message))
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail bool
+		wantFields int
+	}{
+		{name: "001_should_answer_not_found", err: helpers.NewNotFoundError("order", uuid.New()), wantStatus: http.StatusNotFound, wantDetail: true},
+		{name: "002_should_answer_forbidden", err: helpers.NewForbiddenError("orders:read_all"), wantStatus: http.StatusForbidden, wantDetail: true},
+		{name: "003_should_list_invalid_field", err: helpers.NewValidationError("price", "must be positive"), wantStatus: http.StatusBadRequest, wantDetail: true, wantFields: 1},
+		{name: "004_should_answer_wrapped_conflict", err: fmt.Errorf("saving: %w", helpers.NewConflictError("category", "name in use")), wantStatus: http.StatusConflict, wantDetail: true},
+		{name: "005_should_hide_internal_detail", err: errors.New(`pq: relation "lanchonete_orders" does not exist`), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			response := restful.NewResponse(recorder)
+
+			writeError(response, tt.err)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != MIME_PROBLEM_JSON {
+				t.Errorf("Content-Type = %q, want %q", got, MIME_PROBLEM_JSON)
+			}
+			var problem Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if problem.Status != tt.wantStatus || (problem.Detail != "") != tt.wantDetail || len(problem.Errors) != tt.wantFields {
+				t.Errorf("problem = %+v", problem)
+			}
+		})
+	}
+}
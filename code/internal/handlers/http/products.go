@@ -2,12 +2,16 @@ package http
 
 import (
 	"context"
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/google/uuid"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type ProductsHttpHandler struct {
@@ -41,8 +45,11 @@ func NewProductsHttpHandler(ctx context.Context, productsUC ports.ProductsUseCas
 	ws.Route(ws.PUT("/products").To(handler.handleUpdateProduct).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Atualiza dados do produto").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ifMatchParameter(ws)).
 		Reads(UpdateProduct{}). // from the request
 		Returns(200, "Dados do produto atualizados com sucesso", Product{}).
+		Returns(409, "Produto alterado por outra requisição durante a atualização", nil).
+		Returns(412, "Produto alterado desde a versão informada no If-Match", nil).
 		Returns(500, "Erro ao atualizar dados do produto", nil))
 
 	ws.Route(ws.DELETE("/products").To(handler.handleDeleteProduct).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
@@ -50,18 +57,59 @@ func NewProductsHttpHandler(ctx context.Context, productsUC ports.ProductsUseCas
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(QueryStruct{}). // from the request
 		Returns(200, "Produto removido com sucesso", nil).
+		Returns(404, "Produto não encontrado ou já removido", nil).
 		Returns(500, "Erro ao remover produto", nil))
 
+	ws.Route(ws.PUT("/products/restore").To(handler.handleRestoreProduct).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Restaura produto removido").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(QueryStruct{}). // from the request
+		Returns(200, "Produto restaurado com sucesso", Product{}).
+		Returns(404, "Produto não encontrado ou não removido", nil).
+		Returns(500, "Erro ao restaurar produto", nil))
+
 	ws.Route(ws.GET("/products").To(handler.handleListProductsByCategory).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
 		Doc("Lista produtos da categoria especificada").
 		Param(ws.QueryParameter("category-id", "ID da categoria").DataType("string")).
 		Param(ws.QueryParameter("limit", "Quantidade máxima de entradas que pode retornar").DataType("string")).
 		Param(ws.QueryParameter("offset", "Offset a ser usado na paginação").DataType("string")).
+		Param(ws.QueryParameter("cursor", "Pagina por cursor em vez de offset: vazio para a primeira página, depois o next_cursor da anterior").DataType("string")).
+		Param(ws.QueryParameter("total", "Conta o total também na paginação por cursor").DataType("boolean")).
+		Param(ws.QueryParameter("include-deleted", "Inclui produtos removidos, apenas para administradores").DataType("boolean")).
+		Param(ws.QueryParameter("exclude-allergens", "Alérgenos separados por vírgula. Produtos que contenham algum deles não são listados").DataType("string")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(Product{}). // on the response
 		Returns(200, "OK", Product{}).
+		Returns(400, "Parâmetros inválidos", nil).
 		Returns(500, "Erro ao listar produtos", nil))
 
+	ws.Route(ws.GET("/products/{id}/prices").To(handler.handleListPriceHistory).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Histórico de preços do produto, incluindo alterações agendadas").
+		Param(ws.PathParameter("id", "ID do produto").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ProductPriceHistory{}). // on the response
+		Returns(200, "OK", ProductPriceHistory{}).
+		Returns(500, "Erro ao listar histórico de preços", nil))
+
+	ws.Route(ws.GET("/products/{id}/prices/at").To(handler.handleGetPriceAt).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Preço do produto vigente na data informada").
+		Param(ws.PathParameter("id", "ID do produto").DataType("string")).
+		Param(ws.QueryParameter("date", "Data em RFC3339").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ProductPrice{}). // on the response
+		Returns(200, "OK", ProductPrice{}).
+		Returns(400, "Data inválida", nil).
+		Returns(500, "Produto sem preço na data ou outro erro", nil))
+
+	ws.Route(ws.POST("/products/{id}/prices").To(handler.handleSchedulePrice).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Agenda alteração futura de preço do produto").
+		Param(ws.PathParameter("id", "ID do produto").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ScheduleProductPrice{}). // from the request
+		Returns(200, "Alteração de preço agendada", ProductPrice{}).
+		Returns(400, "Preço ou data inválidos", nil).
+		Returns(500, "Erro ao agendar alteração de preço", nil))
+
 	return handler
 }
 
@@ -70,38 +118,33 @@ func (pH *ProductsHttpHandler) handleGetProduct(request *restful.Request, respon
 
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := pH.productsUC.GetProduct(pH.ctx, uid)
+	result, err := pH.productsUC.GetProduct(request.Request.Context(), uid)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
 	var prod Product
 	prod.fromDomain(result)
+	setETag(response, result.Version)
 	_ = response.WriteAsJson(prod)
 
 }
 func (pH *ProductsHttpHandler) handleInsertProduct(request *restful.Request, response *restful.Response) {
 	var iProd InsertionProduct
 
-	if err := request.ReadEntity(&iProd); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
-		return
-	}
-
-	uid, err := uuid.Parse(iProd.UserID)
-	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &iProd); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	product, err := pH.productsUC.InsertProduct(pH.ctx, uid, iProd.toDomain())
+	product, err := pH.productsUC.InsertProduct(request.Request.Context(), iProd.toDomain())
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
@@ -112,89 +155,195 @@ func (pH *ProductsHttpHandler) handleInsertProduct(request *restful.Request, res
 func (pH *ProductsHttpHandler) handleUpdateProduct(request *restful.Request, response *restful.Response) {
 	var upProd UpdateProduct
 
-	if err := request.ReadEntity(&upProd); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &upProd); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(upProd.UserID)
+	version, err := ifMatchVersion(request)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	product, err := pH.productsUC.UpdateProduct(pH.ctx, uid, upProd.toDomain())
+	in := upProd.toDomain()
+	if in == nil {
+		writeProblem(response, http.StatusBadRequest, helpers.ErrInvalidInput)
+		return
+	}
+	in.Version = version
+
+	product, err := pH.productsUC.UpdateProduct(request.Request.Context(), in)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeProblem(response, versionErrorStatus(version, err), err)
 		return
 	}
 
 	var prod Product
 	prod.fromDomain(product)
+	setETag(response, product.Version)
 	_ = response.WriteAsJson(prod)
 }
 func (pH *ProductsHttpHandler) handleDeleteProduct(request *restful.Request, response *restful.Response) {
 	var dS QueryStruct
 
-	if err := request.ReadEntity(&dS); err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(request, &dS); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	uid, err := uuid.Parse(dS.UserID)
+	pID, err := uuid.Parse(dS.ID)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
-	pID, err := uuid.Parse(dS.ID)
+
+	if err = pH.productsUC.DeleteProduct(request.Request.Context(), pID); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+func (pH *ProductsHttpHandler) handleRestoreProduct(request *restful.Request, response *restful.Response) {
+	var rS QueryStruct
+
+	if err := readEntity(request, &rS); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	pID, err := rS.parseToUuid()
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = pH.productsUC.DeleteProduct(pH.ctx, uid, pID); err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+	product, err := pH.productsUC.RestoreProduct(request.Request.Context(), pID)
+	if err != nil {
+		writeError(response, err)
 		return
 	}
 
-	response.WriteHeader(http.StatusOK)
+	var prod Product
+	prod.fromDomain(product)
+	_ = response.WriteAsJson(prod)
 }
 func (pH *ProductsHttpHandler) handleListProductsByCategory(request *restful.Request, response *restful.Response) {
 	id := request.QueryParameter("category-id")
-	limitS := request.QueryParameter("limit")
-	offsetS := request.QueryParameter("offset")
+	page, err := pageQuery(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
 
-	limit, err := strconv.Atoi(limitS)
+	catId, err := uuid.Parse(id)
 	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	offset, err := strconv.Atoi(offsetS)
+	var filter domain.ProductFilter
+	if includeDeletedS := request.QueryParameter("include-deleted"); includeDeletedS != "" {
+		filter.IncludeDeleted, err = strconv.ParseBool(includeDeletedS)
+		if err != nil {
+			writeProblem(response, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if excludeS := request.QueryParameter("exclude-allergens"); excludeS != "" {
+		filter.ExcludeAllergens = allergensToDomain(strings.Split(excludeS, ","))
+	}
+
+	productList, err := pH.productsUC.ListProductsByCategory(request.Request.Context(), catId, page, filter)
 	if err != nil {
+		writeError(response, err)
 		return
 	}
 
-	catId, err := uuid.Parse(id)
+	var prods ProductList
+	prods.fromDomain(productList)
+	_ = response.WriteAsJson(prods)
+}
+
+func (pH *ProductsHttpHandler) handleListPriceHistory(request *restful.Request, response *restful.Response) {
+	pID, err := uuid.Parse(request.PathParameter("id"))
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		writeProblem(response, http.StatusBadRequest, err)
 		return
 	}
 
-	productList, err := pH.productsUC.ListProductsByCategory(pH.ctx, catId, limit, offset)
+	prices, err := pH.productsUC.ListProductPriceHistory(request.Request.Context(), pID)
 	if err != nil {
-		_ = response.WriteError(http.StatusInternalServerError, err)
+		writeError(response, err)
 		return
 	}
 
-	var prods ProductList
-	var prod Product
-	for _, v := range productList.Products {
-		prod.fromDomain(v)
-		prods.Products = append(prods.Products, prod)
+	out := ProductPriceHistory{ProductID: pID.String(), Prices: make([]ProductPrice, 0, len(prices))}
+	for _, v := range prices {
+		var price ProductPrice
+		price.fromDomain(v)
+		out.Prices = append(out.Prices, price)
 	}
-	prods.Total = productList.Total
-	prods.Limit = productList.Limit
-	prods.Offset = productList.Offset
 
-	_ = response.WriteAsJson(prods)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *ProductsHttpHandler) handleGetPriceAt(request *restful.Request, response *restful.Response) {
+	pID, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, request.QueryParameter("date"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := pH.productsUC.GetProductPriceAt(request.Request.Context(), pID, at)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out ProductPrice
+	out.fromDomain(result)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *ProductsHttpHandler) handleSchedulePrice(request *restful.Request, response *restful.Response) {
+	pID, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var req ScheduleProductPrice
+	if err = readEntity(request, &req); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	price, err := helpers.ParseDecimalFromString(req.Price)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	effectiveFrom, err := time.Parse(time.RFC3339, req.EffectiveFrom)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := pH.productsUC.ScheduleProductPrice(request.Request.Context(), pID, price, effectiveFrom)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out ProductPrice
+	out.fromDomain(result)
+	_ = response.WriteAsJson(out)
 }
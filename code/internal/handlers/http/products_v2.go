@@ -0,0 +1,250 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+func NewProductsV2HttpHandler(ctx context.Context, productsUC ports.ProductsUseCase, ws *restful.WebService) *ProductsHttpHandler {
+	handler := &ProductsHttpHandler{
+		ctx:        ctx,
+		productsUC: productsUC,
+	}
+
+	tags := []string{"products"}
+	idParam := ws.PathParameter("id", "ID do produto").DataType("string")
+
+	ws.Route(ws.GET("/products").To(handler.handleListProductsV2).Produces(restful.MIME_JSON).
+		Doc("Lista produtos da categoria especificada").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("category_id", "ID da categoria").DataType("string").Required(true)).
+		Param(ws.QueryParameter("include_deleted", "Inclui produtos removidos, apenas para administradores").DataType("boolean")).
+		Param(ws.QueryParameter("exclude_allergens", "Alérgenos separados por vírgula. Produtos que contenham algum deles não são listados").DataType("string")).
+		Param(limitParameter(ws)).
+		Param(offsetParameter(ws)).
+		Param(cursorParameter(ws)).
+		Param(totalParameter(ws)).
+		Returns(http.StatusOK, "OK", ProductList{}).
+		Returns(http.StatusBadRequest, "Parâmetros inválidos", Problem{}))
+
+	ws.Route(ws.GET("/products/{id}").To(handler.handleGetProductV2).Produces(restful.MIME_JSON).
+		Doc("Obtém dados do produto, com a versão no ETag").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "OK", Product{}).
+		Returns(http.StatusNotFound, "Produto não cadastrado", Problem{}))
+
+	ws.Route(ws.POST("/products").To(handler.handleInsertProductV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Cadastra produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionProduct{}).
+		Returns(http.StatusCreated, "Produto cadastrado, em Location", Product{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}))
+
+	ws.Route(ws.PATCH("/products/{id}").To(handler.handlePatchProductV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Altera os dados enviados do produto, os ausentes são mantidos").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ifMatchParameter(ws)).
+		Reads(PatchProduct{}).
+		Returns(http.StatusOK, "Dados do produto atualizados", Product{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}).
+		Returns(http.StatusNotFound, "Produto não cadastrado", Problem{}).
+		Returns(http.StatusConflict, "Produto alterado por outra requisição durante a atualização", Problem{}).
+		Returns(http.StatusPreconditionFailed, "Produto alterado desde a versão informada no If-Match", Problem{}))
+
+	ws.Route(ws.DELETE("/products/{id}").To(handler.handleDeleteProductV2).
+		Doc("Remove produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusNoContent, "Produto removido", nil).
+		Returns(http.StatusNotFound, "Produto não encontrado ou já removido", Problem{}))
+
+	ws.Route(ws.POST("/products/{id}/restore").To(handler.handleRestoreProductV2).Produces(restful.MIME_JSON).
+		Doc("Restaura produto removido").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "Produto restaurado", Product{}).
+		Returns(http.StatusNotFound, "Produto não encontrado ou não removido", Problem{}))
+
+	// the price routes of v1 already take the product in the path
+	ws.Route(ws.GET("/products/{id}/prices").To(handler.handleListPriceHistory).Operation("handleListPriceHistoryV2").Produces(restful.MIME_JSON).
+		Doc("Histórico de preços do produto, incluindo alterações agendadas").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "OK", ProductPriceHistory{}))
+
+	ws.Route(ws.GET("/products/{id}/prices/at").To(handler.handleGetPriceAt).Operation("handleGetPriceAtV2").Produces(restful.MIME_JSON).
+		Doc("Preço do produto vigente na data informada").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(ws.QueryParameter("date", "Data em RFC3339").DataType("string")).
+		Returns(http.StatusOK, "OK", ProductPrice{}).
+		Returns(http.StatusBadRequest, "Data inválida", Problem{}))
+
+	ws.Route(ws.POST("/products/{id}/prices").To(handler.handleSchedulePrice).Operation("handleSchedulePriceV2").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Agenda alteração futura de preço do produto").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Reads(ScheduleProductPrice{}).
+		Returns(http.StatusOK, "Alteração de preço agendada", ProductPrice{}).
+		Returns(http.StatusBadRequest, "Preço ou data inválidos", Problem{}))
+
+	return handler
+}
+
+func (pH *ProductsHttpHandler) handleListProductsV2(request *restful.Request, response *restful.Response) {
+	categoryID, err := uuid.Parse(request.QueryParameter("category_id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	page, err := pageQuery(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var filter domain.ProductFilter
+	if filter.IncludeDeleted, err = queryBool(request, "include_deleted"); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	if exclude := request.QueryParameter("exclude_allergens"); exclude != "" {
+		filter.ExcludeAllergens = allergensToDomain(strings.Split(exclude, ","))
+	}
+
+	list, err := pH.productsUC.ListProductsByCategory(request.Request.Context(), categoryID, page, filter)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out ProductList
+	out.fromDomain(list)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *ProductsHttpHandler) handleGetProductV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := pH.productsUC.GetProduct(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Product
+	out.fromDomain(product)
+	setETag(response, product.Version)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *ProductsHttpHandler) handleInsertProductV2(request *restful.Request, response *restful.Response) {
+	var iProd InsertionProduct
+	if err := readEntity(request, &iProd); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := pH.productsUC.InsertProduct(request.Request.Context(), iProd.toDomain())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Product
+	out.fromDomain(product)
+	setETag(response, product.Version)
+	writeCreated(request, response, product.ID, out)
+}
+
+func (pH *ProductsHttpHandler) handlePatchProductV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	version, err := ifMatchVersion(request)
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	var patch PatchProduct
+	if err = readEntity(request, &patch); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := request.Request.Context()
+	product, err := pH.productsUC.GetProduct(ctx, id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	if err = patch.applyTo(product); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+	// the patch is made on the version just read when the client sent none
+	if version != 0 {
+		product.Version = version
+	}
+
+	product, err = pH.productsUC.UpdateProduct(ctx, product)
+	if err != nil {
+		writeProblem(response, versionErrorStatus(version, err), err)
+		return
+	}
+
+	var out Product
+	out.fromDomain(product)
+	setETag(response, product.Version)
+	_ = response.WriteAsJson(out)
+}
+
+func (pH *ProductsHttpHandler) handleDeleteProductV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = pH.productsUC.DeleteProduct(request.Request.Context(), id); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (pH *ProductsHttpHandler) handleRestoreProductV2(request *restful.Request, response *restful.Response) {
+	id, err := pathUUID(request, "id")
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := pH.productsUC.RestoreProduct(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Product
+	out.fromDomain(product)
+	setETag(response, product.Version)
+	_ = response.WriteAsJson(out)
+}
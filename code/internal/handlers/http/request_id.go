@@ -0,0 +1,41 @@
+package http
+
+import (
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	requestIDMaxLength = 100
+)
+
+// NewRequestIDFilter puts the request ID in the request context and echoes it in the response, so the audit
+// entries and logs of a request can be correlated. The ID sent by the client, or a proxy, is kept when
+// reasonable, otherwise a new one is generated.
+func NewRequestIDFilter() restful.FilterFunction {
+	return func(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+		requestID := request.HeaderParameter(requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		response.AddHeader(requestIDHeader, requestID)
+		request.Request = request.Request.WithContext(domain.ContextWithRequestID(request.Request.Context(), requestID))
+		chain.ProcessFilter(request, response)
+	}
+}
+
+// isValidRequestID accepts printable ASCII only, the ID ends up in logs and response headers.
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > requestIDMaxLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
 )
 
 type UserHandler struct {
@@ -30,15 +32,91 @@ func NewUserHandler(
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(InsertionUser{}). // from the request
 		Returns(200, "Cliente cadastrado", User{}).
+		Returns(400, "CPF inválido", nil).
+		Returns(409, "CPF já cadastrado", nil).
 		Returns(500, "Erro ao cadastrar cliente", nil))
 
 	ws.Route(ws.POST("/users/validate").To(handler.handleValidate).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
-		Doc("Identifica cliente via CPF. Retorna o ID do cliente no sistema, caso haja cliente cadastrado com esse CPF").
+		Doc("Identifica cliente via CPF, apenas para a equipe. Retorna o ID do cliente no sistema, caso haja cliente cadastrado com esse CPF").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(QueryUser{}).
 		Writes(ValidatedUser{}). // on the response
 		Returns(200, "OK", ValidatedUser{}).
-		Returns(500, "CPF não cadastrado ou outro erro", nil))
+		Returns(400, "CPF inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token", Problem{}).
+		Returns(http.StatusForbidden, "Usuário sem permissão para listar usuários", Problem{}).
+		Returns(http.StatusNotFound, "CPF não cadastrado", Problem{}))
+
+	ws.Route(ws.GET("/users/me").To(handler.handleGetMyProfile).Produces(restful.MIME_JSON).
+		Doc("Obtém o perfil do usuário autenticado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", User{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.PUT("/users/me").To(handler.handleUpdateMyProfile).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Altera nome e email do usuário autenticado. O CPF não pode ser alterado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(UpdateUser{}).
+		Returns(http.StatusOK, "Perfil atualizado", User{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}).
+		Returns(http.StatusConflict, "Email já cadastrado", Problem{}))
+
+	ws.Route(ws.POST("/users/all").To(handler.handleListUsers).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Listagem de usuários com busca por nome, email ou CPF, apenas para administradores").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(UserListRequest{}).
+		Returns(http.StatusOK, "sucesso", UserList{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}).
+		Returns(http.StatusInternalServerError, "falha interna do servidor", Problem{}))
+
+	ws.Route(ws.GET("/users/me/consents").To(handler.handleListMyConsents).Produces(restful.MIME_JSON).
+		Doc("Lista o histórico de consentimentos do usuário autenticado. O registro mais recente de cada finalidade é a escolha atual").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", []Consent{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.POST("/users/me/consents").To(handler.handleRecordMyConsents).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Concede ou revoga finalidades de marketing para o usuário autenticado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ConsentsRequest{}).
+		Returns(http.StatusOK, "Histórico de consentimentos atualizado", []Consent{}).
+		Returns(http.StatusBadRequest, "Finalidade ou canal inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.POST("/users/contacts").To(handler.handleListContacts).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Lista os clientes que consentem com a finalidade, para campanhas de marketing").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ContactsRequest{}).
+		Returns(http.StatusOK, "sucesso", ContactList{}).
+		Returns(http.StatusBadRequest, "Finalidade inválida", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.GET("/users/{id}/roles").To(handler.handleListRoles).Produces(restful.MIME_JSON).
+		Doc("Lista os papéis do usuário. Apenas administradores consultam outros usuários").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", UserRoles{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.POST("/users/{id}/roles").To(handler.handleGrantRole).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Concede um papel ao usuário, apenas para administradores").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RoleRequest{}).
+		Returns(http.StatusOK, "Papéis do usuário após a concessão", UserRoles{}).
+		Returns(http.StatusBadRequest, "Papel inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.DELETE("/users/{id}/roles/{role}").To(handler.handleRevokeRole).Produces(restful.MIME_JSON).
+		Doc("Revoga um papel do usuário, apenas para administradores").
+		Param(ws.PathParameter("id", "ID do usuário").DataType("string")).
+		Param(ws.PathParameter("role", "Papel a revogar").DataType("string").PossibleValues(roleValues())).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Papéis do usuário após a revogação", UserRoles{}).
+		Returns(http.StatusNotFound, "Usuário não possui o papel", Problem{}).
+		Returns(http.StatusConflict, "Administrador revogando o próprio papel de administrador", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
 
 	return handler
 }
@@ -46,14 +124,14 @@ func NewUserHandler(
 func (uH *UserHandler) handleCreate(req *restful.Request, resp *restful.Response) {
 	var user User
 
-	if err := req.ReadEntity(&user); err != nil {
-		_ = resp.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(req, &user); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := uH.usersUseCase.CreateUser(uH.ctx, user.Name, user.Document, user.Email)
+	result, err := uH.usersUseCase.CreateUser(req.Request.Context(), user.Name, user.Document, user.Email, user.Password, consentsToDomain(user.Consents))
 	if err != nil {
-		_ = resp.WriteError(http.StatusInternalServerError, err)
+		writeError(resp, err)
 		return
 	}
 
@@ -64,14 +142,14 @@ func (uH *UserHandler) handleCreate(req *restful.Request, resp *restful.Response
 
 func (uH *UserHandler) handleValidate(req *restful.Request, resp *restful.Response) {
 	var queryUser QueryUser
-	if err := req.ReadEntity(&queryUser); err != nil {
-		_ = resp.WriteError(http.StatusBadRequest, err)
+	if err := readEntity(req, &queryUser); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := uH.usersUseCase.ValidateUser(uH.ctx, queryUser.Document)
+	result, err := uH.usersUseCase.ValidateUser(req.Request.Context(), queryUser.Document)
 	if err != nil {
-		_ = resp.WriteError(http.StatusInternalServerError, err)
+		writeError(resp, err)
 		return
 	}
 
@@ -82,3 +160,173 @@ func (uH *UserHandler) handleValidate(req *restful.Request, resp *restful.Respon
 	_ = resp.WriteAsJson(ret)
 	return
 }
+
+func (uH *UserHandler) handleGetMyProfile(req *restful.Request, resp *restful.Response) {
+	result, err := uH.usersUseCase.GetMyProfile(req.Request.Context())
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var user User
+	user.fromDomain(result)
+	_ = resp.WriteAsJson(user)
+}
+
+func (uH *UserHandler) handleUpdateMyProfile(req *restful.Request, resp *restful.Response) {
+	var uU UpdateUser
+	if err := readEntity(req, &uU); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := uH.usersUseCase.UpdateMyProfile(req.Request.Context(), uU.Name, uU.Email)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var user User
+	user.fromDomain(result)
+	_ = resp.WriteAsJson(user)
+}
+
+func (uH *UserHandler) handleListUsers(req *restful.Request, resp *restful.Response) {
+	var lR UserListRequest
+	if err := readEntity(req, &lR); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := uH.usersUseCase.ListUsers(req.Request.Context(), lR.Limit, lR.Offset, lR.Search)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var uL UserList
+	uL.fromDomain(list)
+	_ = resp.WriteAsJson(uL)
+}
+
+func (uH *UserHandler) handleListMyConsents(req *restful.Request, resp *restful.Response) {
+	consents, err := uH.usersUseCase.ListMyConsents(req.Request.Context())
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	writeConsents(resp, consents)
+}
+
+func (uH *UserHandler) handleRecordMyConsents(req *restful.Request, resp *restful.Response) {
+	var cR ConsentsRequest
+	if err := readEntity(req, &cR); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	consents, err := uH.usersUseCase.RecordMyConsents(req.Request.Context(), consentsToDomain(cR.Consents))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	writeConsents(resp, consents)
+}
+
+func (uH *UserHandler) handleListContacts(req *restful.Request, resp *restful.Response) {
+	var cR ContactsRequest
+	if err := readEntity(req, &cR); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := uH.usersUseCase.ListEligibleContacts(req.Request.Context(), domain.ConsentPurpose(cR.Purpose), cR.Limit, cR.Offset)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out ContactList
+	out.fromDomain(list)
+	_ = resp.WriteAsJson(out)
+}
+
+func writeConsents(resp *restful.Response, consents []*domain.Consent) {
+	out := make([]Consent, 0, len(consents))
+	for _, c := range consents {
+		var consent Consent
+		consent.fromDomain(c)
+		out = append(out, consent)
+	}
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleListRoles(req *restful.Request, resp *restful.Response) {
+	id, err := uuid.Parse(req.PathParameter("id"))
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	roles, err := uH.usersUseCase.ListUserRoles(req.Request.Context(), id)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out UserRoles
+	out.fromDomain(id, roles)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleGrantRole(req *restful.Request, resp *restful.Response) {
+	id, err := uuid.Parse(req.PathParameter("id"))
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	var roleReq RoleRequest
+	if err = readEntity(req, &roleReq); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	roles, err := uH.usersUseCase.GrantUserRole(req.Request.Context(), id, domain.Role(roleReq.Role))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out UserRoles
+	out.fromDomain(id, roles)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleRevokeRole(req *restful.Request, resp *restful.Response) {
+	id, err := uuid.Parse(req.PathParameter("id"))
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	roles, err := uH.usersUseCase.RevokeUserRole(req.Request.Context(), id, domain.Role(req.PathParameter("role")))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out UserRoles
+	out.fromDomain(id, roles)
+	_ = resp.WriteAsJson(out)
+}
+
+func roleValues() []string {
+	values := make([]string, 0, len(domain.Roles))
+	for _, r := range domain.Roles {
+		values = append(values, string(r))
+	}
+	return values
+}
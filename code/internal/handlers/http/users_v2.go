@@ -0,0 +1,243 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+)
+
+func NewUserV2Handler(
+	_ context.Context,
+	useCase ports.UsersUseCase,
+	ws *restful.WebService,
+) *UserHandler {
+	handler := &UserHandler{
+		usersUseCase: useCase,
+	}
+
+	tags := []string{"users"}
+	idParam := ws.PathParameter("id", "ID do usuário").DataType("string")
+	roleParam := ws.PathParameter("role", "Papel").DataType("string").PossibleValues(roleValues())
+
+	ws.Route(ws.POST("/users").To(handler.handleCreateV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Cadastra cliente").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionUser{}).
+		Returns(http.StatusCreated, "Cliente cadastrado", User{}).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusConflict, "CPF já cadastrado", Problem{}))
+
+	// the CPF is sent in the body, never in the URL, so it is not left in access logs
+	ws.Route(ws.POST("/users/validate").To(handler.handleValidate).Operation("handleValidateV2").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Identifica cliente via CPF, apenas para a equipe. Retorna o ID do cliente no sistema, caso haja cliente cadastrado com esse CPF").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(QueryUser{}).
+		Returns(http.StatusOK, "OK", ValidatedUser{}).
+		Returns(http.StatusBadRequest, "CPF inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token", Problem{}).
+		Returns(http.StatusForbidden, "Usuário sem permissão para listar usuários", Problem{}).
+		Returns(http.StatusNotFound, "CPF não cadastrado", Problem{}))
+
+	ws.Route(ws.GET("/users").To(handler.handleListUsersV2).Produces(restful.MIME_JSON).
+		Doc("Listagem de usuários com busca por nome, email ou CPF, apenas para administradores").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("search", "Busca por nome, email ou CPF").DataType("string")).
+		Param(limitParameter(ws)).
+		Param(offsetParameter(ws)).
+		Returns(http.StatusOK, "sucesso", UserList{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.GET("/users/me").To(handler.handleGetMyProfile).Operation("handleGetMyProfileV2").Produces(restful.MIME_JSON).
+		Doc("Obtém o perfil do usuário autenticado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", User{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.PATCH("/users/me").To(handler.handlePatchMyProfileV2).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Altera nome ou email do usuário autenticado, os ausentes são mantidos. O CPF não pode ser alterado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(PatchUser{}).
+		Returns(http.StatusOK, "Perfil atualizado", User{}).
+		Returns(http.StatusBadRequest, "Dados inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}).
+		Returns(http.StatusConflict, "Email já cadastrado", Problem{}))
+
+	ws.Route(ws.GET("/users/me/consents").To(handler.handleListMyConsents).Operation("handleListMyConsentsV2").Produces(restful.MIME_JSON).
+		Doc("Lista o histórico de consentimentos do usuário autenticado. O registro mais recente de cada finalidade é a escolha atual").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", []Consent{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.POST("/users/me/consents").To(handler.handleRecordMyConsents).Operation("handleRecordMyConsentsV2").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Concede ou revoga finalidades de marketing para o usuário autenticado").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(ConsentsRequest{}).
+		Returns(http.StatusOK, "Histórico de consentimentos atualizado", []Consent{}).
+		Returns(http.StatusBadRequest, "Finalidade ou canal inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Requisição sem token de usuário", Problem{}))
+
+	ws.Route(ws.GET("/users/contacts").To(handler.handleListContactsV2).Produces(restful.MIME_JSON).
+		Doc("Lista os clientes que consentem com a finalidade, para campanhas de marketing").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("purpose", "Finalidade do contato").DataType("string").Required(true).
+			PossibleValues([]string{"email_promotions", "sms_promotions", "personalized_offers"})).
+		Param(limitParameter(ws)).
+		Param(offsetParameter(ws)).
+		Returns(http.StatusOK, "sucesso", ContactList{}).
+		Returns(http.StatusBadRequest, "Finalidade inválida", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.GET("/users/{id}/roles").To(handler.handleListRoles).Operation("handleListRolesV2").Produces(restful.MIME_JSON).
+		Doc("Lista os papéis do usuário. Apenas administradores consultam outros usuários").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Returns(http.StatusOK, "OK", UserRoles{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.PUT("/users/{id}/roles/{role}").To(handler.handleGrantRoleV2).Produces(restful.MIME_JSON).
+		Doc("Concede um papel ao usuário, apenas para administradores. Conceder um papel que o usuário já possui não tem efeito").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(roleParam).
+		Returns(http.StatusOK, "Papéis do usuário após a concessão", UserRoles{}).
+		Returns(http.StatusBadRequest, "Papel inválido", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.DELETE("/users/{id}/roles/{role}").To(handler.handleRevokeRoleV2).
+		Doc("Revoga um papel do usuário, apenas para administradores").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(idParam).
+		Param(roleParam).
+		Returns(http.StatusNoContent, "Papel revogado", nil).
+		Returns(http.StatusNotFound, "Usuário não possui o papel", Problem{}).
+		Returns(http.StatusConflict, "Administrador revogando o próprio papel de administrador", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	return handler
+}
+
+// handleCreateV2 answers 201 without Location, users are only read through their own profile.
+func (uH *UserHandler) handleCreateV2(req *restful.Request, resp *restful.Response) {
+	var in User
+	if err := readEntity(req, &in); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := uH.usersUseCase.CreateUser(req.Request.Context(), in.Name, in.Document, in.Email, in.Password, consentsToDomain(in.Consents))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out User
+	out.fromDomain(result)
+	_ = resp.WriteHeaderAndJson(http.StatusCreated, out, restful.MIME_JSON)
+}
+
+func (uH *UserHandler) handleListUsersV2(req *restful.Request, resp *restful.Response) {
+	limit, offset, err := listQuery(req)
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := uH.usersUseCase.ListUsers(req.Request.Context(), limit, offset, req.QueryParameter("search"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out UserList
+	out.fromDomain(list)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handlePatchMyProfileV2(req *restful.Request, resp *restful.Response) {
+	var patch PatchUser
+	if err := readEntity(req, &patch); err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := req.Request.Context()
+	user, err := uH.usersUseCase.GetMyProfile(ctx)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	name, email := user.Name, user.Email
+	if patch.Name != nil {
+		name = *patch.Name
+	}
+	if patch.Email != nil {
+		email = *patch.Email
+	}
+
+	result, err := uH.usersUseCase.UpdateMyProfile(ctx, name, email)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out User
+	out.fromDomain(result)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleListContactsV2(req *restful.Request, resp *restful.Response) {
+	limit, offset, err := listQuery(req)
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	purpose := domain.ConsentPurpose(req.QueryParameter("purpose"))
+	list, err := uH.usersUseCase.ListEligibleContacts(req.Request.Context(), purpose, limit, offset)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out ContactList
+	out.fromDomain(list)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleGrantRoleV2(req *restful.Request, resp *restful.Response) {
+	id, err := pathUUID(req, "id")
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	roles, err := uH.usersUseCase.GrantUserRole(req.Request.Context(), id, domain.Role(req.PathParameter("role")))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	var out UserRoles
+	out.fromDomain(id, roles)
+	_ = resp.WriteAsJson(out)
+}
+
+func (uH *UserHandler) handleRevokeRoleV2(req *restful.Request, resp *restful.Response) {
+	id, err := pathUUID(req, "id")
+	if err != nil {
+		writeProblem(resp, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err = uH.usersUseCase.RevokeUserRole(req.Request.Context(), id, domain.Role(req.PathParameter("role"))); err != nil {
+		writeError(resp, err)
+		return
+	}
+
+	resp.WriteHeader(http.StatusNoContent)
+}
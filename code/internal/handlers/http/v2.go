@@ -0,0 +1,107 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+// The v2 API takes IDs in the path and listings in the query string, creates with 201 and a Location,
+// deletes with 204 and changes part of a resource with PATCH. It reuses the use cases of v1.
+
+const defaultListLimit = 10
+
+// pathUUID reads an ID from the path of the request.
+func pathUUID(request *restful.Request, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(request.PathParameter(name))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s must be an UUID", helpers.ErrInvalidInput, name)
+	}
+	return id, nil
+}
+
+// listQuery reads the page of a listing from the limit and offset query parameters.
+func listQuery(request *restful.Request) (limit, offset int, err error) {
+	limit, err = queryInt(request, "limit", defaultListLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err = queryInt(request, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+// pageQuery reads the page of a listing from the limit and offset query parameters, or from cursor and
+// total for keyset paging, see listPage.
+func pageQuery(request *restful.Request) (domain.Page, error) {
+	limit, offset, err := listQuery(request)
+	if err != nil {
+		return domain.Page{}, err
+	}
+	withTotal, err := queryBool(request, "total")
+	if err != nil {
+		return domain.Page{}, err
+	}
+
+	var cursor *string
+	if request.Request.URL.Query().Has("cursor") {
+		value := request.QueryParameter("cursor")
+		cursor = &value
+	}
+	return listPage(limit, offset, cursor, withTotal)
+}
+
+func queryInt(request *restful.Request, name string, defaultValue int) (int, error) {
+	value := request.QueryParameter(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: %s must be a non negative integer", helpers.ErrInvalidInput, name)
+	}
+	return n, nil
+}
+
+func queryBool(request *restful.Request, name string) (bool, error) {
+	value := request.QueryParameter(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s must be true or false", helpers.ErrInvalidInput, name)
+	}
+	return b, nil
+}
+
+// writeCreated answers 201 with the Location of the created resource, under the path it was created on.
+func writeCreated(request *restful.Request, response *restful.Response, id uuid.UUID, entity any) {
+	response.AddHeader("Location", request.Request.URL.Path+"/"+id.String())
+	_ = response.WriteHeaderAndJson(http.StatusCreated, entity, restful.MIME_JSON)
+}
+
+func limitParameter(ws *restful.WebService) *restful.Parameter {
+	return ws.QueryParameter("limit", "Quantidade de registros").DataType("integer").DefaultValue(strconv.Itoa(defaultListLimit))
+}
+
+func offsetParameter(ws *restful.WebService) *restful.Parameter {
+	return ws.QueryParameter("offset", "Quantidade de registros a pular").DataType("integer").DefaultValue("0")
+}
+
+func cursorParameter(ws *restful.WebService) *restful.Parameter {
+	return ws.QueryParameter("cursor", "Pagina por cursor em vez de offset: vazio para a primeira página, depois o next_cursor da anterior").DataType("string")
+}
+
+func totalParameter(ws *restful.WebService) *restful.Parameter {
+	return ws.QueryParameter("total", "Conta o total também na paginação por cursor").DataType("boolean")
+}
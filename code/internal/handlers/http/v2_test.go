@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+func TestListQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantErr    bool
+	}{
+		{name: "001_should_default_when_absent", query: "", wantLimit: defaultListLimit, wantOffset: 0},
+		{name: "002_should_read_limit_and_offset", query: "?limit=25&offset=50", wantLimit: 25, wantOffset: 50},
+		{name: "003_should_refuse_negative_offset", query: "?offset=-1", wantErr: true},
+		{name: "004_should_refuse_non_numeric_limit", query: "?limit=ten", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := restful.NewRequest(httptest.NewRequest("GET", "/v2/orders"+tt.query, nil))
+
+			limit, offset, err := listQuery(request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (limit != tt.wantLimit || offset != tt.wantOffset) {
+				t.Errorf("listQuery() = %d, %d, want %d, %d", limit, offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+// enums are the values accepted by the enum rule, by name.
+var enums = map[string][]string{
+	"order_status":           {ORDER_STATUS_RECEIVED, ORDER_STATUS_PREPARING, ORDER_STATUS_DONE, ORDER_STATUS_FINISHED, ORDER_STATUS_CANCELED},
+	"allergen":               enumValues(domain.Allergens),
+	"consent_purpose":        enumValues(domain.ConsentPurposes),
+	"consent_channel":        enumValues(domain.ConsentChannels),
+	"role":                   enumValues(domain.Roles),
+	"permission":             enumValues(domain.Permissions),
+	"event_type":             enumValues(domain.WebhookEventTypes),
+	"category_delete_policy": {"refuse", "reassign", "cascade"},
+	"webhook_delivery_status": {
+		string(domain.WEBHOOK_DELIVERY_PENDING), string(domain.WEBHOOK_DELIVERY_DELIVERED), string(domain.WEBHOOK_DELIVERY_DEAD),
+	},
+}
+
+// readEntity reads the request body into entity and validates it, so use cases are only called with
+// well formed input.
+func readEntity(request *restful.Request, entity interface{}) error {
+	if err := request.ReadEntity(entity); err != nil {
+		return err
+	}
+	return validate(entity)
+}
+
+// validate checks the rules in the validate tag of every field and returns all the fields breaking them
+// as helpers.ValidationErrors. Rules are separated by commas:
+//
+//	required   refuses the zero value, or an empty list
+//	uuid       a UUID
+//	email      an email address
+//	price      a positive amount, such as R$ 12,90
+//	rfc3339    a date in RFC 3339
+//	enum=name  one of the values of enums[name]
+//	min=n      a number of at least n
+//
+// Apart from required, rules accept the zero value, so optional fields are only checked when sent. Nil
+// pointers are fields left out, the rules of a pointer apply to its value when present. On lists, the rules
+// other than required apply to each item. Struct fields, and lists of them, are validated as well.
+func validate(entity interface{}) error {
+	var errs helpers.ValidationErrors
+	validateStruct(reflect.Indirect(reflect.ValueOf(entity)), "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateStruct(value reflect.Value, prefix string, errs *helpers.ValidationErrors) {
+	for i := 0; i < value.NumField(); i++ {
+		field, fieldType := value.Field(i), value.Type().Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		if fieldType.Anonymous {
+			validateStruct(reflect.Indirect(field), prefix, errs)
+			continue
+		}
+
+		name := prefix + jsonName(fieldType)
+		rules := strings.Split(fieldType.Tag.Get("validate"), ",")
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		validateField(field, name, rules, errs)
+	}
+}
+
+func validateField(field reflect.Value, name string, rules []string, errs *helpers.ValidationErrors) {
+	if hasRule(rules, "required") && (field.IsZero() || (field.Kind() == reflect.Slice && field.Len() == 0) ||
+		(field.Kind() == reflect.String && strings.TrimSpace(field.String()) == "")) {
+		*errs = append(*errs, helpers.NewValidationError(name, "is required"))
+		return
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		if field.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(field, name+".", errs)
+		}
+	case reflect.Slice:
+		for i := 0; i < field.Len(); i++ {
+			validateField(reflect.Indirect(field.Index(i)), fmt.Sprintf("%s[%d]", name, i), withoutRequired(rules), errs)
+		}
+	case reflect.String:
+		if field.String() == "" {
+			return
+		}
+		for _, rule := range rules {
+			if reason := checkString(rule, field.String()); reason != "" {
+				*errs = append(*errs, helpers.NewValidationError(name, reason))
+				return
+			}
+		}
+	case reflect.Int, reflect.Int64:
+		for _, rule := range rules {
+			if min, ok := strings.CutPrefix(rule, "min="); ok {
+				if n, _ := strconv.ParseInt(min, 10, 64); field.Int() < n {
+					*errs = append(*errs, helpers.NewValidationError(name, "must be at least "+min))
+					return
+				}
+			}
+		}
+	}
+}
+
+// checkString tells why the value breaks the rule, or nothing when it does not.
+func checkString(rule, value string) string {
+	switch {
+	case rule == "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return "must be a UUID"
+		}
+	case rule == "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return "must be an email address"
+		}
+	case rule == "price":
+		if price, err := helpers.ParseDecimalFromString(value); err != nil || !price.IsPositive() {
+			return "must be a positive amount, such as R$ 12,90"
+		}
+	case rule == "rfc3339":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be a date in RFC 3339, such as 2024-01-31T18:00:00-03:00"
+		}
+	case strings.HasPrefix(rule, "enum="):
+		values := enums[strings.TrimPrefix(rule, "enum=")]
+		for _, v := range values {
+			if v == value {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+	return ""
+}
+
+func jsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutRequired(rules []string) []string {
+	out := make([]string, 0, len(rules))
+	for _, r := range rules {
+		if r != "required" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func enumValues[T ~string](values []T) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, string(v))
+	}
+	return out
+}
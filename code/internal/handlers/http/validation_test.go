@@ -0,0 +1,66 @@
+package http
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+)
+
+func TestValidate(t *testing.T) {
+	blank, badPrice := " ", "doze reais"
+	tests := []struct {
+		name       string
+		entity     interface{}
+		wantFields []string
+	}{
+		{
+			name:   "001_should_accept_valid_product",
+			entity: &InsertionProduct{Name: "X-Burger", CategoryID: "9b2c1f54-3f57-4c1e-9a0e-0d4f5b0a7e11", Price: "R$ 12,90", Allergens: []string{"gluten"}},
+		},
+		{
+			name:       "002_should_gather_every_invalid_field",
+			entity:     &InsertionProduct{CategoryID: "1", Price: "R$ -1,00", Allergens: []string{"gluten", "pepper"}},
+			wantFields: []string{"name", "category_id", "price", "allergens[1]"},
+		},
+		{
+			name:       "003_should_refuse_unknown_order_status",
+			entity:     &OrderStatusUpdate{OrderID: "not-a-uuid", Status: "Entregue"},
+			wantFields: []string{"order_id", "status"},
+		},
+		{
+			name:       "004_should_check_only_sent_patch_fields",
+			entity:     &PatchProduct{Name: &blank, Price: &badPrice},
+			wantFields: []string{"name", "price"},
+		},
+		{
+			name:       "005_should_check_nested_items",
+			entity:     &InsertionUser{Document: "12345678909", Name: "Ana", Email: "ana@", Consents: []ConsentRequest{{Purpose: "email_promotions", Channel: "fax"}}},
+			wantFields: []string{"email", "consents[0].channel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.entity)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs helpers.ValidationErrors
+			if !errors.As(err, &errs) || !errors.Is(err, helpers.ErrInvalidInput) {
+				t.Fatalf("validate() error = %v, want ValidationErrors", err)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("validate() fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
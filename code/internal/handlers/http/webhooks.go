@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+type WebhooksHttpHandler struct {
+	ctx        context.Context
+	webhooksUC ports.WebhooksUseCase
+}
+
+func NewWebhooksHttpHandler(ctx context.Context, webhooksUC ports.WebhooksUseCase, ws *restful.WebService) *WebhooksHttpHandler {
+	handler := &WebhooksHttpHandler{
+		ctx:        ctx,
+		webhooksUC: webhooksUC,
+	}
+
+	tags := []string{"webhooks"}
+
+	ws.Route(ws.POST("/webhooks").To(handler.handleCreate).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Assina os eventos de pedidos e pagamentos para um parceiro. As entregas são assinadas com HMAC-SHA256 no cabeçalho X-Lanchonete-Signature. O segredo só é exibido nesta resposta").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(InsertionWebhook{}).
+		Returns(http.StatusOK, "Assinatura criada", Webhook{}).
+		Returns(http.StatusBadRequest, "URL, eventos ou segredo inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.GET("/webhooks").To(handler.handleList).Produces(restful.MIME_JSON).
+		Doc("Lista as assinaturas ativas, sem os segredos").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", []Webhook{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.DELETE("/webhooks/{id}").To(handler.handleDelete).Produces(restful.MIME_JSON).
+		Doc("Remove assinatura, os eventos deixam de ser enviados ao parceiro").
+		Param(ws.PathParameter("id", "ID da assinatura").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Assinatura removida", nil).
+		Returns(http.StatusNotFound, "Assinatura não encontrada ou já removida", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.POST("/webhooks/deliveries/all").To(handler.handleListDeliveries).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON).
+		Doc("Lista o registro de entregas, da mais recente para a mais antiga. Com status dead, lista as entregas que esgotaram as tentativas").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(WebhookDeliveryQuery{}).
+		Returns(http.StatusOK, "sucesso", WebhookDeliveryList{}).
+		Returns(http.StatusBadRequest, "Filtros inválidos", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	ws.Route(ws.POST("/webhooks/deliveries/{id}/retry").To(handler.handleRetryDelivery).Produces(restful.MIME_JSON).
+		Doc("Envia novamente uma entrega que esgotou as tentativas").
+		Param(ws.PathParameter("id", "ID da entrega").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "Entrega reenfileirada", WebhookDelivery{}).
+		Returns(http.StatusNotFound, "Entrega não encontrada", Problem{}).
+		Returns(http.StatusConflict, "Entrega não esgotou as tentativas ou assinatura removida", Problem{}).
+		Returns(http.StatusUnauthorized, "Usuário não autorizado", Problem{}))
+
+	return handler
+}
+
+func (wH *WebhooksHttpHandler) handleCreate(request *restful.Request, response *restful.Response) {
+	var in InsertionWebhook
+	if err := readEntity(request, &in); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	eventTypes := make([]domain.EventType, 0, len(in.EventTypes))
+	for _, t := range in.EventTypes {
+		eventTypes = append(eventTypes, domain.EventType(t))
+	}
+
+	subscription, err := wH.webhooksUC.CreateWebhookSubscription(request.Request.Context(), in.URL, eventTypes, in.Secret)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out Webhook
+	out.fromDomain(subscription)
+	_ = response.WriteAsJson(out)
+}
+
+func (wH *WebhooksHttpHandler) handleList(request *restful.Request, response *restful.Response) {
+	subscriptions, err := wH.webhooksUC.ListWebhookSubscriptions(request.Request.Context())
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	out := make([]Webhook, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		var subscription Webhook
+		subscription.fromDomain(s)
+		out = append(out, subscription)
+	}
+	_ = response.WriteAsJson(out)
+}
+
+func (wH *WebhooksHttpHandler) handleDelete(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = wH.webhooksUC.DeleteWebhookSubscription(request.Request.Context(), id); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func (wH *WebhooksHttpHandler) handleListDeliveries(request *restful.Request, response *restful.Response) {
+	var q WebhookDeliveryQuery
+	if err := readEntity(request, &q); err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := q.toDomain()
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := wH.webhooksUC.ListWebhookDeliveries(request.Request.Context(), filter, q.Limit, q.Offset)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	out := WebhookDeliveryList{
+		Deliveries: make([]WebhookDelivery, 0, len(list.Deliveries)),
+		Limit:      list.Limit,
+		Offset:     list.Offset,
+		Total:      list.Total,
+	}
+	for _, d := range list.Deliveries {
+		var delivery WebhookDelivery
+		delivery.fromDomain(d)
+		out.Deliveries = append(out.Deliveries, delivery)
+	}
+	_ = response.WriteAsJson(out)
+}
+
+func (wH *WebhooksHttpHandler) handleRetryDelivery(request *restful.Request, response *restful.Response) {
+	id, err := uuid.Parse(request.PathParameter("id"))
+	if err != nil {
+		writeProblem(response, http.StatusBadRequest, err)
+		return
+	}
+
+	delivery, err := wH.webhooksUC.RetryWebhookDelivery(request.Request.Context(), id)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var out WebhookDelivery
+	out.fromDomain(delivery)
+	_ = response.WriteAsJson(out)
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts requests by key in fixed windows. Counts are kept in memory, so each instance of the API
+// limits on its own.
+type Limiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	now     func() time.Time
+	counts  map[string]*count
+	sweepAt time.Time
+}
+
+type count struct {
+	n       int
+	resetAt time.Time
+}
+
+func NewLimiter(window time.Duration) *Limiter {
+	return &Limiter{window: window, now: time.Now, counts: map[string]*count{}}
+}
+
+// Allow counts a request under key and tells whether it is within limit requests in the current window.
+// When it is not, the duration is how long until the window resets.
+func (l *Limiter) Allow(key string, limit int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	c, ok := l.counts[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = &count{resetAt: now.Add(l.window)}
+		l.counts[key] = c
+	}
+	if c.n >= limit {
+		return false, c.resetAt.Sub(now)
+	}
+	c.n++
+	return true, 0
+}
+
+// sweep drops the windows already reset, once a window, so keys seen once do not pile up.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.sweepAt) {
+		return
+	}
+	for key, c := range l.counts {
+		if !now.Before(c.resetAt) {
+			delete(l.counts, key)
+		}
+	}
+	l.sweepAt = now.Add(l.window)
+}
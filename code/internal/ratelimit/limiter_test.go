@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	start := time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		requests  []time.Duration
+		key       string
+		at        time.Duration
+		wantOk    bool
+		wantRetry time.Duration
+	}{
+		{name: "001_should_allow_under_limit", requests: []time.Duration{0}, key: "ip:1", at: time.Minute, wantOk: true},
+		{name: "002_should_refuse_over_limit", requests: []time.Duration{0, time.Minute}, key: "ip:1", at: 2 * time.Minute, wantRetry: 13 * time.Minute},
+		{name: "003_should_count_keys_apart", requests: []time.Duration{0, time.Minute}, key: "ip:2", at: 2 * time.Minute, wantOk: true},
+		{name: "004_should_allow_after_window", requests: []time.Duration{0, time.Minute}, key: "ip:1", at: 15 * time.Minute, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(15 * time.Minute)
+			for _, at := range tt.requests {
+				l.now = func() time.Time { return start.Add(at) }
+				l.Allow("ip:1", 2)
+			}
+
+			l.now = func() time.Time { return start.Add(tt.at) }
+			ok, retry := l.Allow(tt.key, 2)
+			if ok != tt.wantOk || retry != tt.wantRetry {
+				t.Errorf("Allow() = %v, %v, want %v, %v", ok, retry, tt.wantOk, tt.wantRetry)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const apiKeysTable = "lanchonete_api_keys"
+
+type apiKeysRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxAPIKeysRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.APIKeysRepository {
+	return &apiKeysRepositoryImpl{log: logger, db: db}
+}
+
+func (a *apiKeysRepositoryImpl) InsertAPIKey(ctx context.Context, key *domain.APIKey) error {
+	in := APIKey{}
+	in.fromDomain(key)
+
+	err := conn(ctx, a.db).Table(apiKeysTable).Create(&in).Error
+	if err != nil {
+		a.log.Errorw(
+			"db failed inserting api key",
+			zap.String("name", key.Name),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("api key", err)
+}
+
+func (a *apiKeysRepositoryImpl) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	out := APIKey{}
+
+	err := conn(ctx, a.db).Table(apiKeysTable).
+		Where("prefix = ?", prefix).First(&out).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("api key", uuid.Nil)
+		}
+		a.log.Errorw(
+			"db failed getting api key",
+			zap.String("prefix", prefix),
+			zap.Error(err),
+		)
+		return nil, translateError("api key", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+func (a *apiKeysRepositoryImpl) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	var saved []APIKey
+
+	err := conn(ctx, a.db).Table(apiKeysTable).Order("created_at ASC").Scan(&saved).Error
+	if err != nil {
+		a.log.Errorw(
+			"db failed listing api keys",
+			zap.Error(err),
+		)
+		return nil, translateError("api key", err)
+	}
+
+	out := make([]*domain.APIKey, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (a *apiKeysRepositoryImpl) RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := conn(ctx, a.db).Table(apiKeysTable).
+		Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		a.log.Errorw(
+			"db failed revoking api key",
+			zap.String("id", id.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("api key", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("api key", id)
+	}
+
+	return nil
+}
+
+func (a *apiKeysRepositoryImpl) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := conn(ctx, a.db).Table(apiKeysTable).
+		Where("id = ?", id).Update("last_used_at", at).Error
+	if err != nil {
+		a.log.Errorw(
+			"db failed touching api key",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("api key", err)
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const auditLogTable = "lanchonete_audit_log"
+
+type auditRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxAuditRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.AuditRepository {
+	return &auditRepositoryImpl{log: logger, db: db}
+}
+
+func (a *auditRepositoryImpl) InsertAuditEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	in := AuditEntry{}
+	in.fromDomain(entry)
+
+	err := conn(ctx, a.db).Table(auditLogTable).Create(&in).Error
+	if err != nil {
+		a.log.Errorw(
+			"db failed inserting audit entry",
+			zap.String("action", string(entry.Action)),
+			zap.String("entity_id", entry.EntityID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("audit entry", err)
+}
+
+// ListAuditEntries lists the entries matching the filter, newest first.
+func (a *auditRepositoryImpl) ListAuditEntries(ctx context.Context, filter domain.AuditFilter, limit, offset int) (*domain.AuditList, error) {
+	var total int64
+	var saved []AuditEntry
+
+	query := conn(ctx, a.db).Table(auditLogTable)
+	if filter.ActorID != uuid.Nil {
+		query = query.Where("actor_user_id = ? OR actor_api_key_id = ?", filter.ActorID, filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != uuid.Nil {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&saved).Error; err != nil {
+		a.log.Errorw(
+			"db failed listing audit entries",
+			zap.Any("filter", filter),
+			zap.Error(err),
+		)
+		return nil, translateError("audit entry", err)
+	}
+
+	// counted apart, the count would otherwise take the limit and offset of the page
+	if err := query.Session(&gorm.Session{}).
+		Count(&total).Error; err != nil {
+		a.log.Errorw(
+			"db failed counting audit entries",
+			zap.Any("filter", filter),
+			zap.Error(err),
+		)
+		return nil, translateError("audit entry", err)
+	}
+
+	out := &domain.AuditList{}
+	entries := make([]*domain.AuditEntry, 0, len(saved))
+	for _, v := range saved {
+		entries = append(entries, v.toDomain())
+	}
+
+	out.Entries = entries
+	out.Total = total
+	out.Limit = limit
+	out.Offset = offset
+
+	return out, nil
+}
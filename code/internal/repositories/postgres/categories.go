@@ -2,11 +2,15 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"time"
 )
 
 const categoriesTable = "lanchonete_categories"
@@ -23,25 +27,39 @@ func NewPgxCategoriesRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.Ca
 	}
 }
 
-func (c categoriesRepositoryImpl) ListCategories(ctx context.Context, limit int, offset int) (*domain.CategoryList, error) {
+func (c categoriesRepositoryImpl) ListCategories(ctx context.Context, limit int, offset int, includeDeleted bool) (*domain.CategoryList, error) {
 	var total int64
 	var savedCats []Category
 
-	var err error
-	if err = c.db.WithContext(ctx).Table(categoriesTable).
+	query := conn(ctx, c.db).Table(categoriesTable)
+	if !includeDeleted {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Order("position ASC, name ASC").
 		Limit(limit).
 		Offset(offset).
-		Count(&total).
 		Scan(&savedCats).Error; err != nil {
 		c.log.Errorw(
 			"failed listing categories",
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("category", err)
+	}
+
+	// counted apart, the count would otherwise take the limit and offset of the page
+	if err := query.Session(&gorm.Session{}).
+		Count(&total).Error; err != nil {
+		c.log.Errorw(
+			"failed counting categories",
+			zap.Error(err),
+		)
+		return nil, translateError("category", err)
 	}
 
 	out := &domain.CategoryList{}
-	outList := make([]*domain.Category, 0, total)
+	outList := make([]*domain.Category, 0, len(savedCats))
 
 	for _, c := range savedCats {
 		outList = append(outList, c.toDomain())
@@ -52,21 +70,35 @@ func (c categoriesRepositoryImpl) ListCategories(ctx context.Context, limit int,
 	out.Limit = limit
 	out.Offset = offset
 
-	return out, err
+	return out, nil
 }
 
 func (c categoriesRepositoryImpl) InsertCategory(ctx context.Context, in *domain.Category) (*domain.Category, error) {
 	cat := Category{}
 	cat.fromDomain(in)
 
-	if err := c.db.WithContext(ctx).Table(categoriesTable).
+	if cat.Position == 0 {
+		// new categories go to the end of the menu
+		if err := conn(ctx, c.db).Table(categoriesTable).
+			Select("COALESCE(MAX(position), 0) + 1").
+			Where("deleted_at IS NULL").
+			Scan(&cat.Position).Error; err != nil {
+			c.log.Errorw(
+				"db failed getting next category position",
+				zap.Error(err),
+			)
+			return nil, translateError("category", err)
+		}
+	}
+
+	if err := conn(ctx, c.db).Table(categoriesTable).
 		Create(&cat).Error; err != nil {
 		c.log.Errorw(
 			"db failed inserting category",
 			zap.Any("in_category", in),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("category", err)
 	}
 
 	return cat.toDomain(), nil
@@ -75,28 +107,137 @@ func (c categoriesRepositoryImpl) InsertCategory(ctx context.Context, in *domain
 func (c categoriesRepositoryImpl) GetCategoryByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
 	cat := Category{}
 
-	if err := c.db.WithContext(ctx).Table(categoriesTable).
+	if err := conn(ctx, c.db).Table(categoriesTable).
 		Select("*").Where("id = ?", id).First(&cat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("category", id)
+		}
 		c.log.Errorw(
 			"db failed getting category",
 			zap.String("category_id", id.String()),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("category", err)
+	}
+
+	return cat.toDomain(), nil
+}
+
+func (c categoriesRepositoryImpl) GetCategoryByName(ctx context.Context, name string) (*domain.Category, error) {
+	cat := Category{}
+
+	if err := conn(ctx, c.db).Table(categoriesTable).
+		Select("*").Where("name = ? AND deleted_at IS NULL", name).First(&cat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("category", uuid.Nil)
+		}
+		c.log.Errorw(
+			"db failed getting category by name",
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		return nil, translateError("category", err)
 	}
 
 	return cat.toDomain(), nil
 }
 
-func (c categoriesRepositoryImpl) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	cat := Category{ID: id}
-	if err := c.db.WithContext(ctx).Table(categoriesTable).Delete(&cat).Error; err != nil {
+func (c categoriesRepositoryImpl) UpdateCategory(ctx context.Context, in *domain.Category) (*domain.Category, error) {
+	cat := Category{}
+	cat.fromDomain(in)
+
+	result := conn(ctx, c.db).Table(categoriesTable).
+		Where("id = ? AND deleted_at IS NULL", in.ID).
+		Updates(map[string]any{
+			"name":       cat.Name,
+			"position":   cat.Position,
+			"updated_at": cat.UpdatedAt,
+		})
+	if err := result.Error; err != nil {
+		c.log.Errorw(
+			"db failed updating category",
+			zap.Any("in_category", in),
+			zap.Error(err),
+		)
+		return nil, translateError("category", err)
+	}
+	if result.RowsAffected == 0 {
+		return nil, helpers.NewNotFoundError("category", in.ID)
+	}
+
+	return c.GetCategoryByID(ctx, in.ID)
+}
+
+// ReorderCategories places the given categories first, in the given order, followed by the
+// remaining ones in their current relative order.
+func (c categoriesRepositoryImpl) ReorderCategories(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := conn(ctx, c.db).Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Table(categoriesTable).
+				Where("id = ?", id).
+				UpdateColumn("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(`
+			UPDATE `+categoriesTable+` c
+			SET position = ordered.position
+			FROM (SELECT id, ? + ROW_NUMBER() OVER (ORDER BY position, name) AS position
+			      FROM `+categoriesTable+`
+			      WHERE deleted_at IS NULL AND id NOT IN ?) ordered
+			WHERE c.id = ordered.id`,
+			len(ids), ids,
+		).Error
+	})
+	if err != nil {
+		c.log.Errorw(
+			"db failed reordering categories",
+			zap.Any("ids", ids),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("category", err)
+}
+
+func (c categoriesRepositoryImpl) DeleteCategory(ctx context.Context, id uuid.UUID, deletedAt time.Time) error {
+	result := conn(ctx, c.db).Table(categoriesTable).
+		Where("id = ? AND deleted_at IS NULL", id).
+		UpdateColumn("deleted_at", sql.NullTime{Time: deletedAt, Valid: true})
+	if err := result.Error; err != nil {
 		c.log.Errorw(
 			"db failed deleting category",
 			zap.Any("category_id", id.String()),
 			zap.Error(err),
 		)
-		return err
+		return translateError("category", err)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("category", id)
+	}
+
+	return nil
+}
+
+func (c categoriesRepositoryImpl) RestoreCategory(ctx context.Context, id uuid.UUID) error {
+	result := conn(ctx, c.db).Table(categoriesTable).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumn("deleted_at", sql.NullTime{})
+	if err := result.Error; err != nil {
+		c.log.Errorw(
+			"db failed restoring category",
+			zap.Any("category_id", id.String()),
+			zap.Error(err),
+		)
+		return translateError("category", err)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("category", id)
 	}
 
 	return nil
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const dataSubjectRequestsTable = "lanchonete_data_subject_requests"
+
+type dataSubjectRequestsRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxDataSubjectRequestsRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.DataSubjectRequestsRepository {
+	return &dataSubjectRequestsRepositoryImpl{log: logger, db: db}
+}
+
+func (d *dataSubjectRequestsRepositoryImpl) InsertDataSubjectRequest(ctx context.Context, request *domain.DataSubjectRequest) error {
+	in := DataSubjectRequest{}
+	in.fromDomain(request)
+
+	err := conn(ctx, d.db).Table(dataSubjectRequestsTable).Create(&in).Error
+	if err != nil {
+		d.log.Errorw(
+			"db failed inserting data subject request",
+			zap.String("id", request.ID.String()),
+			zap.String("user_id", request.UserID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("data subject request", err)
+}
+
+// UpdateDataSubjectRequest only records the outcome, the request itself is never changed.
+func (d *dataSubjectRequestsRepositoryImpl) UpdateDataSubjectRequest(ctx context.Context, request *domain.DataSubjectRequest) error {
+	in := DataSubjectRequest{}
+	in.fromDomain(request)
+
+	result := conn(ctx, d.db).Table(dataSubjectRequestsTable).
+		Where("id = ?", request.ID).
+		Updates(map[string]interface{}{
+			"status":       in.Status,
+			"completed_at": in.CompletedAt,
+			"error":        in.Error,
+		})
+	if result.Error != nil {
+		d.log.Errorw(
+			"db failed updating data subject request",
+			zap.String("id", request.ID.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("data subject request", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("data subject request", request.ID)
+	}
+
+	return nil
+}
+
+func (d *dataSubjectRequestsRepositoryImpl) ListDataSubjectRequests(ctx context.Context, userID uuid.UUID) ([]*domain.DataSubjectRequest, error) {
+	var saved []DataSubjectRequest
+
+	err := conn(ctx, d.db).Table(dataSubjectRequestsTable).
+		Where("user_id = ?", userID).Order("created_at ASC").Scan(&saved).Error
+	if err != nil {
+		d.log.Errorw(
+			"db failed listing data subject requests",
+			zap.String("user_id", userID.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("data subject request", err)
+	}
+
+	out := make([]*domain.DataSubjectRequest, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
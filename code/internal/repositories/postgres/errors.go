@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// translateError turns gorm and pgx errors into the errors of helpers, so the use cases and handlers do not
+// depend on the driver and database messages, naming tables and constraints, do not reach clients.
+// Other errors are returned as they are and end up as internal errors.
+func translateError(resource string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return helpers.NewNotFoundError(resource, uuid.Nil)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return helpers.NewConflictError(resource, "already exists")
+	case pgForeignKeyViolation:
+		return helpers.NewConflictError(resource, "refers to a missing record, or is referred to by another one")
+	case pgNotNullViolation:
+		return helpers.NewValidationError(pgErr.ColumnName, "is required")
+	case pgCheckViolation:
+		return helpers.NewValidationError(resource, "out of the accepted values")
+	}
+	return err
+}
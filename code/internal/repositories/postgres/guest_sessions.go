@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const guestSessionsTable = "lanchonete_guest_sessions"
+
+type guestSessionsRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxGuestSessionsRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.GuestSessionsRepository {
+	return &guestSessionsRepositoryImpl{log: logger, db: db}
+}
+
+func (g *guestSessionsRepositoryImpl) InsertGuestSession(ctx context.Context, session *domain.GuestSession) error {
+	in := GuestSession{}
+	in.fromDomain(session)
+
+	err := conn(ctx, g.db).Table(guestSessionsTable).Create(&in).Error
+	if err != nil {
+		g.log.Errorw(
+			"db failed inserting guest session",
+			zap.String("id", session.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("guest session", err)
+}
+
+func (g *guestSessionsRepositoryImpl) GetGuestSession(ctx context.Context, id uuid.UUID) (*domain.GuestSession, error) {
+	out := GuestSession{}
+
+	err := conn(ctx, g.db).Table(guestSessionsTable).
+		Where("id = ?", id).First(&out).Error
+	if err != nil {
+		g.log.Errorw(
+			"db failed getting guest session",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("guest session", id)
+		}
+		return nil, translateError("guest session", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+func (g *guestSessionsRepositoryImpl) SetGuestSessionDocument(ctx context.Context, id uuid.UUID, document string) error {
+	result := conn(ctx, g.db).Table(guestSessionsTable).
+		Where("id = ?", id).Update("document", document)
+	if result.Error != nil {
+		g.log.Errorw(
+			"db failed setting guest session document",
+			zap.String("id", id.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("guest session", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("guest session", id)
+	}
+
+	return nil
+}
+
+// MergeGuestSession moves the orders of the session to the user and closes the session, atomically.
+func (g *guestSessionsRepositoryImpl) MergeGuestSession(ctx context.Context, id, userID uuid.UUID) error {
+	err := conn(ctx, g.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(guestSessionsTable).
+			Where("id = ? AND merged_into IS NULL", id).
+			Updates(map[string]interface{}{"merged_into": userID, "merged_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helpers.NewNotFoundError("guest session", id)
+		}
+
+		return tx.Table(ordersTable).
+			Where("guest_session_id = ? AND user_id IS NULL", id).
+			Update("user_id", userID).Error
+	})
+	if err != nil {
+		g.log.Errorw(
+			"db failed merging guest session",
+			zap.String("id", id.String()),
+			zap.String("user_id", userID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("guest session", err)
+}
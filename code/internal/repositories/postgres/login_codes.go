@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const loginCodesTable = "lanchonete_login_codes"
+
+type loginCodesRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxLoginCodesRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.LoginCodesRepository {
+	return &loginCodesRepositoryImpl{log: logger, db: db}
+}
+
+func (l *loginCodesRepositoryImpl) InsertLoginCode(ctx context.Context, code *domain.LoginCode) error {
+	in := LoginCode{}
+	in.fromDomain(code)
+
+	err := conn(ctx, l.db).Table(loginCodesTable).Create(&in).Error
+	if err != nil {
+		l.log.Errorw(
+			"db failed inserting login code",
+			zap.String("user_id", code.UserID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("login code", err)
+}
+
+// GetLatestLoginCode returns the last code sent to the user, requesting a new code supersedes the previous ones.
+func (l *loginCodesRepositoryImpl) GetLatestLoginCode(ctx context.Context, userID uuid.UUID) (*domain.LoginCode, error) {
+	out := LoginCode{}
+
+	err := conn(ctx, l.db).Table(loginCodesTable).
+		Where("user_id = ?", userID).Order("created_at DESC").First(&out).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("login code", uuid.Nil)
+		}
+		l.log.Errorw(
+			"db failed getting login code",
+			zap.String("user_id", userID.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("login code", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+// ClaimLoginCodeAttempt counts the attempt in a single conditional update, so concurrent guesses can not
+// all pass a check made before counting them.
+func (l *loginCodesRepositoryImpl) ClaimLoginCodeAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	result := conn(ctx, l.db).Table(loginCodesTable).
+		Where("id = ? AND attempts < ? AND consumed_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		l.log.Errorw(
+			"db failed claiming login code attempt",
+			zap.String("id", id.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("login code", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("login code", id)
+	}
+
+	return nil
+}
+
+// ConsumeLoginCode marks the code as used. It fails with ErrNotFound when the code was already used,
+// so two concurrent logins with the same code can not both succeed.
+func (l *loginCodesRepositoryImpl) ConsumeLoginCode(ctx context.Context, id uuid.UUID) error {
+	result := conn(ctx, l.db).Table(loginCodesTable).
+		Where("id = ? AND consumed_at IS NULL", id).Update("consumed_at", time.Now())
+	if result.Error != nil {
+		l.log.Errorw(
+			"db failed consuming login code",
+			zap.String("id", id.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("login code", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("login code", id)
+	}
+
+	return nil
+}
@@ -11,18 +11,65 @@ import (
 )
 
 type User struct {
+	ID           uuid.UUID `gorm:"id,primaryKey"`
+	CreatedAt    time.Time
+	UpdatedAt    sql.NullTime
+	DeletedAt    sql.NullTime
+	Document     sql.NullString
+	Name         string
+	Email        sql.NullString
+	PasswordHash sql.NullString
+	AnonymizedAt sql.NullTime
+}
+
+type UserRole struct {
+	UserID    uuid.UUID
+	Role      string
+	GrantedAt time.Time
+	GrantedBy uuid.NullUUID
+}
+
+type UserConsent struct {
 	ID        uuid.UUID `gorm:"id,primaryKey"`
+	UserID    uuid.UUID
+	Purpose   string
+	Granted   bool
+	Channel   string
 	CreatedAt time.Time
-	UpdatedAt sql.NullTime
-	DeletedAt sql.NullTime
-	Document  string
-	Name      string
-	Email     string
-	IsAdmin   bool
+}
+
+func (c *UserConsent) fromDomain(consent *domain.Consent) {
+	c.ID = consent.ID
+	c.UserID = consent.UserID
+	c.Purpose = string(consent.Purpose)
+	c.Granted = consent.Granted
+	c.Channel = string(consent.Channel)
+	c.CreatedAt = consent.CreatedAt
+}
+
+func (c *UserConsent) toDomain() *domain.Consent {
+	return &domain.Consent{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Purpose:   domain.ConsentPurpose(c.Purpose),
+		Granted:   c.Granted,
+		Channel:   domain.ConsentChannel(c.Channel),
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+type Contact struct {
+	ID    uuid.UUID
+	Name  string
+	Email string
+}
+
+func (c *Contact) toDomain() *domain.Contact {
+	return &domain.Contact{UserID: c.ID, Name: c.Name, Email: c.Email}
 }
 
 func (u *User) toDomain() *domain.User {
-	return &domain.User{ID: u.ID, Document: u.Document, Name: u.Name, Email: u.Email}
+	return &domain.User{ID: u.ID, Document: u.Document.String, Name: u.Name, Email: u.Email.String, PasswordHash: u.PasswordHash.String}
 }
 
 func (u *User) fromDomain(dUser *domain.User) {
@@ -31,19 +78,62 @@ func (u *User) fromDomain(dUser *domain.User) {
 	}
 
 	u.ID = dUser.ID
-	u.Document = dUser.Document
+	u.Document = sql.NullString{String: dUser.Document, Valid: dUser.Document != ""}
 	u.Name = dUser.Name
-	u.Email = dUser.Email
+	u.Email = sql.NullString{String: dUser.Email, Valid: dUser.Email != ""}
+	u.PasswordHash = sql.NullString{String: dUser.PasswordHash, Valid: dUser.PasswordHash != ""}
 }
 
 type Product struct {
 	ID          uuid.UUID       `gorm:"id,primaryKey" json:"id"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   sql.NullTime    `json:"updated_at"`
+	DeletedAt   sql.NullTime    `json:"deleted_at"`
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	CategoryID  uuid.UUID       `json:"category_id"`
 	Price       decimal.Decimal `json:"price"`
+	Nutrition   json.RawMessage `json:"nutrition" gorm:"type:jsonb"`
+	Allergens   json.RawMessage `json:"allergens" gorm:"type:jsonb"`
+	Version     int             `json:"version"`
+}
+
+type NutritionFacts struct {
+	ServingSizeGrams   decimal.Decimal `json:"serving_size_g"`
+	Calories           decimal.Decimal `json:"calories_kcal"`
+	CarbohydratesGrams decimal.Decimal `json:"carbohydrates_g"`
+	SugarsGrams        decimal.Decimal `json:"sugars_g"`
+	ProteinsGrams      decimal.Decimal `json:"proteins_g"`
+	FatsGrams          decimal.Decimal `json:"fats_g"`
+	SaturatedFatsGrams decimal.Decimal `json:"saturated_fats_g"`
+	FibersGrams        decimal.Decimal `json:"fibers_g"`
+	SodiumMilligrams   decimal.Decimal `json:"sodium_mg"`
+}
+
+func (n *NutritionFacts) toDomain() *domain.NutritionFacts {
+	return &domain.NutritionFacts{
+		ServingSizeGrams:   n.ServingSizeGrams,
+		Calories:           n.Calories,
+		CarbohydratesGrams: n.CarbohydratesGrams,
+		SugarsGrams:        n.SugarsGrams,
+		ProteinsGrams:      n.ProteinsGrams,
+		FatsGrams:          n.FatsGrams,
+		SaturatedFatsGrams: n.SaturatedFatsGrams,
+		FibersGrams:        n.FibersGrams,
+		SodiumMilligrams:   n.SodiumMilligrams,
+	}
+}
+
+func (n *NutritionFacts) fromDomain(in *domain.NutritionFacts) {
+	n.ServingSizeGrams = in.ServingSizeGrams
+	n.Calories = in.Calories
+	n.CarbohydratesGrams = in.CarbohydratesGrams
+	n.SugarsGrams = in.SugarsGrams
+	n.ProteinsGrams = in.ProteinsGrams
+	n.FatsGrams = in.FatsGrams
+	n.SaturatedFatsGrams = in.SaturatedFatsGrams
+	n.FibersGrams = in.FibersGrams
+	n.SodiumMilligrams = in.SodiumMilligrams
 }
 
 type OrderProduct struct {
@@ -73,15 +163,32 @@ func (oP *OrderProduct) fromDomain(dP *domain.Product) {
 }
 
 func (p *Product) toDomain() *domain.Product {
-	return &domain.Product{
+	out := &domain.Product{
 		ID:          p.ID,
 		CategoryID:  p.CategoryID,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt.Time,
+		DeletedAt:   p.DeletedAt.Time,
 		Name:        p.Name,
 		Description: p.Description,
 		Price:       p.Price,
+		Version:     p.Version,
+	}
+
+	// both columns are written by fromDomain, a malformed value is left empty
+	var nutrition *NutritionFacts
+	if err := json.Unmarshal(p.Nutrition, &nutrition); err == nil && nutrition != nil {
+		out.Nutrition = nutrition.toDomain()
 	}
+
+	var allergens []string
+	if err := json.Unmarshal(p.Allergens, &allergens); err == nil {
+		for _, a := range allergens {
+			out.Allergens = append(out.Allergens, domain.Allergen(a))
+		}
+	}
+
+	return out
 }
 
 func (p *Product) fromDomain(dProd *domain.Product) {
@@ -94,6 +201,52 @@ func (p *Product) fromDomain(dProd *domain.Product) {
 	p.CategoryID = dProd.CategoryID
 	p.Description = dProd.Description
 	p.Price = dProd.Price
+	p.Version = dProd.Version
+
+	var nutrition *NutritionFacts
+	if dProd.Nutrition != nil {
+		nutrition = &NutritionFacts{}
+		nutrition.fromDomain(dProd.Nutrition)
+	}
+	p.Nutrition, _ = json.Marshal(nutrition)
+
+	allergens := make([]string, 0, len(dProd.Allergens))
+	for _, a := range dProd.Allergens {
+		allergens = append(allergens, string(a))
+	}
+	p.Allergens, _ = json.Marshal(allergens)
+}
+
+type ProductPrice struct {
+	ID            uuid.UUID `gorm:"id,primaryKey"`
+	CreatedAt     time.Time
+	ProductID     uuid.UUID
+	Price         decimal.Decimal
+	EffectiveFrom time.Time
+	AppliedAt     sql.NullTime
+	CreatedBy     uuid.NullUUID
+}
+
+func (pp *ProductPrice) toDomain() *domain.ProductPrice {
+	return &domain.ProductPrice{
+		ID:            pp.ID,
+		ProductID:     pp.ProductID,
+		CreatedAt:     pp.CreatedAt,
+		CreatedBy:     pp.CreatedBy.UUID,
+		EffectiveFrom: pp.EffectiveFrom,
+		AppliedAt:     pp.AppliedAt.Time,
+		Price:         pp.Price,
+	}
+}
+
+func (pp *ProductPrice) fromDomain(in *domain.ProductPrice) {
+	pp.ID = in.ID
+	pp.CreatedAt = in.CreatedAt
+	pp.ProductID = in.ProductID
+	pp.Price = in.Price
+	pp.EffectiveFrom = in.EffectiveFrom
+	pp.AppliedAt = sql.NullTime{Time: in.AppliedAt, Valid: !in.AppliedAt.IsZero()}
+	pp.CreatedBy = uuid.NullUUID{UUID: in.CreatedBy, Valid: in.CreatedBy != uuid.Nil}
 }
 
 type ProductList struct {
@@ -106,7 +259,9 @@ type Category struct {
 	ID        uuid.UUID `gorm:"id,primaryKey"`
 	CreatedAt time.Time
 	UpdatedAt sql.NullTime
+	DeletedAt sql.NullTime
 	Name      string
+	Position  int
 }
 
 func (c *Category) toDomain() *domain.Category {
@@ -114,7 +269,9 @@ func (c *Category) toDomain() *domain.Category {
 		ID:        c.ID,
 		CreatedAt: c.CreatedAt,
 		UpdatedAt: c.UpdatedAt.Time,
+		DeletedAt: c.DeletedAt.Time,
 		Name:      c.Name,
+		Position:  c.Position,
 	}
 }
 
@@ -130,20 +287,23 @@ func (c *Category) fromDomain(in *domain.Category) {
 		c.UpdatedAt.Valid = true
 	}
 	c.Name = in.Name
+	c.Position = in.Position
 
 	return
 }
 
 type Order struct {
-	ID        uuid.UUID `gorm:"id,primaryKey"`
-	UserID    uuid.UUID
-	PaymentID uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
-	DeletedAt sql.NullTime
-	Price     decimal.Decimal
-	Status    OrderStatus
-	Products  json.RawMessage `json:"products" gorm:"type:jsonb"`
+	ID             uuid.UUID `gorm:"id,primaryKey"`
+	UserID         uuid.NullUUID
+	GuestSessionID uuid.NullUUID
+	PaymentID      uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+	DeletedAt      sql.NullTime
+	Price          decimal.Decimal
+	Status         OrderStatus
+	Products       json.RawMessage `json:"products" gorm:"type:jsonb"`
+	Version        int
 }
 
 func (o *Order) fromDomain(order *domain.Order) {
@@ -151,10 +311,12 @@ func (o *Order) fromDomain(order *domain.Order) {
 		panic("order is nil")
 	}
 	o.ID = order.ID
-	o.UserID = order.UserID
+	o.UserID = uuid.NullUUID{UUID: order.UserID, Valid: order.UserID != uuid.Nil}
+	o.GuestSessionID = uuid.NullUUID{UUID: order.GuestSessionID, Valid: order.GuestSessionID != uuid.Nil}
 	o.PaymentID = order.PaymentID
 	o.CreatedAt = order.CreatedAt
 	o.Price = order.Price
+	o.Version = order.Version
 
 	var products []OrderProduct
 	for _, p := range order.Products {
@@ -202,15 +364,17 @@ func (o *Order) toDomain() *domain.Order {
 	}
 
 	return &domain.Order{
-		ID:        o.ID,
-		UserID:    o.UserID,
-		PaymentID: o.PaymentID,
-		CreatedAt: o.CreatedAt,
-		UpdatedAt: o.UpdatedAt.Time,
-		DeletedAt: o.DeletedAt.Time,
-		Status:    o.Status.toDomain(),
-		Price:     o.Price,
-		Products:  outProducts,
+		ID:             o.ID,
+		UserID:         o.UserID.UUID,
+		GuestSessionID: o.GuestSessionID.UUID,
+		PaymentID:      o.PaymentID,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt.Time,
+		DeletedAt:      o.DeletedAt.Time,
+		Status:         o.Status.toDomain(),
+		Price:          o.Price,
+		Products:       outProducts,
+		Version:        o.Version,
 	}
 }
 
@@ -293,6 +457,7 @@ type Payment struct {
 	Value     decimal.Decimal `json:"value"`
 	OrderID   uuid.UUID
 	Status    PaymentStatus
+	Version   int
 }
 
 type PaymentStatus string
@@ -329,6 +494,7 @@ func (p *Payment) fromDomain(dP *domain.Payment) {
 	p.OrderID = dP.OrderID
 	p.CreatedAt = dP.CreatedAt
 	p.Value = dP.Price
+	p.Version = dP.Version
 
 	if !dP.UpdatedAt.IsZero() {
 		p.UpdatedAt = sql.NullTime{
@@ -354,5 +520,341 @@ func (p *Payment) toDomain() *domain.Payment {
 		Price:     p.Value,
 		OrderID:   p.OrderID,
 		Status:    dS,
+		Version:   p.Version,
+	}
+}
+
+type GuestSession struct {
+	ID         uuid.UUID `gorm:"id,primaryKey"`
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Document   sql.NullString
+	MergedInto uuid.NullUUID
+	MergedAt   sql.NullTime
+}
+
+func (g *GuestSession) fromDomain(session *domain.GuestSession) {
+	g.ID = session.ID
+	g.CreatedAt = session.CreatedAt
+	g.ExpiresAt = session.ExpiresAt
+	g.Document = sql.NullString{String: session.Document, Valid: session.Document != ""}
+	g.MergedInto = uuid.NullUUID{UUID: session.MergedInto, Valid: session.MergedInto != uuid.Nil}
+	g.MergedAt = sql.NullTime{Time: session.MergedAt, Valid: !session.MergedAt.IsZero()}
+}
+
+func (g *GuestSession) toDomain() *domain.GuestSession {
+	return &domain.GuestSession{
+		ID:         g.ID,
+		CreatedAt:  g.CreatedAt,
+		ExpiresAt:  g.ExpiresAt,
+		Document:   g.Document.String,
+		MergedInto: g.MergedInto.UUID,
+		MergedAt:   g.MergedAt.Time,
+	}
+}
+
+type DataSubjectRequest struct {
+	ID          uuid.UUID `gorm:"id,primaryKey"`
+	UserID      uuid.UUID
+	Type        string
+	Status      string
+	RequestedBy uuid.UUID
+	CreatedAt   time.Time
+	CompletedAt sql.NullTime
+	Error       sql.NullString
+}
+
+func (d *DataSubjectRequest) fromDomain(request *domain.DataSubjectRequest) {
+	d.ID = request.ID
+	d.UserID = request.UserID
+	d.Type = string(request.Type)
+	d.Status = string(request.Status)
+	d.RequestedBy = request.RequestedBy
+	d.CreatedAt = request.CreatedAt
+	d.CompletedAt = sql.NullTime{Time: request.CompletedAt, Valid: !request.CompletedAt.IsZero()}
+	d.Error = sql.NullString{String: request.Error, Valid: request.Error != ""}
+}
+
+func (d *DataSubjectRequest) toDomain() *domain.DataSubjectRequest {
+	return &domain.DataSubjectRequest{
+		ID:          d.ID,
+		UserID:      d.UserID,
+		Type:        domain.DataSubjectRequestType(d.Type),
+		Status:      domain.DataSubjectRequestStatus(d.Status),
+		RequestedBy: d.RequestedBy,
+		CreatedAt:   d.CreatedAt,
+		CompletedAt: d.CompletedAt.Time,
+		Error:       d.Error.String,
+	}
+}
+
+type LoginCode struct {
+	ID         uuid.UUID `gorm:"id,primaryKey"`
+	UserID     uuid.UUID
+	CodeHash   string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Attempts   int
+	ConsumedAt sql.NullTime
+}
+
+func (l *LoginCode) fromDomain(code *domain.LoginCode) {
+	l.ID = code.ID
+	l.UserID = code.UserID
+	l.CodeHash = code.CodeHash
+	l.CreatedAt = code.CreatedAt
+	l.ExpiresAt = code.ExpiresAt
+	l.Attempts = code.Attempts
+	l.ConsumedAt = sql.NullTime{Time: code.ConsumedAt, Valid: !code.ConsumedAt.IsZero()}
+}
+
+func (l *LoginCode) toDomain() *domain.LoginCode {
+	return &domain.LoginCode{
+		ID:         l.ID,
+		UserID:     l.UserID,
+		CodeHash:   l.CodeHash,
+		CreatedAt:  l.CreatedAt,
+		ExpiresAt:  l.ExpiresAt,
+		Attempts:   l.Attempts,
+		ConsumedAt: l.ConsumedAt.Time,
+	}
+}
+
+type APIKey struct {
+	ID         uuid.UUID `gorm:"id,primaryKey"`
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     json.RawMessage `gorm:"type:jsonb"`
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+func (k *APIKey) fromDomain(key *domain.APIKey) {
+	k.ID = key.ID
+	k.Name = key.Name
+	k.Prefix = key.Prefix
+	k.KeyHash = key.KeyHash
+	k.CreatedBy = key.CreatedBy
+	k.CreatedAt = key.CreatedAt
+	k.LastUsedAt = sql.NullTime{Time: key.LastUsedAt, Valid: !key.LastUsedAt.IsZero()}
+	k.RevokedAt = sql.NullTime{Time: key.RevokedAt, Valid: !key.RevokedAt.IsZero()}
+
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, s := range key.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	k.Scopes, _ = json.Marshal(scopes)
+}
+
+func (k *APIKey) toDomain() *domain.APIKey {
+	out := &domain.APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		KeyHash:    k.KeyHash,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt.Time,
+		RevokedAt:  k.RevokedAt.Time,
+	}
+
+	var scopes []string
+	if err := json.Unmarshal(k.Scopes, &scopes); err == nil {
+		for _, s := range scopes {
+			out.Scopes = append(out.Scopes, domain.Permission(s))
+		}
+	}
+
+	return out
+}
+
+type AuditEntry struct {
+	ID            uuid.UUID `gorm:"id,primaryKey"`
+	CreatedAt     time.Time
+	ActorUserID   uuid.NullUUID
+	ActorAPIKeyID uuid.NullUUID `gorm:"column:actor_api_key_id"`
+	Action        string
+	EntityType    string
+	EntityID      uuid.NullUUID
+	Before        json.RawMessage `gorm:"type:jsonb"`
+	After         json.RawMessage `gorm:"type:jsonb"`
+	RequestID     sql.NullString
+}
+
+func (a *AuditEntry) fromDomain(entry *domain.AuditEntry) {
+	a.ID = entry.ID
+	a.CreatedAt = entry.CreatedAt
+	a.ActorUserID = uuid.NullUUID{UUID: entry.ActorUserID, Valid: entry.ActorUserID != uuid.Nil}
+	a.ActorAPIKeyID = uuid.NullUUID{UUID: entry.ActorAPIKeyID, Valid: entry.ActorAPIKeyID != uuid.Nil}
+	a.Action = string(entry.Action)
+	a.EntityType = string(entry.EntityType)
+	a.EntityID = uuid.NullUUID{UUID: entry.EntityID, Valid: entry.EntityID != uuid.Nil}
+	a.Before = entry.Before
+	a.After = entry.After
+	a.RequestID = sql.NullString{String: entry.RequestID, Valid: entry.RequestID != ""}
+}
+
+func (a *AuditEntry) toDomain() *domain.AuditEntry {
+	return &domain.AuditEntry{
+		ID:            a.ID,
+		CreatedAt:     a.CreatedAt,
+		ActorUserID:   a.ActorUserID.UUID,
+		ActorAPIKeyID: a.ActorAPIKeyID.UUID,
+		Action:        domain.AuditAction(a.Action),
+		EntityType:    domain.AuditEntityType(a.EntityType),
+		EntityID:      a.EntityID.UUID,
+		Before:        a.Before,
+		After:         a.After,
+		RequestID:     a.RequestID.String,
+	}
+}
+
+type OutboxEvent struct {
+	ID            uuid.UUID `gorm:"id,primaryKey"`
+	EventType     string
+	Payload       json.RawMessage `gorm:"type:jsonb"`
+	RequestID     sql.NullString
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	DispatchedAt  sql.NullTime
+	DeliveredAt   sql.NullTime
+}
+
+func (o *OutboxEvent) fromDomain(event *domain.OutboxEvent) {
+	o.ID = event.ID
+	o.EventType = string(event.EventType)
+	o.Payload = event.Payload
+	o.RequestID = sql.NullString{String: event.RequestID, Valid: event.RequestID != ""}
+	o.CreatedAt = event.CreatedAt
+	o.Attempts = event.Attempts
+	o.NextAttemptAt = event.NextAttemptAt
+	o.LastError = sql.NullString{String: event.LastError, Valid: event.LastError != ""}
+	o.DispatchedAt = sql.NullTime{Time: event.DispatchedAt, Valid: !event.DispatchedAt.IsZero()}
+	o.DeliveredAt = sql.NullTime{Time: event.DeliveredAt, Valid: !event.DeliveredAt.IsZero()}
+}
+
+func (o *OutboxEvent) toDomain() *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		ID:            o.ID,
+		EventType:     domain.EventType(o.EventType),
+		Payload:       o.Payload,
+		RequestID:     o.RequestID.String,
+		CreatedAt:     o.CreatedAt,
+		Attempts:      o.Attempts,
+		NextAttemptAt: o.NextAttemptAt,
+		LastError:     o.LastError.String,
+		DispatchedAt:  o.DispatchedAt.Time,
+		DeliveredAt:   o.DeliveredAt.Time,
+	}
+}
+
+type WebhookSubscription struct {
+	ID         uuid.UUID       `gorm:"id,primaryKey"`
+	URL        string          `gorm:"column:url"`
+	EventTypes json.RawMessage `gorm:"type:jsonb"`
+	Secret     string
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+	DeletedAt  sql.NullTime
+}
+
+func (w *WebhookSubscription) fromDomain(subscription *domain.WebhookSubscription) {
+	w.ID = subscription.ID
+	w.URL = subscription.URL
+	w.Secret = subscription.Secret
+	w.CreatedBy = subscription.CreatedBy
+	w.CreatedAt = subscription.CreatedAt
+	w.DeletedAt = sql.NullTime{Time: subscription.DeletedAt, Valid: subscription.IsDeleted()}
+
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+	for _, t := range subscription.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	w.EventTypes, _ = json.Marshal(eventTypes)
+}
+
+func (w *WebhookSubscription) toDomain() *domain.WebhookSubscription {
+	out := &domain.WebhookSubscription{
+		ID:        w.ID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+		DeletedAt: w.DeletedAt.Time,
+	}
+
+	var eventTypes []string
+	if err := json.Unmarshal(w.EventTypes, &eventTypes); err == nil {
+		for _, t := range eventTypes {
+			out.EventTypes = append(out.EventTypes, domain.EventType(t))
+		}
+	}
+
+	return out
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"id,primaryKey"`
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	EventVersion   int
+	Source         string
+	OccurredAt     time.Time
+	RequestID      sql.NullString
+	Data           json.RawMessage `gorm:"type:jsonb"`
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+}
+
+func (w *WebhookDelivery) fromDomain(delivery *domain.WebhookDelivery) {
+	w.ID = delivery.ID
+	w.SubscriptionID = delivery.SubscriptionID
+	w.EventID = delivery.Envelope.ID
+	w.EventType = string(delivery.Envelope.Type)
+	w.EventVersion = delivery.Envelope.Version
+	w.Source = delivery.Envelope.Source
+	w.OccurredAt = delivery.Envelope.OccurredAt
+	w.RequestID = sql.NullString{String: delivery.Envelope.RequestID, Valid: delivery.Envelope.RequestID != ""}
+	w.Data = delivery.Envelope.Data
+	w.Status = string(delivery.Status)
+	w.Attempts = delivery.Attempts
+	w.NextAttemptAt = delivery.NextAttemptAt
+	w.LastError = sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""}
+	w.LastStatusCode = sql.NullInt32{Int32: int32(delivery.LastStatusCode), Valid: delivery.LastStatusCode != 0}
+	w.CreatedAt = delivery.CreatedAt
+	w.DeliveredAt = sql.NullTime{Time: delivery.DeliveredAt, Valid: !delivery.DeliveredAt.IsZero()}
+}
+
+func (w *WebhookDelivery) toDomain() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             w.ID,
+		SubscriptionID: w.SubscriptionID,
+		Envelope: domain.EventEnvelope{
+			ID:         w.EventID,
+			Type:       domain.EventType(w.EventType),
+			Version:    w.EventVersion,
+			Source:     w.Source,
+			OccurredAt: w.OccurredAt,
+			RequestID:  w.RequestID.String,
+			Data:       w.Data,
+		},
+		Status:         domain.WebhookDeliveryStatus(w.Status),
+		Attempts:       w.Attempts,
+		NextAttemptAt:  w.NextAttemptAt,
+		LastError:      w.LastError.String,
+		LastStatusCode: int(w.LastStatusCode.Int32),
+		CreatedAt:      w.CreatedAt,
+		DeliveredAt:    w.DeliveredAt.Time,
 	}
 }
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
@@ -21,51 +23,65 @@ func (o *ordersRepositoryImpl) GetOrderByPaymentID(ctx context.Context, paymentI
 	order := &Order{}
 
 	var err error
-	if err = o.db.WithContext(ctx).Table(ordersTable).
+	if err = conn(ctx, o.db).Table(ordersTable).
 		Select("*").
 		Where("payment_id = ?", paymentID).
 		First(order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("order", uuid.Nil)
+		}
 		o.log.Errorw(
 			"db failed getting order",
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
 	out := order.toDomain()
 	return out, err
 }
 
-func (o *ordersRepositoryImpl) ListOrdersByUser(ctx context.Context, limit, offset int, userID uuid.UUID) (*domain.OrderList, error) {
+func (o *ordersRepositoryImpl) ListOrdersByUser(ctx context.Context, page domain.Page, userID uuid.UUID) (*domain.OrderList, error) {
+	return o.listOrdersByOwner(ctx, page, "user_id", userID)
+}
+
+func (o *ordersRepositoryImpl) ListOrdersByGuestSession(ctx context.Context, page domain.Page, guestSessionID uuid.UUID) (*domain.OrderList, error) {
+	return o.listOrdersByOwner(ctx, page, "guest_session_id", guestSessionID)
+}
+
+// listOrdersByOwner lists the orders whose ownerColumn, user_id or guest_session_id, matches ownerID.
+func (o *ordersRepositoryImpl) listOrdersByOwner(ctx context.Context, page domain.Page, ownerColumn string, ownerID uuid.UUID) (*domain.OrderList, error) {
 	var orders []Order
-	var total int64
+
+	query := conn(ctx, o.db).Table(ordersTable).
+		Where(ownerColumn+" = ?", ownerID)
 
 	var err error
-	if err = o.db.WithContext(ctx).Table(ordersTable).
-		Where("user_id = ?", userID).
-		Limit(limit).
-		Offset(offset).
-		Order("created_at ASC").
+	if err = paged(query.Session(&gorm.Session{}), page, "created_at ASC").
 		Find(&orders).Error; err != nil {
 		o.log.Errorw(
 			"failed listing orders",
-			zap.String("user_id", userID.String()),
+			zap.String(ownerColumn, ownerID.String()),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
-	if err = o.db.WithContext(ctx).Table(ordersTable).
-		Where("user_id = ?", userID).
-		Count(&total).Error; err != nil {
-		o.log.Errorw(
-			"failed counting orders by user_id",
-			zap.String("category", userID.String()),
-			zap.Error(err),
-		)
+	oList := &domain.OrderList{}
+	if page.CountTotal {
+		var total int64
+		if err = query.Session(&gorm.Session{}).
+			Count(&total).Error; err != nil {
+			o.log.Errorw(
+				"failed counting orders by "+ownerColumn,
+				zap.String(ownerColumn, ownerID.String()),
+				zap.Error(err),
+			)
+		}
+		oList.Total = &total
 	}
 
-	oList := &domain.OrderList{}
+	orders, oList.Next = nextPage(orders, page, orderCursor)
 	out := make([]*domain.Order, 0, len(orders))
 
 	for _, v := range orders {
@@ -73,43 +89,43 @@ func (o *ordersRepositoryImpl) ListOrdersByUser(ctx context.Context, limit, offs
 	}
 
 	oList.Orders = out
-	oList.Total = total
-	oList.Limit = limit
-	oList.Offset = offset
+	oList.Limit = page.Limit
+	oList.Offset = page.Offset
 
 	return oList, err
 
 }
 
-func (o *ordersRepositoryImpl) ListOrders(ctx context.Context, limit, offset int) (*domain.OrderList, error) {
-	var total int64
-
+func (o *ordersRepositoryImpl) ListOrders(ctx context.Context, page domain.Page) (*domain.OrderList, error) {
 	var saveOrders []Order
 
+	query := conn(ctx, o.db).Table(ordersTable).
+		Where("status > ? AND status < ? ", ORDER_STATUS_WAITING_PAYMENT, ORDER_STATUS_FINISHED)
+
 	var err error
-	if err = o.db.WithContext(ctx).Table(ordersTable).
-		Limit(limit).
-		Offset(offset).
-		Order("status DESC").
-		Where("status > ? AND status < ? ", ORDER_STATUS_WAITING_PAYMENT, ORDER_STATUS_FINISHED).
+	if err = paged(query.Session(&gorm.Session{}), page, "status DESC").
 		Scan(&saveOrders).Error; err != nil {
 		o.log.Errorw(
 			"failed listing orders",
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
-	if err = o.db.WithContext(ctx).Table(ordersTable).
-		Where("status > ? AND status < ? ", ORDER_STATUS_WAITING_PAYMENT, ORDER_STATUS_FINISHED).
-		Count(&total).Error; err != nil {
-		o.log.Errorw(
-			"failed counting orders",
-			zap.Error(err),
-		)
+	oList := &domain.OrderList{}
+	if page.CountTotal {
+		var total int64
+		if err = query.Session(&gorm.Session{}).
+			Count(&total).Error; err != nil {
+			o.log.Errorw(
+				"failed counting orders",
+				zap.Error(err),
+			)
+		}
+		oList.Total = &total
 	}
 
-	oList := &domain.OrderList{}
+	saveOrders, oList.Next = nextPage(saveOrders, page, orderCursor)
 	out := make([]*domain.Order, 0, len(saveOrders))
 
 	for _, v := range saveOrders {
@@ -117,13 +133,16 @@ func (o *ordersRepositoryImpl) ListOrders(ctx context.Context, limit, offset int
 	}
 
 	oList.Orders = out
-	oList.Total = total
-	oList.Limit = limit
-	oList.Offset = offset
+	oList.Limit = page.Limit
+	oList.Offset = page.Offset
 
 	return oList, err
 }
 
+func orderCursor(order Order) domain.Cursor {
+	return domain.Cursor{CreatedAt: order.CreatedAt, ID: order.ID}
+}
+
 const ordersTable = "lanchonete_orders"
 
 func NewPgxOrdersRepository(log *zap.SugaredLogger, db *gorm.DB) ports.OrdersRepository {
@@ -134,35 +153,44 @@ func (o *ordersRepositoryImpl) GetOrder(ctx context.Context, orderID uuid.UUID)
 	order := &Order{}
 
 	var err error
-	if err = o.db.WithContext(ctx).Table(ordersTable).
+	if err = conn(ctx, o.db).Table(ordersTable).
 		Select("*").
 		Where("id = ?", orderID).
 		First(order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("order", orderID)
+		}
 		o.log.Errorw(
 			"db failed getting order",
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
 	out := order.toDomain()
 	return out, err
 }
 
-func (o *ordersRepositoryImpl) CreateOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
+func (o *ordersRepositoryImpl) CreateOrder(ctx context.Context, order *domain.Order, events ...*domain.OutboxEvent) (*domain.Order, error) {
 	var out *domain.Order
 
 	in := Order{}
 	in.fromDomain(order)
 	in.Status = ORDER_STATUS_OPEN
-
-	if err := o.db.WithContext(ctx).Table(ordersTable).Omit("updated_at").Create(&in).Error; err != nil {
+	in.Version = 1
+
+	if err := conn(ctx, o.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(ordersTable).Omit("updated_at").Create(&in).Error; err != nil {
+			return err
+		}
+		return insertOutboxEvents(tx, events)
+	}); err != nil {
 		o.log.Errorw(
 			"db failed at CreateOrder",
 			zap.Any("order_input", order),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
 	out = in.toDomain()
@@ -170,7 +198,7 @@ func (o *ordersRepositoryImpl) CreateOrder(ctx context.Context, order *domain.Or
 	return out, nil
 }
 
-func (o *ordersRepositoryImpl) UpdateOrder(ctx context.Context, in *domain.Order) (*domain.Order, error) {
+func (o *ordersRepositoryImpl) UpdateOrder(ctx context.Context, in *domain.Order, events ...*domain.OutboxEvent) (*domain.Order, error) {
 	order := &Order{}
 	order.fromDomain(in)
 
@@ -181,38 +209,60 @@ func (o *ordersRepositoryImpl) UpdateOrder(ctx context.Context, in *domain.Order
 
 	var oS OrderStatus
 	order.Status = oS.fromDomain(in.Status)
-
-	if err := o.db.WithContext(ctx).Table(ordersTable).
-		Updates(&order).
-		Where("id = ?", in.ID).
-		Error; err != nil {
+	order.Version = in.Version + 1
+
+	if err := conn(ctx, o.db).Transaction(func(tx *gorm.DB) error {
+		// only the version that was read is updated, otherwise someone else changed the order meanwhile
+		result := tx.Table(ordersTable).
+			Where("id = ? AND version = ?", in.ID, in.Version).
+			Updates(&order)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helpers.NewVersionConflictError("order", in.ID, in.Version)
+		}
+		return insertOutboxEvents(tx, events)
+	}); err != nil {
+		if errors.Is(err, helpers.ErrVersionConflict) {
+			return nil, err
+		}
 		o.log.Errorw(
 			"db failed updating order",
 			zap.Any("in_order", in),
 			zap.Any("repo_order", order),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("order", err)
 	}
 
 	return order.toDomain(), nil
 }
 
-func (o *ordersRepositoryImpl) DeleteOrder(ctx context.Context, orderID uuid.UUID) error {
+func (o *ordersRepositoryImpl) DeleteOrder(ctx context.Context, orderID uuid.UUID, version int) error {
 	deletedAt := sql.NullTime{
 		Time:  time.Now(),
 		Valid: true,
 	}
-	if err := o.db.WithContext(ctx).Table(ordersTable).
-		UpdateColumn("deleted_at", deletedAt).
-		Where("order_id", orderID).
-		Error; err != nil {
+
+	result := conn(ctx, o.db).Table(ordersTable).
+		Where("id = ? AND version = ?", orderID, version).
+		UpdateColumns(map[string]any{
+			"deleted_at": deletedAt,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if err := result.Error; err != nil {
 		o.log.Errorw(
 			"db failed deleting order",
 			zap.String("order_id", orderID.String()),
 			zap.Error(err),
 		)
+		return translateError("order", err)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewVersionConflictError("order", orderID, version)
 	}
+
 	return nil
 }
 
@@ -222,7 +272,7 @@ func (o *ordersRepositoryImpl) SetOrderAsPaid(ctx context.Context, payment *doma
 	//	Valid: true,
 	//}
 
-	//if err = o.db.WithContext(ctx).Table(ordersTable).
+	//if err = conn(ctx, o.db).Table(ordersTable).
 	//	Where("id = ?", payment.OrderID).
 	//	UpdateColumns(map[string]any{
 	//		"status":     usecases.OrderPaidStatus,
@@ -237,5 +287,5 @@ func (o *ordersRepositoryImpl) SetOrderAsPaid(ctx context.Context, payment *doma
 	//	)
 	//}
 
-	return err
+	return translateError("order", err)
 }
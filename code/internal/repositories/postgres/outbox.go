@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const outboxTable = "lanchonete_outbox"
+
+type outboxRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxOutboxRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.OutboxRepository {
+	return &outboxRepositoryImpl{log: logger, db: db}
+}
+
+// insertOutboxEvents saves the events within the transaction of the change that raised them.
+func insertOutboxEvents(tx *gorm.DB, events []*domain.OutboxEvent) error {
+	for _, e := range events {
+		in := OutboxEvent{}
+		in.fromDomain(e)
+		if err := tx.Table(outboxTable).Create(&in).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPendingOutboxEvents lists the undelivered events due until the given time, oldest first.
+func (o *outboxRepositoryImpl) ListPendingOutboxEvents(ctx context.Context, until time.Time, limit int) ([]*domain.OutboxEvent, error) {
+	var saved []OutboxEvent
+
+	err := conn(ctx, o.db).Table(outboxTable).
+		Where("delivered_at IS NULL AND next_attempt_at <= ?", until).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Scan(&saved).Error
+	if err != nil {
+		o.log.Errorw(
+			"db failed listing pending outbox events",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	out := make([]*domain.OutboxEvent, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (o *outboxRepositoryImpl) MarkOutboxEventDispatched(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := conn(ctx, o.db).Table(outboxTable).
+		Where("id = ?", id).Update("dispatched_at", at).Error
+	if err != nil {
+		o.log.Errorw(
+			"db failed marking outbox event dispatched",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+	}
+
+	return err
+}
+
+func (o *outboxRepositoryImpl) MarkOutboxEventDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := conn(ctx, o.db).Table(outboxTable).
+		Where("id = ?", id).Update("delivered_at", at).Error
+	if err != nil {
+		o.log.Errorw(
+			"db failed marking outbox event delivered",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+	}
+
+	return err
+}
+
+func (o *outboxRepositoryImpl) MarkOutboxEventFailed(ctx context.Context, event *domain.OutboxEvent) error {
+	err := conn(ctx, o.db).Table(outboxTable).
+		Where("id = ?", event.ID).
+		UpdateColumns(map[string]any{
+			"attempts":        event.Attempts,
+			"next_attempt_at": event.NextAttemptAt,
+			"last_error":      event.LastError,
+		}).Error
+	if err != nil {
+		o.log.Errorw(
+			"db failed marking outbox event failed",
+			zap.String("id", event.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return err
+}
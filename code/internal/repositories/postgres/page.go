@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+// paged restricts query to the page. Offset pages keep offsetOrder, keyset pages go by (created_at, id),
+// after page.After, and fetch one extra row telling whether there is a next page, see nextPage.
+func paged(query *gorm.DB, page domain.Page, offsetOrder string) *gorm.DB {
+	if !page.Keyset {
+		return query.Order(offsetOrder).Limit(page.Limit).Offset(page.Offset)
+	}
+	if page.After != nil {
+		query = query.Where("(created_at, id) > (?, ?)", page.After.CreatedAt, page.After.ID)
+	}
+	return query.Order("created_at ASC, id ASC").Limit(page.Limit + 1)
+}
+
+// nextPage drops the extra row fetched by paged and returns the cursor of the page following rows, nil on
+// the last page and on offset pages.
+func nextPage[T any](rows []T, page domain.Page, cursor func(T) domain.Cursor) ([]T, *domain.Cursor) {
+	if !page.Keyset || len(rows) <= page.Limit {
+		return rows, nil
+	}
+	rows = rows[:page.Limit]
+	if len(rows) == 0 {
+		return rows, nil
+	}
+	next := cursor(rows[len(rows)-1])
+	return rows, &next
+}
@@ -2,6 +2,9 @@ package postgres
 
 import (
 	"context"
+	"errors"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
@@ -18,36 +21,51 @@ func (p paymentsRepositoryImpl) GetPayment(ctx context.Context, paymentID uuid.U
 	payment := new(Payment)
 
 	var err error
-	if err = p.db.WithContext(ctx).Table(paymentTable).
+	if err = conn(ctx, p.db).Table(paymentTable).
 		Select("*").
 		Where("id = ?", paymentID).
 		First(payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("payment", paymentID)
+		}
 		p.log.Errorw(
 			"db failed getting payment",
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("payment", err)
 	}
 
 	out := payment.toDomain()
 	return out, err
 }
 
-func (p paymentsRepositoryImpl) UpdatePayment(ctx context.Context, in *domain.Payment) (*domain.Payment, error) {
+func (p paymentsRepositoryImpl) UpdatePayment(ctx context.Context, in *domain.Payment, events ...*domain.OutboxEvent) (*domain.Payment, error) {
 	payment := new(Payment)
 	payment.fromDomain(in)
+	payment.Version = in.Version + 1
 
-	if err := p.db.WithContext(ctx).Table(paymentTable).
-		Updates(&payment).
-		Where("id = ?", in.ID).
-		Error; err != nil {
+	if err := conn(ctx, p.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(paymentTable).
+			Where("id = ? AND version = ?", in.ID, in.Version).
+			Updates(&payment)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helpers.NewVersionConflictError("payment", in.ID, in.Version)
+		}
+		return insertOutboxEvents(tx, events)
+	}); err != nil {
+		if errors.Is(err, helpers.ErrVersionConflict) {
+			return nil, err
+		}
 		p.log.Errorw(
 			"db failed updating payment",
 			zap.Any("in_payment", in),
 			zap.Any("repo_payment", payment),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("payment", err)
 	}
 
 	return payment.toDomain(), nil
@@ -58,14 +76,15 @@ const paymentTable = "lanchonete_payments"
 func (p paymentsRepositoryImpl) CreatePayment(ctx context.Context, in *domain.Payment) (*domain.Payment, error) {
 	payment := new(Payment)
 	payment.fromDomain(in)
+	payment.Version = 1
 
-	if err := p.db.WithContext(ctx).Table(paymentTable).Create(&payment).Error; err != nil {
+	if err := conn(ctx, p.db).Table(paymentTable).Create(&payment).Error; err != nil {
 		p.log.Errorw(
 			"db failed at CreatePayment",
 			zap.Any("payment_input", in),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("payment", err)
 	}
 
 	out := new(domain.Payment)
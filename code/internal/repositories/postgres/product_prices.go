@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const productPricesTable = "lanchonete_product_prices"
+
+type productPricesRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxProductPricesRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.ProductPricesRepository {
+	return &productPricesRepositoryImpl{
+		log: logger,
+		db:  db,
+	}
+}
+
+func (p *productPricesRepositoryImpl) InsertProductPrice(ctx context.Context, in *domain.ProductPrice) (*domain.ProductPrice, error) {
+	price := ProductPrice{}
+	price.fromDomain(in)
+
+	if err := conn(ctx, p.db).Table(productPricesTable).Create(&price).Error; err != nil {
+		p.log.Errorw(
+			"db failed inserting product price",
+			zap.Any("in_price", in),
+			zap.Error(err),
+		)
+		return nil, translateError("product price", err)
+	}
+
+	return price.toDomain(), nil
+}
+
+func (p *productPricesRepositoryImpl) ListProductPrices(ctx context.Context, productID uuid.UUID) ([]*domain.ProductPrice, error) {
+	var prices []ProductPrice
+
+	if err := conn(ctx, p.db).Table(productPricesTable).
+		Where("product_id = ?", productID).
+		Order("effective_from DESC").
+		Find(&prices).Error; err != nil {
+		p.log.Errorw(
+			"db failed listing product prices",
+			zap.String("product_id", productID.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("product price", err)
+	}
+
+	out := make([]*domain.ProductPrice, 0, len(prices))
+	for _, v := range prices {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (p *productPricesRepositoryImpl) GetProductPriceAt(ctx context.Context, productID uuid.UUID, at time.Time) (*domain.ProductPrice, error) {
+	price := ProductPrice{}
+
+	if err := conn(ctx, p.db).Table(productPricesTable).
+		Where("product_id = ? AND effective_from <= ?", productID, at).
+		Order("effective_from DESC").
+		First(&price).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("product price", uuid.Nil)
+		}
+		p.log.Errorw(
+			"db failed getting product price",
+			zap.String("product_id", productID.String()),
+			zap.Time("at", at),
+			zap.Error(err),
+		)
+		return nil, translateError("product price", err)
+	}
+
+	return price.toDomain(), nil
+}
+
+func (p *productPricesRepositoryImpl) ListDueProductPrices(ctx context.Context, until time.Time) ([]*domain.ProductPrice, error) {
+	var prices []ProductPrice
+
+	// prices of deleted products are left pending, to be applied if the product is restored
+	if err := conn(ctx, p.db).Table(productPricesTable).
+		Where("applied_at IS NULL AND effective_from <= ?", until).
+		Where("product_id IN (SELECT id FROM " + productsTable + " WHERE deleted_at IS NULL)").
+		Order("effective_from ASC").
+		Find(&prices).Error; err != nil {
+		p.log.Errorw(
+			"db failed listing due product prices",
+			zap.Time("until", until),
+			zap.Error(err),
+		)
+		return nil, translateError("product price", err)
+	}
+
+	out := make([]*domain.ProductPrice, 0, len(prices))
+	for _, v := range prices {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (p *productPricesRepositoryImpl) SetProductPriceApplied(ctx context.Context, id uuid.UUID, appliedAt time.Time) error {
+	if err := conn(ctx, p.db).Table(productPricesTable).
+		Where("id = ?", id).
+		UpdateColumn("applied_at", sql.NullTime{Time: appliedAt, Valid: true}).
+		Error; err != nil {
+		p.log.Errorw(
+			"db failed setting product price as applied",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		return translateError("product price", err)
+	}
+
+	return nil
+}
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
@@ -27,9 +29,9 @@ func (p *productsRepositoryImpl) GetProductsPriceSumByID(ctx context.Context, id
 	}
 	var itemsAndPrices []IDAndPrice
 
-	if err := p.db.WithContext(ctx).Table(productsTable).
+	if err := conn(ctx, p.db).Table(productsTable).
 		Select("id, price").
-		Where("id IN (?)", ids).
+		Where("id IN (?) AND deleted_at IS NULL", ids).
 		Scan(&itemsAndPrices).
 		Error; err != nil {
 		p.log.Errorw(
@@ -37,7 +39,7 @@ func (p *productsRepositoryImpl) GetProductsPriceSumByID(ctx context.Context, id
 			zap.Any("ids", ids),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("product", err)
 	}
 
 	prodsSum := &domain.ProductsSum{
@@ -65,14 +67,37 @@ func NewPgxProductsRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.Prod
 func (p *productsRepositoryImpl) GetProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
 	out := Product{}
 
-	if err := p.db.WithContext(ctx).Table(productsTable).
-		Select("*").Where("id = ?", id).First(&out).Error; err != nil {
+	if err := conn(ctx, p.db).Table(productsTable).
+		Select("*").Where("id = ? AND deleted_at IS NULL", id).First(&out).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("product", id)
+		}
 		p.log.Errorw(
 			"db failed getting product",
 			zap.String("id", id.String()),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("product", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+func (p *productsRepositoryImpl) GetProductByName(ctx context.Context, categoryID uuid.UUID, name string) (*domain.Product, error) {
+	out := Product{}
+
+	if err := conn(ctx, p.db).Table(productsTable).
+		Select("*").Where("category_id = ? AND name = ? AND deleted_at IS NULL", categoryID, name).First(&out).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("product", uuid.Nil)
+		}
+		p.log.Errorw(
+			"db failed getting product by name",
+			zap.String("category_id", categoryID.String()),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		return nil, translateError("product", err)
 	}
 
 	return out.toDomain(), nil
@@ -81,14 +106,15 @@ func (p *productsRepositoryImpl) GetProduct(ctx context.Context, id uuid.UUID) (
 func (p *productsRepositoryImpl) InsertProduct(ctx context.Context, in *domain.Product) (*domain.Product, error) {
 	product := Product{}
 	product.fromDomain(in)
+	product.Version = 1
 
-	if err := p.db.WithContext(ctx).Table(productsTable).Create(&product).Error; err != nil {
+	if err := conn(ctx, p.db).Table(productsTable).Create(&product).Error; err != nil {
 		p.log.Errorw(
 			"db failed inserting product",
 			zap.Any("in_product", in),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("product", err)
 	}
 
 	return product.toDomain(), nil
@@ -102,68 +128,203 @@ func (p *productsRepositoryImpl) UpdateProduct(ctx context.Context, in *domain.P
 		Time:  time.Now(),
 		Valid: true,
 	}
+	product.Version = in.Version + 1
 
-	if err := p.db.WithContext(ctx).Table(productsTable).Updates(&product).Where("id = ?", in.ID).Error; err != nil {
+	result := conn(ctx, p.db).Table(productsTable).
+		Where("id = ? AND version = ?", in.ID, in.Version).
+		Updates(&product)
+	if err := result.Error; err != nil {
 		p.log.Errorw(
 			"db failed updating product",
 			zap.Any("in_product", in),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, translateError("product", err)
+	}
+	if result.RowsAffected == 0 {
+		return nil, helpers.NewVersionConflictError("product", in.ID, in.Version)
 	}
 
 	return product.toDomain(), nil
 }
 
 func (p *productsRepositoryImpl) DeleteProduct(ctx context.Context, id uuid.UUID) error {
-	product := Product{ID: id}
-	if err := p.db.WithContext(ctx).Table(productsTable).Delete(&product).Error; err != nil {
+	deletedAt := sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	}
+
+	result := conn(ctx, p.db).Table(productsTable).
+		Where("id = ? AND deleted_at IS NULL", id).
+		UpdateColumns(map[string]any{
+			"deleted_at": deletedAt,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if err := result.Error; err != nil {
 		p.log.Errorw(
 			"failed deleting product",
 			zap.String("product_id", id.String()),
 			zap.Error(err),
 		)
-		return err
+		return translateError("product", err)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("product", id)
 	}
 
 	return nil
 }
 
-func (p *productsRepositoryImpl) ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) (*domain.ProductList, error) {
-	var products []Product
-	var total int64
+func (p *productsRepositoryImpl) DeleteProductsByCategory(ctx context.Context, categoryID uuid.UUID, deletedAt time.Time) error {
+	if err := conn(ctx, p.db).Table(productsTable).
+		Where("category_id = ? AND deleted_at IS NULL", categoryID).
+		UpdateColumns(map[string]any{
+			"deleted_at": sql.NullTime{Time: deletedAt, Valid: true},
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		p.log.Errorw(
+			"failed deleting products by category",
+			zap.String("category_id", categoryID.String()),
+			zap.Error(err),
+		)
+		return translateError("product", err)
+	}
 
-	err := p.db.WithContext(ctx).Table(productsTable).
-		Where("category_id = ?", categoryID).Limit(limit).Offset(offset).Order("name ASC").Find(&products).Error
-	if err != nil {
+	return nil
+}
+
+// RestoreProductsByCategory restores the products of the category deleted at deletedAt, the ones deleted
+// along with it, leaving the products deleted on their own before.
+func (p *productsRepositoryImpl) RestoreProductsByCategory(ctx context.Context, categoryID uuid.UUID, deletedAt time.Time) error {
+	if err := conn(ctx, p.db).Table(productsTable).
+		Where("category_id = ? AND deleted_at = ?", categoryID, deletedAt).
+		UpdateColumns(map[string]any{
+			"deleted_at": sql.NullTime{},
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
 		p.log.Errorw(
-			"failed listing products",
-			zap.String("category", categoryID.String()),
+			"failed restoring products by category",
+			zap.String("category_id", categoryID.String()),
 			zap.Error(err),
 		)
-		return nil, err
+		return translateError("product", err)
 	}
 
-	if err = p.db.WithContext(ctx).Table(productsTable).
-		Where("category_id = ?", categoryID).
+	return nil
+}
+
+func (p *productsRepositoryImpl) ReassignProductsCategory(ctx context.Context, fromCategoryID, toCategoryID uuid.UUID) error {
+	if err := conn(ctx, p.db).Table(productsTable).
+		Where("category_id = ? AND deleted_at IS NULL", fromCategoryID).
+		UpdateColumns(map[string]any{
+			"category_id": toCategoryID,
+			"updated_at":  sql.NullTime{Time: time.Now(), Valid: true},
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		p.log.Errorw(
+			"failed reassigning products category",
+			zap.String("from_category_id", fromCategoryID.String()),
+			zap.String("to_category_id", toCategoryID.String()),
+			zap.Error(err),
+		)
+		return translateError("product", err)
+	}
+
+	return nil
+}
+
+func (p *productsRepositoryImpl) CountProductsByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	var total int64
+
+	if err := conn(ctx, p.db).Table(productsTable).
+		Where("category_id = ? AND deleted_at IS NULL", categoryID).
 		Count(&total).Error; err != nil {
 		p.log.Errorw(
 			"failed counting products by category id",
+			zap.String("category_id", categoryID.String()),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (p *productsRepositoryImpl) RestoreProduct(ctx context.Context, id uuid.UUID) error {
+	result := conn(ctx, p.db).Table(productsTable).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]any{
+			"deleted_at": sql.NullTime{},
+			"version":    gorm.Expr("version + 1"),
+		})
+	if err := result.Error; err != nil {
+		p.log.Errorw(
+			"failed restoring product",
+			zap.String("product_id", id.String()),
+			zap.Error(err),
+		)
+		return translateError("product", err)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("product", id)
+	}
+
+	return nil
+}
+
+func (p *productsRepositoryImpl) ListProductsByCategory(ctx context.Context, categoryID uuid.UUID, page domain.Page, filter domain.ProductFilter) (*domain.ProductList, error) {
+	var products []Product
+
+	query := conn(ctx, p.db).Table(productsTable).
+		Where("category_id = ?", categoryID)
+	if !filter.IncludeDeleted {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if len(filter.ExcludeAllergens) > 0 {
+		allergens := make([]string, 0, len(filter.ExcludeAllergens))
+		for _, a := range filter.ExcludeAllergens {
+			allergens = append(allergens, string(a))
+		}
+		query = query.Where("NOT EXISTS (SELECT 1 FROM jsonb_array_elements_text(allergens) a WHERE a IN ?)", allergens)
+	}
+
+	err := paged(query.Session(&gorm.Session{}), page, "name ASC").Find(&products).Error
+	if err != nil {
+		p.log.Errorw(
+			"failed listing products",
 			zap.String("category", categoryID.String()),
 			zap.Error(err),
 		)
+		return nil, translateError("product", err)
 	}
 
 	pList := &domain.ProductList{}
+	if page.CountTotal {
+		var total int64
+		if err = query.Session(&gorm.Session{}).
+			Count(&total).Error; err != nil {
+			p.log.Errorw(
+				"failed counting products by category id",
+				zap.String("category", categoryID.String()),
+				zap.Error(err),
+			)
+		}
+		pList.Total = &total
+	}
+
+	products, pList.Next = nextPage(products, page, productCursor)
 	out := make([]*domain.Product, 0, len(products))
 	for _, v := range products {
 		out = append(out, v.toDomain())
 	}
 
 	pList.Products = out
-	pList.Total = total
-	pList.Limit = limit
-	pList.Offset = offset
+	pList.Limit = page.Limit
+	pList.Offset = page.Offset
 
 	return pList, err
 }
+
+func productCursor(product Product) domain.Cursor {
+	return domain.Cursor{CreatedAt: product.CreatedAt, ID: product.ID}
+}
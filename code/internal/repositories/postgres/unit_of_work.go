@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"gorm.io/gorm"
+)
+
+type txContextKey struct{}
+
+type unitOfWorkImpl struct {
+	db *gorm.DB
+}
+
+// NewPgxUnitOfWork runs use case steps in one Postgres transaction. The transaction travels in the context,
+// every repository of this package joins it through conn.
+func NewPgxUnitOfWork(db *gorm.DB) ports.UnitOfWork {
+	return &unitOfWorkImpl{db: db}
+}
+
+func (u *unitOfWorkImpl) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// a unit of work within another one runs in a savepoint: a failure only rolls back its own steps, and the
+	// rest is committed or rolled back with the outer one
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txContextKey{}, tx))
+		})
+	}
+
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// conn is the transaction of the unit of work running in ctx, if any, or else db.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
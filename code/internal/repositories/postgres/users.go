@@ -2,15 +2,25 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
 
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-const userTable = "lanchonete_users"
+const (
+	userTable      = "lanchonete_users"
+	userRolesTable = "lanchonete_user_roles"
+	consentsTable  = "lanchonete_user_consents"
+)
 
 type usersRepositoryImpl struct {
 	log *zap.SugaredLogger
@@ -26,27 +36,125 @@ func NewPgxUsersRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.UsersRe
 
 func (u usersRepositoryImpl) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	repUser := User{}
-	err := u.db.WithContext(ctx).Table(userTable).
-		Select("*").Where("id = ?", id).First(repUser).Error
+	err := conn(ctx, u.db).Table(userTable).
+		Select("*").Where("id = ? AND deleted_at IS NULL", id).First(&repUser).Error
 	if err != nil {
 		u.log.Errorw(
 			"failed getting user",
 			zap.String("id", id.String()),
 			zap.Error(err),
 		)
-		return nil, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("user", id)
+		}
+		return nil, translateError("user", err)
+	}
+
+	return repUser.toDomain(), nil
+}
+
+func (u usersRepositoryImpl) GetUserByDocument(ctx context.Context, document string) (*domain.User, error) {
+	repUser := User{}
+	err := conn(ctx, u.db).Table(userTable).
+		Select("*").Where("document = ?", document).First(&repUser).Error
+	if err != nil {
+		u.log.Errorw(
+			"failed getting user by document",
+			zap.String("document", document),
+			zap.Error(err),
+		)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("user", uuid.Nil)
+		}
+		return nil, translateError("user", err)
+	}
+
+	return repUser.toDomain(), nil
+}
+
+func (u usersRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	repUser := User{}
+	err := conn(ctx, u.db).Table(userTable).
+		Select("*").Where("lower(email) = lower(?)", email).First(&repUser).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("user", uuid.Nil)
+		}
+		u.log.Errorw(
+			"failed getting user by email",
+			zap.String("email", email),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
 	}
 
 	return repUser.toDomain(), nil
+}
+
+// ListUsers matches the search against the name, the email or, when it has digits, the document.
+func (u usersRepositoryImpl) ListUsers(ctx context.Context, limit, offset int, search string) (*domain.UserList, error) {
+	var total int64
+	var savedUsers []User
+
+	query := conn(ctx, u.db).Table(userTable).Where("deleted_at IS NULL")
+	if search != "" {
+		pattern := "%" + search + "%"
+		if digits := onlyDigits(search); digits != "" {
+			query = query.Where("name ILIKE ? OR email ILIKE ? OR document LIKE ?", pattern, pattern, "%"+digits+"%")
+		} else {
+			query = query.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+		}
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Order("name ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&savedUsers).Error; err != nil {
+		u.log.Errorw(
+			"failed listing users",
+			zap.String("search", search),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
+
+	// counted apart, the count would otherwise take the limit and offset of the page
+	if err := query.Session(&gorm.Session{}).
+		Count(&total).Error; err != nil {
+		u.log.Errorw(
+			"failed counting users",
+			zap.String("search", search),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
 
+	out := &domain.UserList{}
+	outList := make([]*domain.User, 0, len(savedUsers))
+	for _, v := range savedUsers {
+		outList = append(outList, v.toDomain())
+	}
+
+	out.Users = outList
+	out.Total = total
+	out.Limit = limit
+	out.Offset = offset
+
+	return out, nil
 }
 
 func (u usersRepositoryImpl) InsertUser(ctx context.Context, user *domain.User) error {
 	repUser := User{}
 	repUser.fromDomain(user)
 
-	err := u.db.WithContext(ctx).Table(userTable).
-		Create(&repUser).Error
+	// every user is a customer, other roles are granted later
+	err := conn(ctx, u.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(userTable).Create(&repUser).Error; err != nil {
+			return err
+		}
+		return grantUserRole(tx, user.ID, domain.ROLE_CUSTOMER, uuid.Nil)
+	})
 	if err != nil {
 		u.log.Errorw(
 			"failed inserting user",
@@ -55,12 +163,35 @@ func (u usersRepositoryImpl) InsertUser(ctx context.Context, user *domain.User)
 		)
 	}
 
-	return err
+	return translateError("user", err)
+}
+
+func (u usersRepositoryImpl) UpdateUser(ctx context.Context, user *domain.User) error {
+	result := conn(ctx, u.db).Table(userTable).
+		Where("id = ? AND deleted_at IS NULL", user.ID).
+		Updates(map[string]interface{}{
+			"name":       user.Name,
+			"email":      user.Email,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		u.log.Errorw(
+			"failed updating user",
+			zap.String("id", user.ID.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("user", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("user", user.ID)
+	}
+
+	return nil
 }
 
 func (u usersRepositoryImpl) ValidateUser(ctx context.Context, document string) (uuid.UUID, error) {
 	var user User
-	err := u.db.WithContext(ctx).Table(userTable).
+	err := conn(ctx, u.db).Table(userTable).
 		Select("*").Where("document = ?", document).First(&user).Error
 	if err != nil {
 		u.log.Errorw(
@@ -68,25 +199,230 @@ func (u usersRepositoryImpl) ValidateUser(ctx context.Context, document string)
 			zap.String("document", document),
 			zap.Error(err),
 		)
-		return uuid.Nil, err
+		return uuid.Nil, translateError("user", err)
 	}
 
 	return user.ID, nil
 }
 
-func (u usersRepositoryImpl) IsUserAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
-	var isAdmin bool
+func (u usersRepositoryImpl) ListUserRoles(ctx context.Context, id uuid.UUID) ([]domain.Role, error) {
+	var roles []string
+
+	err := conn(ctx, u.db).Table(userRolesTable).
+		Select("role").Where("user_id = ?", id).Order("role").Scan(&roles).Error
+	if err != nil {
+		u.log.Errorw(
+			"failed listing user roles",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
+
+	out := make([]domain.Role, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, domain.Role(r))
+	}
+
+	return out, nil
+}
 
-	err := u.db.WithContext(ctx).Table(userTable).
-		Select("is_admin").Where("id = ?", id).Scan(&isAdmin).Error
+func (u usersRepositoryImpl) GrantUserRole(ctx context.Context, id uuid.UUID, role domain.Role, grantedBy uuid.UUID) error {
+	err := grantUserRole(conn(ctx, u.db), id, role, grantedBy)
 	if err != nil {
 		u.log.Errorw(
-			"failed checking user admin status",
-			zap.String("document", id.String()),
+			"failed granting user role",
+			zap.String("id", id.String()),
+			zap.String("role", string(role)),
 			zap.Error(err),
 		)
-		return false, err
 	}
 
-	return isAdmin, nil
+	return translateError("user", err)
+}
+
+func (u usersRepositoryImpl) RevokeUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error {
+	result := conn(ctx, u.db).Table(userRolesTable).
+		Where("user_id = ? AND role = ?", id, string(role)).Delete(&UserRole{})
+	if result.Error != nil {
+		u.log.Errorw(
+			"failed revoking user role",
+			zap.String("id", id.String()),
+			zap.String("role", string(role)),
+			zap.Error(result.Error),
+		)
+		return translateError("user", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("user role", id)
+	}
+
+	return nil
+}
+
+// AnonymizeUser erases the personal data of the user, keeping the row for the foreign keys. Their orders
+// are detached, the guest sessions merged into the account lose their CPF, and the roles are revoked.
+func (u usersRepositoryImpl) AnonymizeUser(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+
+	err := conn(ctx, u.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(userTable).
+			Where("id = ? AND anonymized_at IS NULL", id).
+			Updates(map[string]interface{}{
+				"name":          domain.ANONYMIZED_USER_NAME,
+				"document":      nil,
+				"email":         nil,
+				"password_hash": nil,
+				"updated_at":    now,
+				"deleted_at":    now,
+				"anonymized_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return helpers.NewNotFoundError("user", id)
+		}
+
+		if err := tx.Table(ordersTable).
+			Where("user_id = ?", id).
+			Updates(map[string]interface{}{"user_id": nil, "guest_session_id": nil, "detached_at": now}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table(guestSessionsTable).
+			Where("merged_into = ?", id).
+			Updates(map[string]interface{}{"document": nil, "merged_into": nil}).Error; err != nil {
+			return err
+		}
+
+		return tx.Table(userRolesTable).Where("user_id = ?", id).Delete(&UserRole{}).Error
+	})
+	if err != nil {
+		u.log.Errorw(
+			"failed anonymizing user",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("user", err)
+}
+
+func (u usersRepositoryImpl) InsertUserConsents(ctx context.Context, consents []*domain.Consent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+
+	in := make([]UserConsent, len(consents))
+	for i, c := range consents {
+		in[i].fromDomain(c)
+	}
+
+	err := conn(ctx, u.db).Table(consentsTable).Create(&in).Error
+	if err != nil {
+		u.log.Errorw(
+			"failed inserting user consents",
+			zap.String("user_id", consents[0].UserID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("user", err)
+}
+
+func (u usersRepositoryImpl) ListUserConsents(ctx context.Context, id uuid.UUID) ([]*domain.Consent, error) {
+	var saved []UserConsent
+
+	err := conn(ctx, u.db).Table(consentsTable).
+		Where("user_id = ?", id).Order("created_at ASC").Scan(&saved).Error
+	if err != nil {
+		u.log.Errorw(
+			"failed listing user consents",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
+
+	out := make([]*domain.Consent, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+// ListEligibleContacts lists the active users whose latest consent record for the purpose grants it.
+func (u usersRepositoryImpl) ListEligibleContacts(ctx context.Context, purpose domain.ConsentPurpose, limit, offset int) (*domain.ContactList, error) {
+	var total int64
+	var saved []Contact
+
+	latest := u.db.Table(consentsTable).
+		Select("DISTINCT ON (user_id) user_id, granted").
+		Where("purpose = ?", string(purpose)).
+		Order("user_id, created_at DESC")
+
+	query := conn(ctx, u.db).Table(userTable+" u").
+		Joins("JOIN (?) c ON c.user_id = u.id", latest).
+		Where("c.granted AND u.deleted_at IS NULL AND u.email IS NOT NULL")
+
+	if err := query.Session(&gorm.Session{}).
+		Select("u.id, u.name, u.email").
+		Order("u.name ASC, u.id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&saved).Error; err != nil {
+		u.log.Errorw(
+			"failed listing eligible contacts",
+			zap.String("purpose", string(purpose)),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
+
+	// counted apart, the count would otherwise take the limit and offset of the page
+	if err := query.Session(&gorm.Session{}).
+		Count(&total).Error; err != nil {
+		u.log.Errorw(
+			"failed counting eligible contacts",
+			zap.String("purpose", string(purpose)),
+			zap.Error(err),
+		)
+		return nil, translateError("user", err)
+	}
+
+	out := &domain.ContactList{}
+	outList := make([]*domain.Contact, 0, len(saved))
+	for _, v := range saved {
+		outList = append(outList, v.toDomain())
+	}
+
+	out.Contacts = outList
+	out.Total = total
+	out.Limit = limit
+	out.Offset = offset
+
+	return out, nil
+}
+
+// grantUserRole is a no-op when the user already has the role.
+func grantUserRole(db *gorm.DB, id uuid.UUID, role domain.Role, grantedBy uuid.UUID) error {
+	userRole := UserRole{
+		UserID:    id,
+		Role:      string(role),
+		GrantedAt: time.Now(),
+		GrantedBy: uuid.NullUUID{UUID: grantedBy, Valid: grantedBy != uuid.Nil},
+	}
+
+	return db.Table(userRolesTable).Clauses(clause.OnConflict{DoNothing: true}).Create(&userRole).Error
+}
+
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
 }
@@ -0,0 +1,263 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookSubscriptionsTable = "lanchonete_webhook_subscriptions"
+	webhookDeliveriesTable    = "lanchonete_webhook_deliveries"
+)
+
+type webhooksRepositoryImpl struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func NewPgxWebhooksRepository(db *gorm.DB, logger *zap.SugaredLogger) ports.WebhooksRepository {
+	return &webhooksRepositoryImpl{log: logger, db: db}
+}
+
+func (w *webhooksRepositoryImpl) InsertWebhookSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	in := WebhookSubscription{}
+	in.fromDomain(subscription)
+
+	err := conn(ctx, w.db).Table(webhookSubscriptionsTable).Create(&in).Error
+	if err != nil {
+		w.log.Errorw(
+			"db failed inserting webhook subscription",
+			zap.String("url", subscription.URL),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("webhook subscription", err)
+}
+
+func (w *webhooksRepositoryImpl) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*domain.WebhookSubscription, error) {
+	out := WebhookSubscription{}
+
+	err := conn(ctx, w.db).Table(webhookSubscriptionsTable).
+		Where("id = ?", id).First(&out).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("webhook subscription", id)
+		}
+		w.log.Errorw(
+			"db failed getting webhook subscription",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+func (w *webhooksRepositoryImpl) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return w.listWebhookSubscriptions(ctx, conn(ctx, w.db).Table(webhookSubscriptionsTable).Where("deleted_at IS NULL"))
+}
+
+func (w *webhooksRepositoryImpl) ListWebhookSubscriptionsByEventType(ctx context.Context, eventType domain.EventType) ([]*domain.WebhookSubscription, error) {
+	contains, _ := json.Marshal([]string{string(eventType)})
+
+	return w.listWebhookSubscriptions(ctx, conn(ctx, w.db).Table(webhookSubscriptionsTable).
+		Where("deleted_at IS NULL AND event_types @> ?::jsonb", string(contains)))
+}
+
+func (w *webhooksRepositoryImpl) listWebhookSubscriptions(ctx context.Context, query *gorm.DB) ([]*domain.WebhookSubscription, error) {
+	var saved []WebhookSubscription
+
+	if err := query.Order("created_at ASC").Scan(&saved).Error; err != nil {
+		w.log.Errorw(
+			"db failed listing webhook subscriptions",
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	out := make([]*domain.WebhookSubscription, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (w *webhooksRepositoryImpl) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := conn(ctx, w.db).Table(webhookSubscriptionsTable).
+		Where("id = ? AND deleted_at IS NULL", id).Update("deleted_at", at)
+	if result.Error != nil {
+		w.log.Errorw(
+			"db failed deleting webhook subscription",
+			zap.String("id", id.String()),
+			zap.Error(result.Error),
+		)
+		return translateError("webhook subscription", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return helpers.NewNotFoundError("webhook subscription", id)
+	}
+
+	return nil
+}
+
+func (w *webhooksRepositoryImpl) InsertWebhookDeliveries(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	in := make([]WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		var delivery WebhookDelivery
+		delivery.fromDomain(d)
+		in = append(in, delivery)
+	}
+
+	// the same event may be relayed again, a subscription receives it only once
+	err := conn(ctx, w.db).Table(webhookDeliveriesTable).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}}, DoNothing: true}).
+		Create(&in).Error
+	if err != nil {
+		w.log.Errorw(
+			"db failed inserting webhook deliveries",
+			zap.String("event_id", deliveries[0].Envelope.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("webhook subscription", err)
+}
+
+func (w *webhooksRepositoryImpl) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	out := WebhookDelivery{}
+
+	err := conn(ctx, w.db).Table(webhookDeliveriesTable).
+		Where("id = ?", id).First(&out).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, helpers.NewNotFoundError("webhook delivery", id)
+		}
+		w.log.Errorw(
+			"db failed getting webhook delivery",
+			zap.String("id", id.String()),
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	return out.toDomain(), nil
+}
+
+func (w *webhooksRepositoryImpl) ListDueWebhookDeliveries(ctx context.Context, until time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var saved []WebhookDelivery
+
+	err := conn(ctx, w.db).Table(webhookDeliveriesTable).
+		Where("status = ? AND next_attempt_at <= ?", domain.WEBHOOK_DELIVERY_PENDING, until).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Scan(&saved).Error
+	if err != nil {
+		w.log.Errorw(
+			"db failed listing due webhook deliveries",
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	out := make([]*domain.WebhookDelivery, 0, len(saved))
+	for _, v := range saved {
+		out = append(out, v.toDomain())
+	}
+
+	return out, nil
+}
+
+func (w *webhooksRepositoryImpl) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter, limit, offset int) (*domain.WebhookDeliveryList, error) {
+	var total int64
+	var saved []WebhookDelivery
+
+	query := conn(ctx, w.db).Table(webhookDeliveriesTable)
+	if filter.SubscriptionID != uuid.Nil {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventID != uuid.Nil {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&saved).Error; err != nil {
+		w.log.Errorw(
+			"db failed listing webhook deliveries",
+			zap.Any("filter", filter),
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	// counted apart, the count would otherwise take the limit and offset of the page
+	if err := query.Session(&gorm.Session{}).
+		Count(&total).Error; err != nil {
+		w.log.Errorw(
+			"db failed counting webhook deliveries",
+			zap.Any("filter", filter),
+			zap.Error(err),
+		)
+		return nil, translateError("webhook subscription", err)
+	}
+
+	out := &domain.WebhookDeliveryList{}
+	deliveries := make([]*domain.WebhookDelivery, 0, len(saved))
+	for _, v := range saved {
+		deliveries = append(deliveries, v.toDomain())
+	}
+
+	out.Deliveries = deliveries
+	out.Total = total
+	out.Limit = limit
+	out.Offset = offset
+
+	return out, nil
+}
+
+func (w *webhooksRepositoryImpl) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	in := WebhookDelivery{}
+	in.fromDomain(delivery)
+
+	err := conn(ctx, w.db).Table(webhookDeliveriesTable).
+		Where("id = ?", delivery.ID).
+		UpdateColumns(map[string]any{
+			"status":           in.Status,
+			"attempts":         in.Attempts,
+			"next_attempt_at":  in.NextAttemptAt,
+			"last_error":       in.LastError,
+			"last_status_code": in.LastStatusCode,
+			"delivered_at":     in.DeliveredAt,
+		}).Error
+	if err != nil {
+		w.log.Errorw(
+			"db failed updating webhook delivery",
+			zap.String("id", delivery.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return translateError("webhook subscription", err)
+}
@@ -3,21 +3,42 @@ package setup
 import (
 	"context"
 	"embed"
+	"errors"
 	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/broker"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
 	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/usecases"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/events"
 	httphandlers "github.com/SOAT1StackGoLang/tech-challenge/internal/handlers/http"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/mail"
 	pgxrepo "github.com/SOAT1StackGoLang/tech-challenge/internal/repositories/postgres"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/tokens"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/webhooks"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/go-openapi/spec"
 	"github.com/joho/godotenv"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+const (
+	scheduledPricesInterval = time.Minute
+	outboxRelayInterval     = time.Second
+	webhookDeliveryInterval = 5 * time.Second
+	webhookSendTimeout      = 10 * time.Second
+	eventBusBufferSize      = 100
+	shutdownTimeout         = 10 * time.Second
+)
+
 var (
 	binding    string
 	log        = helpers.NewLogger()
@@ -29,6 +50,11 @@ func init() {
 	flag.Parse()
 	godotenv.Load()
 	helpers.ReadPgxConnEnvs()
+	if err := helpers.ReadAuthEnvs(); err != nil {
+		panic(err)
+	}
+	helpers.ReadMailEnvs()
+	helpers.ReadBrokerEnvs()
 	connString = helpers.ToDsnWithDbName()
 }
 
@@ -39,9 +65,11 @@ func init() {
 var swaggerUI embed.FS
 
 func SetupCode() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	gormDB, err := gorm.Open(postgres.Open(connString), &gorm.Config{
+		// single statements need no transaction, use cases spanning several open one with the unit of work
 		SkipDefaultTransaction: true,
 	})
 
@@ -49,40 +77,202 @@ func SetupCode() {
 		panic(err)
 	}
 
+	auditRepo := pgxrepo.NewPgxAuditRepository(gormDB, log)
+	uow := pgxrepo.NewPgxUnitOfWork(gormDB)
+	bus := events.NewInMemoryBus(log, eventBusBufferSize)
+	messageBroker := newMessageBroker()
+
 	userRepo := pgxrepo.NewPgxUsersRepository(gormDB, log)
-	userUseCase := usecases.NewUsersUseCase(userRepo, log)
+	guestRepo := pgxrepo.NewPgxGuestSessionsRepository(gormDB, log)
+	userUseCase := usecases.NewUsersUseCase(userRepo, guestRepo, auditRepo, uow, log)
+	codesRepo := pgxrepo.NewPgxLoginCodesRepository(gormDB, log)
+	mailSender := mail.NewSMTPSender(helpers.SMTPHost(), helpers.SMTPPort(), helpers.SMTPFrom(), helpers.SMTPUser(), helpers.SMTPPassword())
+	authUseCase := usecases.NewAuthUseCase(log, userRepo, guestRepo, codesRepo, mailSender, tokens.NewJWTSigner(helpers.TokenSecret(), helpers.TokenTTL()))
 
 	catRepo := pgxrepo.NewPgxCategoriesRepository(gormDB, log)
-	catUseCase := usecases.NewCategoriesUseCase(log, catRepo, userUseCase)
-
 	prodRepo := pgxrepo.NewPgxProductsRepository(gormDB, log)
-	prodUseCase := usecases.NewProductsUseCase(prodRepo, userUseCase, log)
+	catUseCase := usecases.NewCategoriesUseCase(log, catRepo, prodRepo, auditRepo, uow, userUseCase)
+
+	priceRepo := pgxrepo.NewPgxProductPricesRepository(gormDB, log)
+	prodUseCase := usecases.NewProductsUseCase(prodRepo, priceRepo, auditRepo, uow, userUseCase, log)
+	go applyScheduledPrices(ctx, prodUseCase)
 
 	paymentRepo := pgxrepo.NewPaymentsRepository(log, gormDB)
-	paymenteUseCase := usecases.NewPaymentsUseCase(log, paymentRepo)
+	paymenteUseCase := usecases.NewPaymentsUseCase(log, paymentRepo, auditRepo, uow, userUseCase)
 
 	orderRepo := pgxrepo.NewPgxOrdersRepository(log, gormDB)
-	orderUseCase := usecases.NewOrdersUseCase(log, orderRepo, userUseCase, prodUseCase, paymenteUseCase)
+	orderUseCase := usecases.NewOrdersUseCase(log, orderRepo, auditRepo, uow, userUseCase, prodUseCase, paymenteUseCase, bus)
+
+	webhooksRepo := pgxrepo.NewPgxWebhooksRepository(gormDB, log)
+	webhooksUseCase := usecases.NewWebhooksUseCase(log, webhooksRepo, auditRepo, userUseCase, messageBroker)
+	go deliverWebhooks(ctx, usecases.NewWebhookDispatcher(log, webhooksRepo, webhooks.NewHTTPSender(webhookSendTimeout)))
+
+	outboxRelay := usecases.NewOutboxRelay(log, pgxrepo.NewPgxOutboxRepository(gormDB, log), bus, messageBroker)
+	go relayOutboxEvents(ctx, outboxRelay)
+
+	catalogUseCase := usecases.NewCatalogUseCase(log, catRepo, prodRepo, auditRepo, prodUseCase, userUseCase, uow)
+
+	dataRequestsRepo := pgxrepo.NewPgxDataSubjectRequestsRepository(gormDB, log)
+	privacyUseCase := usecases.NewPrivacyUseCase(log, userRepo, orderRepo, paymentRepo, dataRequestsRepo, auditRepo, userUseCase)
+
+	apiKeysRepo := pgxrepo.NewPgxAPIKeysRepository(gormDB, log)
+	apiKeysUseCase := usecases.NewAPIKeysUseCase(log, apiKeysRepo, auditRepo, userUseCase)
+
+	auditUseCase := usecases.NewAuditUseCase(log, auditRepo, userUseCase)
+
+	restful.DefaultContainer.ServiceErrorHandler(httphandlers.ServiceErrorHandler)
 
 	ws := new(restful.WebService)
 	ws.
 		Path("/v1").
 		Consumes(restful.MIME_JSON).
-		Produces(restful.MIME_JSON)
+		Produces(restful.MIME_JSON).
+		Filter(httphandlers.NewRequestIDFilter()).
+		Filter(httphandlers.NewAuthFilter(authUseCase, apiKeysUseCase))
 
+	httphandlers.NewAuthHttpHandler(ctx, authUseCase, ws)
 	httphandlers.NewProductsHttpHandler(ctx, prodUseCase, ws)
 	httphandlers.NewUserHandler(ctx, userUseCase, ws)
 	httphandlers.NewCategoriesHttpHandler(ctx, catUseCase, ws)
 	httphandlers.NewPaymentsHttpHandler(ctx, paymenteUseCase, ws)
 	httphandlers.NewOrdersHttpHandler(ctx, orderUseCase, ws)
+	httphandlers.NewCatalogHttpHandler(ctx, catalogUseCase, ws)
+	httphandlers.NewPrivacyHttpHandler(ctx, privacyUseCase, ws)
+	httphandlers.NewAPIKeysHttpHandler(ctx, apiKeysUseCase, ws)
+	httphandlers.NewAuditHttpHandler(ctx, auditUseCase, ws)
+	httphandlers.NewWebhooksHttpHandler(ctx, webhooksUseCase, ws)
 
 	restful.Add(ws)
 
+	// v2 takes IDs in the path and listings in the query string, v1 stays for the clients already using it
+	wsV2 := new(restful.WebService)
+	wsV2.
+		Path("/v2").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON).
+		Filter(httphandlers.NewRequestIDFilter()).
+		Filter(httphandlers.NewAuthFilter(authUseCase, apiKeysUseCase))
+
+	httphandlers.NewProductsV2HttpHandler(ctx, prodUseCase, wsV2)
+	httphandlers.NewUserV2Handler(ctx, userUseCase, wsV2)
+	httphandlers.NewCategoriesV2HttpHandler(ctx, catUseCase, wsV2)
+	httphandlers.NewOrdersV2HttpHandler(ctx, orderUseCase, wsV2)
+
+	restful.Add(wsV2)
+
 	// Configure Swagger and Redirect / to /apidocs/
 	configureSwagger()
 
-	log.Info("listening...")
-	log.Panic(http.ListenAndServe(binding, nil))
+	server := &http.Server{Addr: binding}
+	go func() {
+		log.Info("listening...")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Panic(err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdown(server, bus, messageBroker)
+}
+
+// newMessageBroker connects to RabbitMQ, or keeps the events within the process when no broker is set.
+func newMessageBroker() ports.MessageBroker {
+	if helpers.BrokerURL() == "" {
+		log.Info("BROKER_URL not set, events are not published to other services")
+		return broker.NewMemoryBroker(log)
+	}
+
+	messageBroker, err := broker.NewRabbitMQBroker(log, helpers.BrokerURL(), helpers.BrokerExchange())
+	if err != nil {
+		panic(err)
+	}
+	return messageBroker
+}
+
+// shutdown stops taking requests, then lets the event bus deliver the events published by the last ones.
+func shutdown(server *http.Server, bus ports.EventBus, messageBroker ports.MessageBroker) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	log.Info("shutting down...")
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorw(
+			"failed shutting down http server",
+			zap.Error(err),
+		)
+	}
+	if err := bus.Close(ctx); err != nil {
+		log.Errorw(
+			"failed draining event bus",
+			zap.Error(err),
+		)
+	}
+	if err := messageBroker.Close(); err != nil {
+		log.Errorw(
+			"failed closing message broker",
+			zap.Error(err),
+		)
+	}
+}
+
+// applyScheduledPrices periodically applies the scheduled product price changes that became due.
+func applyScheduledPrices(ctx context.Context, prodUseCase ports.ProductsUseCase) {
+	ticker := time.NewTicker(scheduledPricesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := prodUseCase.ApplyScheduledPrices(ctx); err != nil {
+				log.Errorw(
+					"failed applying scheduled prices",
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// relayOutboxEvents periodically delivers the events saved to the outbox.
+func relayOutboxEvents(ctx context.Context, relay ports.OutboxRelay) {
+	ticker := time.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := relay.RelayOutboxEvents(ctx); err != nil {
+				log.Errorw(
+					"failed relaying outbox events",
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// deliverWebhooks periodically sends the due webhook deliveries to the partners.
+func deliverWebhooks(ctx context.Context, dispatcher ports.WebhookDispatcher) {
+	ticker := time.NewTicker(webhookDeliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := dispatcher.DeliverWebhooks(ctx); err != nil {
+				log.Errorw(
+					"failed delivering webhooks",
+					zap.Error(err),
+				)
+			}
+		}
+	}
 }
 
 func configureSwagger() {
@@ -113,9 +303,17 @@ func enrichSwaggerObject(swo *spec.Swagger) {
 			Version:     "1.0.0",
 		},
 	}
+	swo.SecurityDefinitions = spec.SecurityDefinitions{
+		"bearer":  spec.APIKeyAuth("Authorization", "header"),
+		"api_key": spec.APIKeyAuth("X-API-Key", "header"),
+	}
+	swo.Security = []map[string][]string{{"bearer": {}}, {"api_key": {}}}
 	swo.Tags = []spec.Tag{spec.Tag{TagProps: spec.TagProps{
-		Name:        "users",
-		Description: "Gerência de Clientes"}},
+		Name:        "auth",
+		Description: "Autenticação. Envie o token obtido no login como \"Authorization: Bearer <token>\""}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "users",
+			Description: "Gerência de Clientes"}},
 		spec.Tag{TagProps: spec.TagProps{
 			Name:        "categories",
 			Description: "Gerência de Categorias"}},
@@ -127,5 +325,20 @@ func enrichSwaggerObject(swo *spec.Swagger) {
 			Description: "Gerência de Pedidos"}},
 		spec.Tag{TagProps: spec.TagProps{
 			Name:        "payments",
-			Description: "Gerência de Pagamentos"}}}
+			Description: "Gerência de Pagamentos"}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "catalog",
+			Description: "Importação e exportação do catálogo"}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "privacy",
+			Description: "Direitos do titular dos dados (LGPD): exportação e anonimização"}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "api-keys",
+			Description: "Chaves de API de dispositivos. Envie a chave como \"X-API-Key: <chave>\""}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "audit",
+			Description: "Log de auditoria das ações administrativas"}},
+		spec.Tag{TagProps: spec.TagProps{
+			Name:        "webhooks",
+			Description: "Notificação de parceiros sobre eventos de pedidos e pagamentos"}}}
 }
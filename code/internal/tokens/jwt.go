@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const issuer = "tech-challenge"
+
+// claims are the registered JWT claims, with the subject being a guest session ID when Guest is set.
+type claims struct {
+	jwt.RegisteredClaims
+	Guest bool `json:"guest,omitempty"`
+}
+
+type jwtSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewJWTSigner signs HS256 tokens whose subject is the caller's user or guest session ID.
+func NewJWTSigner(secret []byte, ttl time.Duration) ports.TokenSigner {
+	return &jwtSigner{secret: secret, ttl: ttl}
+}
+
+func (s *jwtSigner) Sign(caller domain.Caller) (*domain.AuthToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	subject := caller.UserID
+	if caller.IsGuest() {
+		subject = caller.GuestSessionID
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Guest: caller.IsGuest(),
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthToken{
+		Token:          token,
+		UserID:         caller.UserID,
+		GuestSessionID: caller.GuestSessionID,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+func (s *jwtSigner) Verify(token string) (*domain.Caller, error) {
+	tokenClaims := claims{}
+	_, err := jwt.ParseWithClaims(token, &tokenClaims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", helpers.ErrUnauthorized, err)
+	}
+
+	subject, err := uuid.Parse(tokenClaims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid token subject", helpers.ErrUnauthorized)
+	}
+
+	if tokenClaims.Guest {
+		return &domain.Caller{GuestSessionID: subject}, nil
+	}
+	return &domain.Caller{UserID: subject}, nil
+}
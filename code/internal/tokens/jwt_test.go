@@ -0,0 +1,65 @@
+package tokens
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/helpers"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestJWTSigner(t *testing.T) {
+	caller := domain.Caller{UserID: uuid.New()}
+	signer := NewJWTSigner([]byte("secret"), time.Hour)
+
+	valid, err := signer.Sign(caller)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	expired, err := NewJWTSigner([]byte("secret"), -time.Hour).Sign(caller)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	otherKey, err := NewJWTSigner([]byte("other"), time.Hour).Sign(caller)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	guestCaller := domain.Caller{GuestSessionID: uuid.New()}
+	guest, err := signer.Sign(guestCaller)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    domain.Caller
+		wantErr bool
+	}{
+		{name: "001_should_verify_signed_token", token: valid.Token, want: caller},
+		{name: "002_should_refuse_expired_token", token: expired.Token, wantErr: true},
+		{name: "003_should_refuse_token_signed_with_other_key", token: otherKey.Token, wantErr: true},
+		{name: "004_should_refuse_garbage", token: "not-a-token", wantErr: true},
+		{name: "005_should_verify_guest_token", token: guest.Token, want: guestCaller},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, helpers.ErrUnauthorized) {
+					t.Errorf("Verify() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Verify() got = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/broker"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/ports"
+)
+
+const (
+	userAgent = "lanchonete-webhooks/1"
+	// maxResponseBody is how much of the partner answer is read, the rest is dropped with the connection.
+	maxResponseBody = 64 << 10
+)
+
+type httpSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender posts the deliveries as the JSON event envelope, the same sent to the message broker,
+// signed in the X-Lanchonete-Signature header. A partner slower than timeout counts as a failure.
+func NewHTTPSender(timeout time.Duration) ports.WebhookSender {
+	return &httpSender{client: &http.Client{
+		Timeout: timeout,
+		// a redirect would send the signed payload somewhere the partner did not register
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (s *httpSender) Send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	body, err := broker.EncodeEnvelope(delivery.Envelope)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", broker.CONTENT_TYPE)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(domain.WEBHOOK_EVENT_HEADER, string(delivery.Envelope.Type))
+	req.Header.Set(domain.WEBHOOK_DELIVERY_HEADER, delivery.ID.String())
+	req.Header.Set(domain.WEBHOOK_SIGNATURE_HEADER, domain.SignWebhook(subscription.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("partner answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SOAT1StackGoLang/tech-challenge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestHTTPSender(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "001_should_deliver_on_2xx", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "002_should_fail_on_5xx", status: http.StatusServiceUnavailable, wantStatus: http.StatusServiceUnavailable, wantErr: true},
+		{name: "003_should_fail_on_redirect", status: http.StatusFound, wantStatus: http.StatusFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := domain.NewWebhookSubscription(uuid.New(), "", []domain.EventType{domain.EVENT_ORDER_CREATED}, "0123456789abcdef", uuid.New(), time.Now())
+			delivery := domain.NewWebhookDelivery(uuid.New(), subscription.ID, domain.EventEnvelope{
+				ID:      uuid.New(),
+				Type:    domain.EVENT_ORDER_CREATED,
+				Version: domain.EVENT_ENVELOPE_VERSION,
+				Data:    []byte(`{"order_id":"1"}`),
+			}, time.Now())
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				signature := r.Header.Get(domain.WEBHOOK_SIGNATURE_HEADER)
+				timestamp, _ := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+				if want := domain.SignWebhook(subscription.Secret, time.Unix(timestamp, 0), body); signature != want {
+					t.Errorf("signature = %v, want %v", signature, want)
+				}
+				if got := r.Header.Get(domain.WEBHOOK_DELIVERY_HEADER); got != delivery.ID.String() {
+					t.Errorf("delivery header = %v, want %v", got, delivery.ID)
+				}
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "http://example.com")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			subscription.URL = server.URL
+
+			status, err := NewHTTPSender(time.Second).Send(context.Background(), subscription, delivery)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Send() status = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ function run_fase_2() {
     ## check if the api is working
     echo -e "\n---------------------------------------------------------------"
     check_api
+    login_admin
 
     # Create 6 orders
     echo -e "\n---------------------------------------------------------------"
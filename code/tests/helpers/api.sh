@@ -8,6 +8,9 @@ API_URL="http://localhost:8000"
 
 ## Default app values
 USER_ID="123e4567-e89b-12d3-a456-426614174000"
+ADMIN_DOCUMENT="97580053080"
+ADMIN_PASSWORD="local-only-admin-password"
+TOKEN=""
 
 ## colors
 RED='\033[0;31m'
@@ -24,8 +27,13 @@ function make_request() {
     local method=$1
     local endpoint=$2
     local body=$3
+    local auth=()
+    if [ -n "$TOKEN" ]; then
+        auth=(--header "Authorization: Bearer $TOKEN")
+    fi
     local response=$(curl -s -X "$method" --location "$API_URL$endpoint" \
         --header 'Content-Type: application/json' \
+        "${auth[@]}" \
         --data-raw "$body")
     local response_code=$?
     if [ "$response_code" -ne 0 ]; then
@@ -50,8 +58,14 @@ function get_admin_user() {
     make_request "POST" "/v1/users/validate" '{"document": "97580053080"}'
 }
 
+## This function will log in as the admin user and keep the token for the next requests
+function login_admin() {
+    echo -e "${YELLOW}Logging in as admin${NC}"
+    TOKEN=$(make_request "POST" "/v1/auth/login" "{\"document\": \"$ADMIN_DOCUMENT\", \"password\": \"$ADMIN_PASSWORD\"}" | jq -r '.token')
+}
+
 ## This function will create a product under drink category with default values
-## values are name, description, price, category_id and user_id
+## values are name, description, price and category_id
 ## It will return the response body
 CATEGORY_ID_DRINK="a557b0c0-3bcf-11ee-be56-0242ac120002"
 
@@ -62,7 +76,7 @@ function create_product_drink() {
         echo -e "${YELLOW}Product id: $PRODUCT_ID${NC}"
         return
     fi
-    make_request "POST" "/v1/products" "{\"name\": \"Coca Cola Test\", \"description\": \"Coca Cola 350ml\", \"price\": \"5.00\", \"category_id\": \"$CATEGORY_ID_DRINK\", \"user_id\": \"$USER_ID\"}"
+    make_request "POST" "/v1/products" "{\"name\": \"Coca Cola Test\", \"description\": \"Coca Cola 350ml\", \"price\": \"5.00\", \"category_id\": \"$CATEGORY_ID_DRINK\"}"
 }
 
 ## this function will get the list of products from drink category
@@ -87,13 +101,13 @@ function create_order_default() {
     fi
     #echo -e "${YELLOW}Creating order${NC}"
     #echo -e "${YELLOW}Product ids: $product_ids${NC}"
-    payload="{\"products_ids\": [$product_ids], \"user_id\": \"$USER_ID\"}"
+    payload="{\"products_ids\": [$product_ids]}"
     order=$(make_request "POST" "/v1/orders" "$payload")
     echo "$order"
 }
 
 ## Create checkout will recevie the order_id or a list of order_id as parameter
-## will do a for each to create the checkout for each order_id, as json order_id and user_id
+## will do a for each to create the checkout for each order_id, as json order_id
 ## It will return the response body for each order_id with the return of the checkout
 
 function create_checkout() {
@@ -102,7 +116,7 @@ function create_checkout() {
         order_id=$(create_order_default | jq '.id')
     fi
     #echo -e "${YELLOW}Creating checkout${NC}"
-    payload="{\"order_id\": $order_id, \"user_id\": \"$USER_ID\"}"
+    payload="{\"order_id\": $order_id}"
     checkout=$(make_request "POST" "/v1/orders/checkout" "$payload")
     echo "$checkout"
 }
@@ -142,27 +156,25 @@ function pay_order() {
 }
 
 ## this function will move the order to the next status /v1/orders/status-update
-## will receive the order_id and status as parameters and user_id as optional
+## will receive the order_id and status as parameters
 ## will return the response body
 
 function move_order_to_next_status() {
     local order_id=$1
     local status=$2
-    local user_id=${3:-$USER_ID}
     #echo -e "${YELLOW}Moving order to next status${NC}"
-    payload="{\"order_id\": \"$order_id\", \"status\": \"$status\", \"user_id\": \"$user_id\"}"
+    payload="{\"order_id\": \"$order_id\", \"status\": \"$status\"}"
     status_update=$(make_request "PUT" "/v1/orders/status-update" "$payload")
     echo "$status_update"
 }
 
 ## this function will list all orders /v1/orders/all
-## will receive the user_id as optional parameter
+## uses the token of the last login
 ## will return the response body
 
 function list_all_orders() {
-    local user_id=${1:-$USER_ID}
     #echo -e "${YELLOW}Listing all orders${NC}"
-    payload="{\"limit\": 10, \"offset\": 0, \"user_id\": \"$user_id\"}"
+    payload="{\"limit\": 10, \"offset\": 0}"
     orders=$(make_request "POST" "/v1/orders/all" "$payload")
     echo "$orders" | jq -r '.orders'
 }